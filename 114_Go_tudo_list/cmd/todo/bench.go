@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"todo-app/internal/app"
+	"todo-app/internal/config"
+	"todo-app/internal/domain"
+)
+
+// runBench handles `todo bench --count N`. It inserts N todos row by row and N
+// more with one bulk write against the configured storage, prints the throughput
+// of each, then deletes what it created.
+func runBench(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	count := fs.Int("count", 2000, "number of todos per method")
+	keep := fs.Bool("keep", false, "keep the benchmark todos instead of deleting them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *count <= 0 {
+		return fmt.Errorf("--count must be positive")
+	}
+
+	store, err := app.OpenStorage(ctx, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	generate := func() []*domain.Todo {
+		todos := make([]*domain.Todo, *count)
+		for i := range todos {
+			todos[i] = newSeedTodo(rng, 0.3)
+		}
+		return todos
+	}
+
+	single := generate()
+	start := time.Now()
+	for i, todo := range single {
+		if err := store.Todos.Create(ctx, todo); err != nil {
+			return fmt.Errorf("row-by-row insert %d: %v", i+1, err)
+		}
+	}
+	singleTime := time.Since(start)
+
+	bulk := generate()
+	start = time.Now()
+	if err := store.Todos.CreateMany(ctx, bulk); err != nil {
+		return fmt.Errorf("bulk insert: %v", err)
+	}
+	bulkTime := time.Since(start)
+
+	fmt.Printf("storage: %s\n", cfg.Storage.Driver)
+	printThroughput("row-by-row Create", *count, singleTime)
+	printThroughput("bulk CreateMany", *count, bulkTime)
+	if bulkTime > 0 {
+		fmt.Printf("speedup: %.1fx\n", singleTime.Seconds()/bulkTime.Seconds())
+	}
+
+	if *keep {
+		return nil
+	}
+	for _, todo := range append(single, bulk...) {
+		if err := store.Todos.Delete(ctx, todo.ID); err != nil {
+			return fmt.Errorf("failed to clean up todo %s: %v", todo.ID, err)
+		}
+	}
+	return nil
+}
+
+func printThroughput(name string, count int, elapsed time.Duration) {
+	fmt.Printf("%-18s %6d todos in %-12s %10.0f todos/s\n",
+		name+":", count, elapsed.Round(time.Millisecond), float64(count)/elapsed.Seconds())
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"todo-app/internal/config"
+)
+
+// runConfig handles `todo config print`
+func runConfig(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) < 1 || args[0] != "print" {
+		return fmt.Errorf("usage: todo config print [--redacted] [--format yaml|env]")
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := fs.Bool("redacted", false, "replace passwords and secrets with REDACTED")
+	format := fs.String("format", "yaml", "output format: yaml (a valid config file) or env")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	value := func(v config.Value) string {
+		if *redacted {
+			return v.Redacted()
+		}
+		return v.Value
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	switch *format {
+	case "yaml":
+		fmt.Fprintf(w, "# Effective configuration for the %s profile; comments show where each value came from\n", cfg.Env)
+		section := ""
+		for _, v := range cfg.Values() {
+			group, key, _ := strings.Cut(v.Path, ".")
+			if group != section {
+				section = group
+				fmt.Fprintf(w, "%s:\n", section)
+			}
+			fmt.Fprintf(w, "  %s: %s # %s\n", key, yamlScalar(value(v)), v.Source)
+		}
+	case "env":
+		for _, v := range cfg.Values() {
+			fmt.Fprintf(w, "%s=%s\n", v.Env, envValue(value(v)))
+		}
+	default:
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+	return w.Flush()
+}
+
+// yamlScalar leaves numbers and booleans bare and quotes everything else
+func yamlScalar(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// envValue quotes values that .env parsing would otherwise split or truncate
+func envValue(value string) string {
+	if strings.ContainsAny(value, " \t#\"'\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"todo-app/internal/config"
+)
+
+const usage = `Usage: todo [--config FILE] [--<setting> VALUE ...] <command> [options]
+
+Commands:
+  serve                                Run the HTTP API (migrates first)
+  migrate up|down <N>|status           Manage database migrations
+  seed [--count N]                     Generate realistic fake todos
+  export [--format json|csv] [--output FILE]
+                                       Write all todos to a file (default stdout)
+  import [--format json|csv] --input FILE
+                                       Create todos from a file
+  users create|disable|reset-password|token --email EMAIL [--password PASSWORD] [--ttl DURATION]
+                                       Administer user accounts
+  bench [--count N] [--keep]           Compare row-by-row and bulk insert throughput
+  config print [--redacted] [--format yaml|env]
+                                       Show the effective configuration and where each value came from
+
+Configuration is layered, later sources winning: built-in defaults, the YAML or
+TOML file given by --config or CONFIG_FILE, the environment and .env, then
+per-setting flags named after the file keys, e.g. --server.port 3000.`
+
+// command runs a subcommand with the remaining command-line arguments
+type command func(ctx context.Context, cfg *config.Config, args []string) error
+
+var commands = map[string]command{
+	"serve":   runServe,
+	"migrate": runMigrate,
+	"seed":    runSeed,
+	"export":  runExport,
+	"import":  runImport,
+	"users":   runUsers,
+	"bench":   runBench,
+	"config":  runConfig,
+}
+
+func main() {
+	log.SetFlags(0)
+
+	var opts config.Options
+	global := flag.NewFlagSet("todo", flag.ContinueOnError)
+	global.Usage = func() { fmt.Println(usage) }
+	opts.AddFlags(global)
+	if err := global.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+
+	args := global.Args()
+	if len(args) < 1 || args[0] == "help" {
+		fmt.Println(usage)
+		return
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", args[0], usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(opts)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd(ctx, cfg, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		stop()
+		log.Fatalf("Error: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"todo-app/internal/app"
+	"todo-app/internal/config"
+	"todo-app/internal/migrate"
+)
+
+// runMigrate handles `todo migrate up|down <N>|status`
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: todo migrate up|down <N>|status")
+	}
+
+	migrator, closeDB, err := app.OpenMigrator(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("migration failed: %v", err)
+		}
+		for _, m := range applied {
+			fmt.Printf("applied  %03d_%s\n", m.Version, m.Name)
+		}
+		return printVersion(ctx, migrator)
+
+	case "down":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: todo migrate down <N>")
+		}
+		target, err := strconv.Atoi(args[1])
+		if err != nil || target < 0 {
+			return fmt.Errorf("invalid target version: %q", args[1])
+		}
+		reverted, err := migrator.DownTo(ctx, target)
+		if err != nil {
+			return fmt.Errorf("rollback failed: %v", err)
+		}
+		for _, m := range reverted {
+			fmt.Printf("reverted %03d_%s\n", m.Version, m.Name)
+		}
+		return printVersion(ctx, migrator)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read migration status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Dirty {
+				state += " (modified since applied)"
+			}
+			fmt.Printf("%03d_%-30s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up, down or status)", args[0])
+	}
+}
+
+// printVersion prints the schema version currently applied to the database
+func printVersion(ctx context.Context, migrator *migrate.Migrator) error {
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema is at version %d (latest %d)\n", version, migrator.Latest())
+	return nil
+}
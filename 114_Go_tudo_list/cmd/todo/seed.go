@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"todo-app/internal/app"
+	"todo-app/internal/config"
+	"todo-app/internal/domain"
+	"todo-app/internal/service"
+)
+
+var (
+	seedVerbs = []string{
+		"Review", "Write", "Fix", "Plan", "Refactor", "Deploy", "Test", "Document",
+		"Schedule", "Prepare", "Update", "Clean up", "Research", "Call", "Buy", "Book",
+	}
+	seedObjects = []string{
+		"pull request for payment service", "quarterly report", "login page bug",
+		"sprint backlog", "database indexes", "staging environment", "release notes",
+		"onboarding guide", "dentist appointment", "groceries for the week",
+		"flight to Da Nang", "team retrospective", "API rate limits", "backup strategy",
+		"Kafka consumer lag alert", "Redis sentinel failover test", "SSL certificates",
+		"birthday gift for mom", "gym membership renewal", "monthly budget",
+	}
+	seedDetails = []string{
+		"",
+		"Check with the team before Friday.",
+		"Remember to attach the screenshots from last week.",
+		"Low effort, can be done in the evening.",
+		"Blocked until the vendor replies.",
+		"See the notes in the shared drive.",
+		"Follow up on the comments from the last review.",
+	}
+	seedPriorities = []string{"low", "medium", "medium", "high"}
+)
+
+// runSeed handles `todo seed --count N`
+func runSeed(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	count := fs.Int("count", 50, "number of todos to generate")
+	completedRatio := fs.Float64("completed", 0.3, "fraction of generated todos marked completed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *count <= 0 {
+		return fmt.Errorf("--count must be positive")
+	}
+
+	store, err := app.OpenStorage(ctx, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	todos := make([]*domain.Todo, *count)
+	for i := range todos {
+		todos[i] = newSeedTodo(rng, *completedRatio)
+	}
+
+	todoService := service.NewTodoService(store.Todos, store.UnitOfWork)
+	if _, err := todoService.ImportTodos(ctx, todos); err != nil {
+		return fmt.Errorf("failed to create todos: %v", err)
+	}
+
+	fmt.Printf("seeded %d todos\n", *count)
+	return nil
+}
+
+// newSeedTodo builds a random, realistic todo that is completed with probability completedRatio
+func newSeedTodo(rng *rand.Rand, completedRatio float64) *domain.Todo {
+	todo := &domain.Todo{
+		Title:       seedVerbs[rng.Intn(len(seedVerbs))] + " " + seedObjects[rng.Intn(len(seedObjects))],
+		Description: seedDetails[rng.Intn(len(seedDetails))],
+		Priority:    seedPriorities[rng.Intn(len(seedPriorities))],
+		Completed:   rng.Float64() < completedRatio,
+	}
+	if rng.Float64() < 0.6 {
+		due := time.Now().Add(time.Duration(rng.Intn(37*24)-7*24) * time.Hour).Truncate(time.Hour)
+		todo.DueDate = &due
+	}
+	return todo
+}
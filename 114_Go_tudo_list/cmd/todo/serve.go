@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"todo-app/internal/app"
+	"todo-app/internal/config"
+)
+
+// runServe handles `todo serve`
+func runServe(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return app.Serve(ctx, cfg)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-app/internal/app"
+	"todo-app/internal/config"
+	"todo-app/internal/domain"
+	"todo-app/internal/service"
+)
+
+// csvHeader is the column layout used by export and expected by import
+var csvHeader = []string{"id", "title", "description", "completed", "priority", "due_date", "created_at", "updated_at"}
+
+// runExport handles `todo export`
+func runExport(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "", "json or csv (default: from the output file extension, else json)")
+	output := fs.String("output", "-", "file to write, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := resolveFormat(*format, *output)
+	if err != nil {
+		return err
+	}
+
+	store, err := app.OpenStorage(ctx, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	todoService := service.NewTodoService(store.Todos, store.UnitOfWork)
+	todos, err := todoService.GetAllTodos(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if f == "csv" {
+		err = writeCSV(w, todos)
+	} else {
+		err = writeJSON(w, todos)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write export: %v", err)
+	}
+
+	if *output != "-" {
+		fmt.Printf("exported %d todos to %s\n", len(todos), *output)
+	}
+	return nil
+}
+
+// runImport handles `todo import`
+func runImport(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "json or csv (default: from the input file extension, else json)")
+	input := fs.String("input", "", "file to read, - for stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return fmt.Errorf("--input is required")
+	}
+
+	f, err := resolveFormat(*format, *input)
+	if err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if *input != "-" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var todos []*domain.Todo
+	if f == "csv" {
+		todos, err = readCSV(r)
+	} else {
+		todos, err = readJSON(r)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", *input, err)
+	}
+
+	store, err := app.OpenStorage(ctx, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	todoService := service.NewTodoService(store.Todos, store.UnitOfWork)
+	imported, err := todoService.ImportTodos(ctx, todos)
+	if err != nil {
+		return fmt.Errorf("imported %d of %d todos: %v", imported, len(todos), err)
+	}
+
+	fmt.Printf("imported %d todos\n", imported)
+	return nil
+}
+
+// resolveFormat picks the file format from the flag or the file extension
+func resolveFormat(format, path string) (string, error) {
+	if format == "" {
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			return "csv", nil
+		}
+		return "json", nil
+	}
+	if format != "json" && format != "csv" {
+		return "", fmt.Errorf("unsupported format %q (use json or csv)", format)
+	}
+	return format, nil
+}
+
+func writeJSON(w io.Writer, todos []*domain.Todo) error {
+	responses := make([]*domain.TodoResponse, len(todos))
+	for i, todo := range todos {
+		responses[i] = todo.ToResponse()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(responses)
+}
+
+func readJSON(r io.Reader) ([]*domain.Todo, error) {
+	var responses []*domain.TodoResponse
+	if err := json.NewDecoder(r).Decode(&responses); err != nil {
+		return nil, err
+	}
+
+	todos := make([]*domain.Todo, len(responses))
+	for i, resp := range responses {
+		todos[i] = &domain.Todo{
+			Title:       resp.Title,
+			Description: resp.Description,
+			Completed:   resp.Completed,
+			Priority:    resp.Priority,
+			DueDate:     resp.DueDate,
+		}
+	}
+	return todos, nil
+}
+
+func writeCSV(w io.Writer, todos []*domain.Todo) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, todo := range todos {
+		dueDate := ""
+		if todo.DueDate != nil {
+			dueDate = todo.DueDate.Format(time.RFC3339)
+		}
+		record := []string{
+			todo.ID.String(),
+			todo.Title,
+			todo.Description,
+			strconv.FormatBool(todo.Completed),
+			todo.Priority,
+			dueDate,
+			todo.CreatedAt.Format(time.RFC3339),
+			todo.UpdatedAt.Format(time.RFC3339),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func readCSV(r io.Reader) ([]*domain.Todo, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("missing title column")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	todos := make([]*domain.Todo, 0, len(records)-1)
+	for line, record := range records[1:] {
+		todo := &domain.Todo{
+			Title:       field(record, "title"),
+			Description: field(record, "description"),
+			Priority:    field(record, "priority"),
+		}
+		if v := field(record, "completed"); v != "" {
+			if todo.Completed, err = strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("line %d: invalid completed value %q", line+2, v)
+			}
+		}
+		if v := field(record, "due_date"); v != "" {
+			due, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid due_date %q", line+2, v)
+			}
+			todo.DueDate = &due
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+
+	"todo-app/internal/app"
+	"todo-app/internal/auth"
+	"todo-app/internal/config"
+	"todo-app/internal/repository/postgres"
+	"todo-app/internal/service"
+)
+
+// runUsers handles `todo users create|disable|reset-password|token`
+func runUsers(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: todo users create|disable|reset-password|token --email EMAIL [--password PASSWORD] [--ttl DURATION]")
+	}
+
+	action := args[0]
+	fs := flag.NewFlagSet("users "+action, flag.ContinueOnError)
+	email := fs.String("email", "", "user email")
+	password := fs.String("password", "", "new password (generated and printed if omitted)")
+	ttl := fs.Duration("ttl", cfg.JWT.TTL, "lifetime of the issued token")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *email == "" {
+		return fmt.Errorf("--email is required")
+	}
+
+	pool, err := app.OpenPostgres(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	userRepo := postgres.NewUserRepository(pool)
+	userService := service.NewUserService(userRepo)
+
+	switch action {
+	case "create", "reset-password":
+		generated := *password == ""
+		if generated {
+			if *password, err = generatePassword(); err != nil {
+				return err
+			}
+		}
+
+		if action == "create" {
+			user, err := userService.CreateUser(ctx, *email, *password)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("created user %s (%s)\n", user.Email, user.ID)
+		} else {
+			user, err := userService.ResetPassword(ctx, *email, *password)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("reset password for %s\n", user.Email)
+		}
+
+		if generated {
+			fmt.Printf("password: %s\n", *password)
+		}
+		return nil
+
+	case "disable":
+		user, err := userService.DisableUser(ctx, *email)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("disabled user %s\n", user.Email)
+		return nil
+
+	case "token":
+		user, err := userRepo.GetByEmail(ctx, *email)
+		if err != nil {
+			return err
+		}
+		if user.Disabled {
+			return fmt.Errorf("user %s is disabled", user.Email)
+		}
+		token, err := auth.NewVerifier(cfg.JWT.Secret, nil, false, nil).IssueToken(user.ID.String(), *ttl)
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown users command %q (use create, disable, reset-password or token)", action)
+	}
+}
+
+// generatePassword returns a random URL-safe password
+func generatePassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"todo-app/internal/config"
+	"todo-app/internal/logging"
+	"todo-app/internal/worker"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce collapses the burst of events one save produces into a single reload
+const reloadDebounce = 500 * time.Millisecond
+
+// watchConfig reloads live on SIGHUP and whenever its config file changes
+func watchConfig(live *config.Live) worker.Func {
+	return func(ctx context.Context) error {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+
+		var events <-chan fsnotify.Event
+		var errs <-chan error
+		file := live.File()
+		if file != "" {
+			watcher, err := fsnotify.NewWatcher()
+			if err != nil {
+				return fmt.Errorf("failed to watch config file: %v", err)
+			}
+			defer watcher.Close()
+
+			// Watch the directory: editors and Kubernetes ConfigMaps replace the
+			// file rather than write it in place, which drops a watch on the file
+			if err := watcher.Add(filepath.Dir(file)); err != nil {
+				return fmt.Errorf("failed to watch config file: %v", err)
+			}
+			events, errs = watcher.Events, watcher.Errors
+			logging.Infof("Watching %s for configuration changes", file)
+		}
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-hup:
+				logging.Infof("SIGHUP received, reloading configuration")
+				reloadConfig(live)
+			case event := <-events:
+				// ConfigMaps swap a ..data symlink instead of touching the file
+				if name := filepath.Base(event.Name); name == filepath.Base(file) || name == "..data" {
+					logging.Debugf("Config file event: %s", event)
+					debounce = time.After(reloadDebounce)
+				}
+			case err := <-errs:
+				logging.Warnf("Config file watcher error: %v", err)
+			case <-debounce:
+				debounce = nil
+				reloadConfig(live)
+			}
+		}
+	}
+}
+
+// reloadConfig reloads live and logs what changed and what was refused
+func reloadConfig(live *config.Live) {
+	applied, rejected, err := live.Reload()
+	if err != nil {
+		logging.Errorf("Config reload failed, keeping version %d: %v", live.Snapshot().Version, err)
+		return
+	}
+
+	for _, c := range rejected {
+		logging.Warnf("Config reload: not changing %s from %q to %q, it needs a restart", c.Env, c.From, c.To)
+	}
+	if len(applied) == 0 {
+		logging.Infof("Config reloaded, no runtime setting changed (version %d)", live.Snapshot().Version)
+		return
+	}
+	changes := make([]string, len(applied))
+	for i, c := range applied {
+		changes[i] = fmt.Sprintf("%s %q -> %q", c.Env, c.From, c.To)
+	}
+	// A warning so the change is logged even when it raises the log level
+	logging.Warnf("Configuration version %d applied: %s", live.Snapshot().Version, strings.Join(changes, ", "))
+}
+
+// applyLogLevel sets the log level to LOG_LEVEL, which Load has validated
+func applyLogLevel(cfg *config.Config) {
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	logging.SetLevel(level)
+}
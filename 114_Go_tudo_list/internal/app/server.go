@@ -0,0 +1,298 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/config"
+	"todo-app/internal/events"
+	"todo-app/internal/graphql"
+	"todo-app/internal/grpc"
+	"todo-app/internal/handler"
+	"todo-app/internal/idempotency"
+	"todo-app/internal/logging"
+	"todo-app/internal/ratelimit"
+	"todo-app/internal/repository/cache"
+	"todo-app/internal/service"
+	"todo-app/internal/telemetry"
+	"todo-app/internal/worker"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Serve opens the configured storage, migrating it if needed, and runs the HTTP
+// API, the admin endpoints and the gRPC API on their own ports, until ctx is
+// cancelled. It then drains in-flight requests and releases resources within
+// the shutdown timeout.
+// Runtime settings are reloaded on SIGHUP and when the config file changes.
+func Serve(ctx context.Context, cfg *config.Config) error {
+	applyLogLevel(cfg)
+
+	// Every resource is released by a deferred call registered as soon as it
+	// is acquired, so an early return leaks nothing. The calls share one
+	// shutdown timeout, starting when the first of them needs it.
+	var stopCtx context.Context
+	stopCancel := context.CancelFunc(func() {})
+	defer func() { stopCancel() }()
+	shutdownCtx := func() context.Context {
+		if stopCtx == nil {
+			stopCtx, stopCancel = context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		}
+		return stopCtx
+	}
+	started := false
+	defer func() {
+		if started {
+			logging.Infof("Server stopped")
+		}
+	}()
+
+	// Initialize tracing
+	shutdownTracing, err := telemetry.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(shutdownCtx()); err != nil {
+			logging.Errorf("Failed to shut down tracing: %v", err)
+		}
+	}()
+
+	// Open the configured storage backend and run migrations
+	store, err := OpenStorage(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			logging.Errorf("Failed to close storage: %v", err)
+		}
+	}()
+
+	// Initialize service, publishing its changes for GraphQL subscriptions and gRPC watchers
+	broker := events.NewBroker()
+	todoService := events.NewTodoService(service.NewTodoService(store.Todos, store.UnitOfWork), broker)
+
+	// Background workers
+	workers := worker.NewGroup()
+	defer func() {
+		if err := workers.Stop(shutdownCtx()); err != nil {
+			logging.Errorf("Failed to stop background workers: %v", err)
+		}
+	}()
+	for name, fn := range store.Workers {
+		workers.Go(name, fn)
+	}
+
+	// Apply reloaded runtime settings to the logger and storage; middleware
+	// reads them from live on every request
+	live := config.NewLive(cfg)
+	live.OnChange(applyLogLevel)
+	for _, fn := range store.OnReload {
+		live.OnChange(fn)
+	}
+	workers.Go("config-reload", watchConfig(live))
+
+	// Liveness and readiness probes
+	checks := append(store.Checks, handler.WorkersCheck(workers))
+	healthHandler := handler.NewHealthHandler(cfg.Server.HealthTimeout, checks...)
+
+	// Rate limit buckets, shared between instances when kept in Redis
+	limiter, closeLimiter := openRateLimiter(cfg)
+	defer closeLimiter()
+
+	// Responses saved under Idempotency-Key headers
+	keys, closeKeys := openIdempotencyStore(cfg, store, workers)
+	defer closeKeys()
+
+	// Credentials accepted by the REST, GraphQL and gRPC APIs; JWTs of
+	// disabled or changed users are refused when the backend stores users
+	verifier := auth.NewVerifier(cfg.JWT.Secret, cfg.Auth.APITokens, cfg.Auth.Required, store.Users)
+
+	// GraphQL endpoint, with the queries clients persisted
+	queries, closeQueries := openPersistedQueryStore(cfg)
+	defer closeQueries()
+	gql, err := graphql.NewServer(todoService, broker, graphql.Options{
+		MaxDepth:          cfg.GraphQL.MaxDepth,
+		MaxComplexity:     cfg.GraphQL.MaxComplexity,
+		PersistedQueries:  queries,
+		PersistedQueryTTL: cfg.GraphQL.PersistedQueryTTL,
+		AllowedOrigins: func() []string {
+			return live.Current().CORS.AllowedOrigins
+		},
+		Verifier: verifier,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Initialize router
+	router := handler.NewRouter(todoService, gql, healthHandler, verifier, limiter, keys, live)
+	r := router.SetupRoutes()
+
+	// Start server
+	serverAddr := cfg.Server.GetServerAddr()
+	srv := &http.Server{
+		Addr:              serverAddr,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+	// Shutdown does not wait for hijacked WebSocket connections, so close them
+	srv.RegisterOnShutdown(gql.Shutdown)
+
+	// Operator endpoints, on their own listener kept off the public port
+	adminAddr := cfg.Admin.GetAddr()
+	adminSrv := &http.Server{
+		Addr:              adminAddr,
+		Handler:           router.SetupAdminRoutes(),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+	}
+	if ip := net.ParseIP(cfg.Admin.Host); cfg.Admin.Host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		logging.Warnf("Admin endpoints listen on %s, which is not a loopback address; keep that port off public networks", adminAddr)
+	}
+
+	// gRPC server, sharing authentication and the api and auth rate limit groups with REST
+	var grpcServer *grpc.Server
+	grpcAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.GRPC.Port)
+	if cfg.GRPC.Enabled {
+		grpcServer = grpc.NewServer(todoService, broker, grpc.Options{
+			MaxRecvBytes: int(cfg.Server.MaxBodyBytes),
+			Reflection:   cfg.GRPC.Reflection,
+			Limiter:      limiter,
+			Limit: func(group string) (ratelimit.Limit, bool) {
+				limit, ok := live.Current().RateLimit.Limits[group]
+				return ratelimit.Limit{Requests: limit.Requests, Window: limit.Window}, ok
+			},
+			Verifier: verifier,
+		})
+	}
+
+	logging.Infof("Starting server on %s", serverAddr)
+	logging.Infof("Liveness: http://%s/livez", serverAddr)
+	logging.Infof("Readiness: http://%s/readyz", serverAddr)
+	logging.Infof("API docs: http://%s/api/v1/todos", serverAddr)
+	logging.Infof("Admin: http://%s/admin/config", adminAddr)
+	if grpcServer != nil {
+		logging.Infof("gRPC: %s", grpcAddr)
+	}
+
+	started = true
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	adminErr := make(chan error, 1)
+	go func() {
+		if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			adminErr <- err
+		}
+	}()
+
+	grpcErr := make(chan error, 1)
+	if grpcServer != nil {
+		go func() {
+			lis, err := net.Listen("tcp", grpcAddr)
+			if err == nil {
+				err = grpcServer.Serve(lis)
+			}
+			if err != nil {
+				grpcErr <- err
+			}
+		}()
+	}
+
+	var runErr error
+	select {
+	case err := <-serverErr:
+		runErr = fmt.Errorf("failed to start server: %v", err)
+	case err := <-adminErr:
+		runErr = fmt.Errorf("failed to start admin server: %v", err)
+	case err := <-grpcErr:
+		runErr = fmt.Errorf("failed to start gRPC server: %v", err)
+	case <-ctx.Done():
+		logging.Infof("Shutdown signal received, draining connections (timeout %s)", cfg.Server.ShutdownTimeout)
+	}
+
+	// Report not-ready while draining so the load balancer stops sending traffic
+	healthHandler.SetShuttingDown()
+
+	// Drain in-flight requests; the deferred calls then stop the workers and
+	// release the stores, storage and tracing
+	if err := srv.Shutdown(shutdownCtx()); err != nil {
+		logging.Errorf("Failed to drain HTTP connections: %v", err)
+	}
+	if err := adminSrv.Shutdown(shutdownCtx()); err != nil {
+		logging.Errorf("Failed to drain admin connections: %v", err)
+	}
+	if grpcServer != nil {
+		grpcServer.Shutdown(shutdownCtx())
+	}
+	return runErr
+}
+
+// openRateLimiter returns the rate limit store selected by RATE_LIMIT_STORE and a function releasing it
+func openRateLimiter(cfg *config.Config) (ratelimit.Store, func() error) {
+	if cfg.RateLimit.Store != "redis" {
+		return ratelimit.NewMemory(), func() error { return nil }
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	logging.Infof("Keeping rate limits in Redis at %s", cfg.Redis.Addr)
+	return ratelimit.NewRedis(client), client.Close
+}
+
+// openIdempotencyStore returns the idempotency key store selected by
+// IDEMPOTENCY_STORE and a function releasing it. The postgres store uses the
+// storage pool and starts a worker deleting expired keys.
+func openIdempotencyStore(cfg *config.Config, store *Storage, workers *worker.Group) (idempotency.Store, func() error) {
+	switch cfg.Idempotency.Store {
+	case "postgres":
+		keys := idempotency.NewPostgres(store.Pool)
+		workers.Go("idempotency-sweep", keys.Sweep(time.Hour))
+		logging.Infof("Keeping idempotency keys in PostgreSQL for %s", cfg.Idempotency.TTL)
+		return keys, func() error { return nil }
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		logging.Infof("Keeping idempotency keys in Redis at %s for %s", cfg.Redis.Addr, cfg.Idempotency.TTL)
+		return idempotency.NewRedis(client), client.Close
+	default:
+		return idempotency.NewMemory(), func() error { return nil }
+	}
+}
+
+// openPersistedQueryStore returns the store of GraphQL persisted queries
+// selected by GRAPHQL_PERSISTED_QUERY_STORE and a function releasing it
+func openPersistedQueryStore(cfg *config.Config) (cache.Store, func() error) {
+	if cfg.GraphQL.PersistedQueryStore != "redis" {
+		return cache.NewLRU(cfg.GraphQL.PersistedQueryCacheSize), func() error { return nil }
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	logging.Infof("Keeping GraphQL persisted queries in Redis at %s", cfg.Redis.Addr)
+	return cache.NewRedis(client), client.Close
+}
@@ -0,0 +1,465 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"todo-app/internal/config"
+	"todo-app/internal/domain"
+	"todo-app/internal/handler"
+	"todo-app/internal/logging"
+	"todo-app/internal/migrate"
+	"todo-app/internal/repository/cache"
+	"todo-app/internal/repository/cassandra"
+	"todo-app/internal/repository/memory"
+	"todo-app/internal/repository/mysql"
+	"todo-app/internal/repository/postgres"
+	"todo-app/internal/repository/resilient"
+	"todo-app/internal/repository/sqlite"
+	"todo-app/internal/resilience"
+	"todo-app/internal/worker"
+	"todo-app/migrations"
+
+	gocql "github.com/apache/cassandra-gocql-driver/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/redis/go-redis/v9"
+)
+
+// Storage is the todo backend selected by STORAGE_DRIVER together with the
+// readiness checks and background workers it contributes and a function
+// releasing its resources
+type Storage struct {
+	Todos domain.TodoRepository
+	// UnitOfWork runs several repository calls in one transaction
+	UnitOfWork domain.UnitOfWork
+	// Pool is the PostgreSQL primary when STORAGE_DRIVER is postgres
+	Pool *pgxpool.Pool
+	// Users holds the accounts JWTs name; nil unless STORAGE_DRIVER is
+	// postgres, the only backend storing users
+	Users   domain.UserRepository
+	Checks  []handler.HealthCheck
+	Workers map[string]worker.Func
+	// OnReload applies reloaded runtime settings to the backend
+	OnReload []func(cfg *config.Config)
+	Close    func() error
+}
+
+// OpenStorage opens the configured storage backend. With autoMigrate the
+// schema is brought up to date; otherwise an outdated schema is an error.
+func OpenStorage(ctx context.Context, cfg *config.Config, autoMigrate bool) (*Storage, error) {
+	store, err := openBackend(ctx, cfg, autoMigrate)
+	if err != nil {
+		return nil, err
+	}
+	if err := addCache(cfg, store); err != nil {
+		store.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func openBackend(ctx context.Context, cfg *config.Config, autoMigrate bool) (*Storage, error) {
+	switch cfg.Storage.Driver {
+	case "memory":
+		return openMemory(cfg)
+	case "postgres":
+		return openPostgres(ctx, cfg, autoMigrate)
+	case "mysql":
+		return openMySQL(ctx, cfg, autoMigrate)
+	case "cassandra":
+		return openCassandra(ctx, cfg)
+	case "sqlite":
+		return openSQLite(ctx, cfg, autoMigrate)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %q", cfg.Storage.Driver)
+	}
+}
+
+func openMemory(cfg *config.Config) (*Storage, error) {
+	var repo *memory.TodoRepository
+	if path := cfg.Storage.MemorySnapshotPath; path != "" {
+		var err error
+		if repo, err = memory.NewSnapshotTodoRepository(path); err != nil {
+			return nil, err
+		}
+		logging.Infof("Using in-memory storage with snapshot %s", path)
+	} else {
+		repo = memory.NewTodoRepository()
+		logging.Infof("Using in-memory storage (data is lost on exit)")
+	}
+
+	return &Storage{
+		Todos:      repo,
+		UnitOfWork: memory.NewUnitOfWork(repo),
+		Close:      func() error { return nil },
+	}, nil
+}
+
+func openPostgres(ctx context.Context, cfg *config.Config, autoMigrate bool) (*Storage, error) {
+	pool, db, migrator, err := connectPostgres(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	closeAll := func() error {
+		db.Close()
+		pool.Close()
+		return nil
+	}
+	if err := prepareSchema(ctx, migrator, autoMigrate); err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	store := &Storage{
+		Todos:      postgres.NewTodoRepository(pool),
+		UnitOfWork: postgres.NewUnitOfWork(pool),
+		Pool:       pool,
+		Users:      postgres.NewUserRepository(pool),
+		Checks: []handler.HealthCheck{
+			handler.DatabaseCheck(pool.Ping),
+			handler.SchemaCheck(migrator.Version, migrator.Latest()),
+		},
+		Close: closeAll,
+	}
+	if len(cfg.Database.ReplicaDSNs) > 0 {
+		if err := addReplicas(ctx, cfg, pool, store); err != nil {
+			closeAll()
+			return nil, err
+		}
+	}
+	addBreaker(cfg.Database, store, postgres.IsTransient)
+	return store, nil
+}
+
+// addReplicas routes store's reads to the replicas in DB_REPLICA_DSNS
+func addReplicas(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool, store *Storage) error {
+	replicas, err := postgres.ConnectReplicas(ctx, pool, cfg.Database)
+	if err != nil {
+		return err
+	}
+	replicas.Check(ctx)
+	logging.Infof("Routing reads to %d replica(s)", len(cfg.Database.ReplicaDSNs))
+
+	store.Todos = postgres.NewReplicatedTodoRepository(pool, replicas)
+	// Replicas never fail readiness: reads fall back to the primary
+	store.Checks = append(store.Checks, handler.HealthCheck{
+		Name: "replicas",
+		Check: func(ctx context.Context) (any, error) {
+			return replicas.Status(), nil
+		},
+	})
+	store.Workers = map[string]worker.Func{
+		"replica-monitor": replicas.Monitor,
+	}
+	store.OnReload = append(store.OnReload, func(cfg *config.Config) {
+		replicas.SetLimits(cfg.Database.ReplicaMaxLag, cfg.Database.ReplicaCheckInterval)
+	})
+	closePrimary := store.Close
+	store.Close = func() error {
+		replicas.Close()
+		return closePrimary()
+	}
+	return nil
+}
+
+// addBreaker wraps store.Todos in a circuit breaker that fails fast while the
+// database is down, and retries reads failing with errors transient accepts
+func addBreaker(cfg config.DatabaseConfig, store *Storage, transient func(error) bool) {
+	if cfg.BreakerThreshold <= 0 {
+		return
+	}
+
+	breaker := resilience.NewBreaker("database", cfg.BreakerThreshold, cfg.BreakerCooldown, resilient.IsFailure)
+	backoff := resilience.Backoff{
+		Initial:  50 * time.Millisecond,
+		Max:      500 * time.Millisecond,
+		Attempts: max(cfg.ReadAttempts, 1),
+	}
+	store.Todos = resilient.NewTodoRepository(store.Todos, breaker, backoff, transient)
+	store.UnitOfWork = resilient.NewUnitOfWork(store.UnitOfWork, breaker)
+	// An open breaker never fails readiness: the database check already does
+	store.Checks = append(store.Checks, handler.HealthCheck{
+		Name: "circuit_breaker",
+		Check: func(ctx context.Context) (any, error) {
+			return breaker.Status(), nil
+		},
+	})
+}
+
+// OpenPostgres connects to the PostgreSQL primary for commands that use it
+// directly, failing if the schema is behind the embedded migrations
+func OpenPostgres(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
+	pool, db, migrator, err := connectPostgres(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	if err := prepareSchema(ctx, migrator, false); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// connectPostgres opens the primary pool and a migrator running over it through database/sql
+func connectPostgres(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, *sql.DB, *migrate.Migrator, error) {
+	pool, err := connectWithRetry(ctx, cfg.Database)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	db := stdlib.OpenDBFromPool(pool)
+	migrator, err := migrate.New(db, migrate.Postgres, migrations.Postgres)
+	if err != nil {
+		db.Close()
+		pool.Close()
+		return nil, nil, nil, fmt.Errorf("failed to load migrations: %v", err)
+	}
+	return pool, db, migrator, nil
+}
+
+// connectWithRetry connects to the primary, retrying with backoff for up to
+// DB_CONNECT_RETRY_TIMEOUT so the app can start before the database is ready
+func connectWithRetry(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	backoff := resilience.Backoff{
+		Initial: 500 * time.Millisecond,
+		Max:     5 * time.Second,
+		Timeout: cfg.ConnectRetryTimeout,
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			logging.Warnf("Database not ready (attempt %d): %v; retrying in %s", attempt, err, wait.Round(time.Millisecond))
+		},
+	}
+	if cfg.ConnectRetryTimeout <= 0 {
+		backoff.Attempts = 1
+	}
+
+	var pool *pgxpool.Pool
+	err := resilience.Retry(ctx, backoff, postgres.ConnectRetryable, func(ctx context.Context) error {
+		var err error
+		pool, err = postgres.Connect(ctx, cfg)
+		return err
+	})
+	return pool, err
+}
+
+func openMySQL(ctx context.Context, cfg *config.Config, autoMigrate bool) (*Storage, error) {
+	db, migrator, err := openSQL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareSchema(ctx, migrator, autoMigrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Storage{
+		Todos:      mysql.NewTodoRepository(db),
+		UnitOfWork: mysql.NewUnitOfWork(db),
+		Checks:     sqlChecks(db, migrator),
+		Close:      db.Close,
+	}, nil
+}
+
+func openCassandra(ctx context.Context, cfg *config.Config) (*Storage, error) {
+	c := cfg.Cassandra
+	readConsistency, err := gocql.ParseConsistencyWrapper(c.ReadConsistency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CASSANDRA_READ_CONSISTENCY: %v", err)
+	}
+	writeConsistency, err := gocql.ParseConsistencyWrapper(c.WriteConsistency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CASSANDRA_WRITE_CONSISTENCY: %v", err)
+	}
+
+	session, err := cassandra.Connect(c.Hosts, c.Keyspace, c.ReplicationFactor, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	// The CQL schema is idempotent, so it is applied on every start
+	if err := cassandra.Migrate(ctx, session, migrations.Cassandra); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to apply cassandra schema: %v", err)
+	}
+	logging.Infof("Using Cassandra storage in keyspace %s", c.Keyspace)
+
+	repo := cassandra.NewTodoRepository(session, cassandra.Options{
+		Owner:            c.Owner,
+		ReadConsistency:  readConsistency,
+		WriteConsistency: writeConsistency,
+		CompletedTTL:     c.CompletedTTL,
+	})
+	return &Storage{
+		Todos:      repo,
+		UnitOfWork: cassandra.NewUnitOfWork(repo),
+		Checks:     []handler.HealthCheck{handler.DatabaseCheck(cassandra.Ping(session))},
+		Close: func() error {
+			session.Close()
+			return nil
+		},
+	}, nil
+}
+
+func openSQLite(ctx context.Context, cfg *config.Config, autoMigrate bool) (*Storage, error) {
+	db, migrator, err := openSQL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareSchema(ctx, migrator, autoMigrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+	logging.Infof("Using SQLite storage at %s", cfg.Storage.SQLitePath)
+
+	return &Storage{
+		Todos:      sqlite.NewTodoRepository(db),
+		UnitOfWork: sqlite.NewUnitOfWork(db),
+		Checks:     sqlChecks(db, migrator),
+		Close:      db.Close,
+	}, nil
+}
+
+// addCache wraps store.Todos in the read-through cache selected by CACHE_DRIVER
+func addCache(cfg *config.Config, store *Storage) error {
+	if cfg.Cache.Driver == "none" {
+		return nil
+	}
+
+	var client *redis.Client
+	if cfg.Cache.Driver == "redis" || cfg.Cache.PubSub {
+		client = redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		store.Checks = append(store.Checks, handler.HealthCheck{
+			Name: "cache",
+			Check: func(ctx context.Context) (any, error) {
+				return nil, client.Ping(ctx).Err()
+			},
+		})
+		closeBackend := store.Close
+		store.Close = func() error {
+			client.Close()
+			return closeBackend()
+		}
+	}
+
+	var cacheStore cache.Store
+	if cfg.Cache.Driver == "redis" {
+		cacheStore = cache.NewRedis(client)
+	} else {
+		cacheStore = cache.NewLRU(cfg.Cache.LRUSize)
+	}
+
+	var bus cache.Bus
+	if cfg.Cache.PubSub {
+		bus = cache.NewRedisBus(client, cfg.Cache.Channel)
+	}
+
+	cached := cache.NewTodoRepository(store.Todos, cacheStore, cfg.Cache.TTL, bus)
+	store.Todos = cached
+	store.UnitOfWork = cache.NewUnitOfWork(store.UnitOfWork, cached)
+	if bus != nil {
+		if store.Workers == nil {
+			store.Workers = make(map[string]worker.Func)
+		}
+		store.Workers["cache-invalidation"] = cached.Listen
+	}
+
+	logging.Infof("Caching todos in %s for %s (pub/sub invalidation: %v)", cfg.Cache.Driver, cfg.Cache.TTL, cfg.Cache.PubSub)
+	return nil
+}
+
+// OpenMigrator connects to the configured SQL database and returns a migrator
+// using that database's dialect and migration set, and a function closing the connection
+func OpenMigrator(ctx context.Context, cfg *config.Config) (*migrate.Migrator, func() error, error) {
+	if cfg.Storage.Driver == "postgres" {
+		pool, db, migrator, err := connectPostgres(ctx, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return migrator, func() error {
+			db.Close()
+			pool.Close()
+			return nil
+		}, nil
+	}
+
+	db, migrator, err := openSQL(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrator, db.Close, nil
+}
+
+// openSQL connects to a database/sql backend and returns a migrator for it. The caller closes db.
+func openSQL(cfg *config.Config) (*sql.DB, *migrate.Migrator, error) {
+	var (
+		db      *sql.DB
+		dialect migrate.Dialect
+		source  fs.FS
+		err     error
+	)
+	switch cfg.Storage.Driver {
+	case "mysql":
+		db, err = mysql.Connect(cfg.MySQL.GetDSN())
+		dialect, source = migrate.MySQL, migrations.MySQL
+	case "sqlite":
+		if err = os.MkdirAll(filepath.Dir(cfg.Storage.SQLitePath), 0o755); err != nil {
+			return nil, nil, fmt.Errorf("failed to create database directory: %v", err)
+		}
+		db, err = sqlite.Open(cfg.Storage.SQLitePath)
+		dialect, source = migrate.SQLite, migrations.SQLite
+	default:
+		return nil, nil, fmt.Errorf("storage driver %q has no migrations", cfg.Storage.Driver)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	migrator, err := migrate.New(db, dialect, source)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to load migrations: %v", err)
+	}
+	return db, migrator, nil
+}
+
+// prepareSchema applies pending migrations, or with autoMigrate off,
+// fails if the schema is behind the embedded migrations
+func prepareSchema(ctx context.Context, migrator *migrate.Migrator, autoMigrate bool) error {
+	if autoMigrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to run migrations: %v", err)
+		}
+		for _, m := range applied {
+			logging.Infof("Applied migration %03d_%s", m.Version, m.Name)
+		}
+		return nil
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version < migrator.Latest() {
+		return fmt.Errorf("database schema is at version %d but %d is required, run `todo migrate up` first", version, migrator.Latest())
+	}
+	return nil
+}
+
+// sqlChecks returns the readiness checks for a SQL backend
+func sqlChecks(db *sql.DB, migrator *migrate.Migrator) []handler.HealthCheck {
+	return []handler.HealthCheck{
+		handler.DatabaseCheck(db.PingContext),
+		handler.SchemaCheck(migrator.Version, migrator.Latest()),
+	}
+}
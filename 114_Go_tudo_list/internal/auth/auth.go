@@ -0,0 +1,257 @@
+// Package auth verifies the credentials API clients send: a JWT naming a
+// user, or an API token. The REST, GraphQL and gRPC APIs all verify them here
+// so a client is the same caller, with the same rate limit, on each of them.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyHeader carries API tokens; users send their JWT as
+// "Authorization: Bearer <token>"
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrInvalidCredentials is returned for a malformed, forged or expired JWT
+	// and for an unknown API token
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrMissingCredentials is returned by Require for anonymous callers
+	ErrMissingCredentials = errors.New("authentication required")
+	// ErrRevokedCredentials is returned for a JWT whose user was disabled, or
+	// changed in any way such as a password reset, after it was issued
+	ErrRevokedCredentials = errors.New("credentials revoked")
+)
+
+// Rejected reports whether err means the caller's credentials were refused,
+// rather than that they could not be checked
+func Rejected(err error) bool {
+	return errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrMissingCredentials) || errors.Is(err, ErrRevokedCredentials)
+}
+
+// Identity is the verified caller of a request. Both fields are empty for
+// anonymous callers.
+type Identity struct {
+	// UserID is the subject of the caller's JWT
+	UserID string
+	// Token is the API token the caller sent
+	Token string
+}
+
+// Anonymous reports whether the caller sent no credentials
+func (id Identity) Anonymous() bool {
+	return id.UserID == "" && id.Token == ""
+}
+
+// Key identifies the caller in rate limit buckets and idempotency scopes, the
+// same on every API: "user:<id>", "token:<hash>" or "" for anonymous callers.
+// Tokens are hashed so they are never stored.
+func (id Identity) Key() string {
+	if id.UserID != "" {
+		return "user:" + id.UserID
+	}
+	if id.Token != "" {
+		sum := sha256.Sum256([]byte(id.Token))
+		return "token:" + hex.EncodeToString(sum[:16])
+	}
+	return ""
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity verified for ctx; it is anonymous when
+// none was
+func FromContext(ctx context.Context) Identity {
+	id, _ := ctx.Value(identityKey{}).(Identity)
+	return id
+}
+
+// Verifier checks JWTs signed with HS256 under a shared secret and API tokens
+// from a configured list
+type Verifier struct {
+	secret []byte
+	// tokens holds the SHA-256 of each API token, so lookups take the same
+	// time whatever prefix of a token a caller guesses
+	tokens map[[sha256.Size]byte]bool
+	// required rejects anonymous callers
+	required bool
+	// users, when set, holds the accounts JWTs name
+	users domain.UserRepository
+	now   func() time.Time
+}
+
+// NewVerifier returns a verifier of JWTs signed with secret and of the API
+// tokens listed. When required is set anonymous callers are rejected. When
+// users is not nil a JWT is only accepted while its user exists, is enabled
+// and has not changed since the token was issued, so disabling a user or
+// resetting their password revokes the tokens they hold.
+func NewVerifier(secret string, tokens []string, required bool, users domain.UserRepository) *Verifier {
+	v := &Verifier{
+		secret:   []byte(secret),
+		tokens:   make(map[[sha256.Size]byte]bool, len(tokens)),
+		required: required,
+		users:    users,
+		now:      time.Now,
+	}
+	for _, token := range tokens {
+		if token = strings.TrimSpace(token); token != "" {
+			v.tokens[sha256.Sum256([]byte(token))] = true
+		}
+	}
+	return v
+}
+
+// Verify returns the caller identified by the value of the Authorization
+// header, a JWT as "Bearer <token>", or else by apiKey, an API token. A caller
+// sending neither is anonymous; credentials that are sent must be valid.
+// Errors other than those Rejected reports mean the user could not be looked up.
+func (v *Verifier) Verify(ctx context.Context, authorization, apiKey string) (Identity, error) {
+	if authorization != "" {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return Identity{}, ErrInvalidCredentials
+		}
+		c, err := v.parseToken(strings.TrimSpace(token))
+		if err != nil {
+			return Identity{}, err
+		}
+		if err := v.checkUser(ctx, c); err != nil {
+			return Identity{}, err
+		}
+		return Identity{UserID: c.Subject}, nil
+	}
+
+	if apiKey != "" {
+		if !v.tokens[sha256.Sum256([]byte(apiKey))] {
+			return Identity{}, ErrInvalidCredentials
+		}
+		return Identity{Token: apiKey}, nil
+	}
+	return Identity{}, nil
+}
+
+// Require returns ErrMissingCredentials when id is anonymous and anonymous
+// callers are rejected
+func (v *Verifier) Require(id Identity) error {
+	if v.required && id.Anonymous() {
+		return ErrMissingCredentials
+	}
+	return nil
+}
+
+// jwtHeader is the only JOSE header issued and accepted
+const jwtHeader = `{"alg":"HS256","typ":"JWT"}`
+
+// claims are the registered JWT claims used
+type claims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// IssueToken returns a JWT naming userID that expires after ttl
+func (v *Verifier) IssueToken(userID string, ttl time.Duration) (string, error) {
+	now := v.now()
+	payload, err := json.Marshal(claims{Subject: userID, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token claims: %v", err)
+	}
+	signed := encode([]byte(jwtHeader)) + "." + encode(payload)
+	return signed + "." + encode(v.sign(signed)), nil
+}
+
+// checkUser checks that the user a JWT names may still use it. Tokens issued
+// before the last change to the account are revoked; iat has one-second
+// precision, so a token issued in the same second as the change is too.
+func (v *Verifier) checkUser(ctx context.Context, c claims) error {
+	if v.users == nil {
+		return nil
+	}
+	id, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+	user, err := v.users.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up token user: %w", err)
+	}
+	if user.Disabled || time.Unix(c.IssuedAt, 0).Before(user.UpdatedAt) {
+		return ErrRevokedCredentials
+	}
+	return nil
+}
+
+// parseToken returns the claims of a JWT after checking its algorithm,
+// signature and expiry
+func (v *Verifier) parseToken(token string) (claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims{}, ErrInvalidCredentials
+	}
+
+	// Only HS256 is accepted, so "none" or an asymmetric algorithm cannot be
+	// used to pass off a token the secret did not sign
+	header, err := decode(parts[0])
+	if err != nil {
+		return claims{}, ErrInvalidCredentials
+	}
+	var jose struct {
+		Alg string `json:"alg"`
+	}
+	if json.Unmarshal(header, &jose) != nil || jose.Alg != "HS256" {
+		return claims{}, ErrInvalidCredentials
+	}
+
+	signature, err := decode(parts[2])
+	if err != nil || !hmac.Equal(signature, v.sign(parts[0]+"."+parts[1])) {
+		return claims{}, ErrInvalidCredentials
+	}
+
+	payload, err := decode(parts[1])
+	if err != nil {
+		return claims{}, ErrInvalidCredentials
+	}
+	var c claims
+	if json.Unmarshal(payload, &c) != nil || c.Subject == "" {
+		return claims{}, ErrInvalidCredentials
+	}
+	if c.ExpiresAt == 0 || v.now().Unix() >= c.ExpiresAt {
+		return claims{}, ErrInvalidCredentials
+	}
+	return c, nil
+}
+
+// sign returns the HMAC-SHA256 of the signing input under the secret
+func (v *Verifier) sign(input string) []byte {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"todo-app/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeUsers holds users by ID; err, when set, fails every lookup
+type fakeUsers struct {
+	domain.UserRepository
+	users map[uuid.UUID]*domain.User
+	err   error
+}
+
+func (f *fakeUsers) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if user, ok := f.users[id]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func TestVerifyChecksTheUser(t *testing.T) {
+	issued := time.Unix(1_700_000_000, 0)
+	id := uuid.New()
+
+	tests := []struct {
+		name     string
+		user     *domain.User
+		lookup   error
+		wantErr  error
+		rejected bool
+	}{
+		{"active user", &domain.User{ID: id, UpdatedAt: issued.Add(-time.Hour)}, nil, nil, false},
+		{"disabled user", &domain.User{ID: id, Disabled: true, UpdatedAt: issued.Add(-time.Hour)}, nil, ErrRevokedCredentials, true},
+		{"password reset after issue", &domain.User{ID: id, UpdatedAt: issued.Add(time.Minute)}, nil, ErrRevokedCredentials, true},
+		{"changed in the second of issue", &domain.User{ID: id, UpdatedAt: issued.Add(500 * time.Millisecond)}, nil, ErrRevokedCredentials, true},
+		{"unknown user", nil, nil, ErrInvalidCredentials, true},
+		{"lookup failure", nil, errors.New("connection refused"), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{users: map[uuid.UUID]*domain.User{}, err: tt.lookup}
+			if tt.user != nil {
+				users.users[id] = tt.user
+			}
+			v := NewVerifier("test-secret", nil, false, users)
+			v.now = func() time.Time { return issued }
+			token, err := v.IssueToken(id.String(), time.Hour)
+			if err != nil {
+				t.Fatalf("IssueToken: %v", err)
+			}
+			v.now = func() time.Time { return issued.Add(time.Minute) }
+
+			got, err := v.Verify(context.Background(), "Bearer "+token, "")
+			if tt.lookup != nil {
+				if err == nil || Rejected(err) {
+					t.Fatalf("Verify: got %v, want a lookup error that is not a rejection", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || Rejected(err) != tt.rejected {
+				t.Fatalf("Verify: got %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.UserID != id.String() {
+				t.Fatalf("UserID: got %q, want %q", got.UserID, id)
+			}
+		})
+	}
+}
+
+func TestVerifyTokens(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := NewVerifier("test-secret", []string{"key-1"}, false, nil)
+	v.now = func() time.Time { return now }
+	valid, _ := v.IssueToken("user-1", time.Hour)
+	expired, _ := v.IssueToken("user-1", -time.Second)
+	forged, _ := NewVerifier("other-secret", nil, false, nil).IssueToken("user-1", time.Hour)
+
+	tests := []struct {
+		name          string
+		authorization string
+		apiKey        string
+		want          Identity
+		wantErr       error
+	}{
+		{"anonymous", "", "", Identity{}, nil},
+		{"JWT", "Bearer " + valid, "", Identity{UserID: "user-1"}, nil},
+		{"expired JWT", "Bearer " + expired, "", Identity{}, ErrInvalidCredentials},
+		{"forged JWT", "Bearer " + forged, "", Identity{}, ErrInvalidCredentials},
+		{"not a bearer token", "Basic " + valid, "", Identity{}, ErrInvalidCredentials},
+		{"API token", "", "key-1", Identity{Token: "key-1"}, nil},
+		{"unknown API token", "", "key-2", Identity{}, ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(context.Background(), tt.authorization, tt.apiKey)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Fatalf("Verify: got %+v, %v; want %+v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
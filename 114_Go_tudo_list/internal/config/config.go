@@ -2,17 +2,54 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"net"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
+	// Env is the deployment profile: dev, staging or prod
+	Env         string
+	Log         LogConfig
+	Storage     StorageConfig
+	Database    DatabaseConfig
+	MySQL       MySQLConfig
+	Cassandra   CassandraConfig
+	Cache       CacheConfig
+	Redis       RedisConfig
+	RateLimit   RateLimitConfig
+	Idempotency IdempotencyConfig
+	GraphQL     GraphQLConfig
+	GRPC        GRPCConfig
+	Server      ServerConfig
+	Admin       AdminConfig
+	CORS        CORSConfig
+	OpenAPI     OpenAPIConfig
+	JWT         JWTConfig
+	Auth        AuthConfig
+	Tracing     TracingConfig
+
+	values []Value
+	// source is where the configuration was loaded from, for reloading it
+	source Options
+}
+
+// LogConfig holds logging configuration
+type LogConfig struct {
+	Level string // debug, info, warn or error
+}
+
+// StorageConfig selects the todo storage backend
+type StorageConfig struct {
+	Driver string // postgres, mysql, cassandra, sqlite or memory
+	// MemorySnapshotPath is an optional JSON file the memory driver loads from and saves to
+	MemorySnapshotPath string
+	// SQLitePath is the database file used by the sqlite driver
+	SQLitePath string
 }
 
 // DatabaseConfig holds database configuration
@@ -23,67 +60,445 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// Connection pool settings
+	MaxConns               int32
+	MinConns               int32
+	MaxConnLifetime        time.Duration
+	MaxConnIdleTime        time.Duration
+	HealthCheckPeriod      time.Duration
+	StatementCacheCapacity int
+	// ConnectRetryTimeout keeps retrying the first connection for this long; zero fails at once
+	ConnectRetryTimeout time.Duration
+	// BreakerThreshold consecutive failures open the circuit breaker for
+	// BreakerCooldown, failing requests fast; zero disables the breaker
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// ReadAttempts is how many times a read failing with a transient error is tried
+	ReadAttempts int
+	// ReplicaDSNs are streaming replicas that serve reads; empty means all reads go to the primary
+	ReplicaDSNs []string
+	// ReplicaMaxLag takes a replica out of rotation when it falls further behind
+	ReplicaMaxLag        time.Duration
+	ReplicaCheckInterval time.Duration
+	// ReadYourWritesWindow pins a client's reads to the primary for this long after it writes
+	ReadYourWritesWindow time.Duration
 }
 
-// ServerConfig holds server configuration
-type ServerConfig struct {
+// MySQLConfig holds MySQL/MariaDB configuration for the mysql storage driver
+type MySQLConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	DBName   string
+}
+
+// CassandraConfig holds Apache Cassandra configuration for the cassandra storage driver
+type CassandraConfig struct {
+	Hosts             []string
+	Keyspace          string
+	ReplicationFactor int
+	ReadConsistency   string
+	WriteConsistency  string
+	// CompletedTTL expires completed todos after this long; zero keeps them forever
+	CompletedTTL time.Duration
+	// Owner is the user partition todos are stored under
+	Owner   string
+	Timeout time.Duration
+}
+
+// CacheConfig holds the read-through todo cache configuration
+type CacheConfig struct {
+	Driver  string // none, lru or redis
+	TTL     time.Duration
+	LRUSize int
+	// PubSub broadcasts invalidations to other instances over Redis
+	PubSub  bool
+	Channel string
+}
+
+// RateLimitConfig holds the per-client request limits of each route group
+type RateLimitConfig struct {
+	Store string // memory or redis
+	// Limits maps a route group (api, web) to its limit; groups without one are not limited
+	Limits map[string]RateLimit
+}
+
+// RateLimit allows Requests per Window per client, with bursts of up to Requests
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// IdempotencyConfig holds where the responses to requests with an
+// Idempotency-Key are kept and for how long
+type IdempotencyConfig struct {
+	Store string // memory, redis or postgres
+	TTL   time.Duration
+}
+
+// GraphQLConfig holds the limits of the GraphQL endpoint and where the
+// queries registered by automatic persisted queries are kept
+type GraphQLConfig struct {
+	// MaxDepth rejects operations nesting selections deeper than this
+	MaxDepth int
+	// MaxComplexity rejects operations estimated to resolve more fields than this
+	MaxComplexity int
+	// PersistedQueryStore is memory or redis
+	PersistedQueryStore     string
+	PersistedQueryCacheSize int
+	PersistedQueryTTL       time.Duration
+}
+
+// GRPCConfig holds the settings of the gRPC server, which listens on
+// SERVER_HOST beside the HTTP server
+type GRPCConfig struct {
+	Enabled bool
+	Port    int
+	// Reflection lets tools such as grpcurl list the services
+	Reflection bool
+}
+
+// AdminConfig holds the listener of the operator endpoints, kept off the
+// public port
+type AdminConfig struct {
 	Host string
 	Port int
 }
 
+// RedisConfig holds Redis connection configuration
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// ServerConfig holds server configuration
+type ServerConfig struct {
+	Host              string
+	Port              int
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	MaxHeaderBytes    int
+	MaxBodyBytes      int64
+	HealthTimeout     time.Duration
+	// TrustedProxies are the proxy IPs or CIDRs whose X-Forwarded-For and
+	// X-Real-IP headers are believed when finding the client IP
+	TrustedProxies []string
+}
+
+// CORSConfig holds the cross-origin policy of the API
+type CORSConfig struct {
+	// AllowedOrigins may call the API from a browser; "*" allows any origin
+	AllowedOrigins []string
+}
+
+// OpenAPIConfig holds the API description settings
+type OpenAPIConfig struct {
+	// Validate checks every request and response against the OpenAPI
+	// document; only allowed in the dev profile
+	Validate bool
+}
+
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
 	Secret string
+	// TTL is how long the tokens `todo users token` issues stay valid
+	TTL time.Duration
 }
 
-// Load loads configuration from environment variables
-func Load() (*Config, error) {
+// AuthConfig holds API authentication settings
+type AuthConfig struct {
+	// Required rejects API requests sent without credentials
+	Required bool
+	// APITokens are accepted in the X-API-Key header
+	APITokens []string
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Exporter     string // stdout, otlp or none
+	OTLPEndpoint string
+	ServiceName  string
+	SampleRatio  float64
+}
+
+// Load builds the configuration from the built-in defaults overlaid by the
+// config file, the environment (and .env) and command-line overrides. The
+// returned *ValidationError lists every invalid setting at once.
+func Load(opts Options) (*Config, error) {
 	// Try to load .env file, but don't fail if it doesn't exist
 	_ = godotenv.Load()
 
+	return build(newLoader(opts))
+}
+
+// build reads every setting through l into a new Config
+func build(l *loader) (*Config, error) {
 	config := &Config{}
 
+	config.Env = l.oneOf("APP_ENV", "dev", "staging", "prod")
+	config.Log.Level = l.oneOf("LOG_LEVEL", "debug", "info", "warn", "error")
+
+	// Storage configuration
+	config.Storage.Driver = l.oneOf("STORAGE_DRIVER", "postgres", "mysql", "cassandra", "sqlite", "memory")
+	config.Storage.MemorySnapshotPath = l.str("MEMORY_SNAPSHOT_PATH")
+	config.Storage.SQLitePath = l.str("SQLITE_PATH")
+
 	// Database configuration
-	config.Database.Host = getEnv("DB_HOST", "localhost")
-	port, err := strconv.Atoi(getEnv("DB_PORT", "5432"))
+	config.Database.Host = l.str("DB_HOST")
+	config.Database.Port = l.port("DB_PORT")
+	config.Database.User = l.str("DB_USER")
+	config.Database.Password = l.str("DB_PASSWORD")
+	config.Database.DBName = l.str("DB_NAME")
+	config.Database.SSLMode = l.oneOf("DB_SSLMODE", "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	config.Database.MaxConns = int32(l.integer("DB_MAX_CONNS", 32))
+	l.atLeast("DB_MAX_CONNS", int64(config.Database.MaxConns), 1)
+	config.Database.MinConns = int32(l.integer("DB_MIN_CONNS", 32))
+	if config.Database.MinConns < 0 || config.Database.MinConns > config.Database.MaxConns {
+		l.invalid("DB_MIN_CONNS", "must be between 0 and DB_MAX_CONNS")
+	}
+	config.Database.MaxConnLifetime = l.duration("DB_MAX_CONN_LIFETIME")
+	config.Database.MaxConnIdleTime = l.duration("DB_MAX_CONN_IDLE_TIME")
+	config.Database.HealthCheckPeriod = l.duration("DB_HEALTH_CHECK_PERIOD")
+	config.Database.StatementCacheCapacity = int(l.integer("DB_STATEMENT_CACHE_CAPACITY", 0))
+	l.atLeast("DB_STATEMENT_CACHE_CAPACITY", int64(config.Database.StatementCacheCapacity), 0)
+	config.Database.ConnectRetryTimeout = l.duration("DB_CONNECT_RETRY_TIMEOUT")
+	config.Database.BreakerThreshold = int(l.integer("DB_BREAKER_THRESHOLD", 0))
+	l.atLeast("DB_BREAKER_THRESHOLD", int64(config.Database.BreakerThreshold), 0)
+	config.Database.BreakerCooldown = l.duration("DB_BREAKER_COOLDOWN")
+	config.Database.ReadAttempts = int(l.integer("DB_READ_ATTEMPTS", 0))
+	l.atLeast("DB_READ_ATTEMPTS", int64(config.Database.ReadAttempts), 1)
+	config.Database.ReplicaDSNs = l.list("DB_REPLICA_DSNS")
+	config.Database.ReplicaMaxLag = l.duration("DB_REPLICA_MAX_LAG")
+	config.Database.ReplicaCheckInterval = l.duration("DB_REPLICA_CHECK_INTERVAL")
+	config.Database.ReadYourWritesWindow = l.duration("DB_READ_YOUR_WRITES_WINDOW")
+
+	// MySQL configuration, defaulting to a stock XAMPP install
+	config.MySQL.Host = l.str("MYSQL_HOST")
+	config.MySQL.Port = l.port("MYSQL_PORT")
+	config.MySQL.User = l.str("MYSQL_USER")
+	config.MySQL.Password = l.str("MYSQL_PASSWORD")
+	config.MySQL.DBName = l.str("MYSQL_DATABASE")
+
+	// Cassandra configuration, defaulting to a local single-node cluster
+	config.Cassandra.Hosts = l.list("CASSANDRA_HOSTS")
+	config.Cassandra.Keyspace = l.str("CASSANDRA_KEYSPACE")
+	config.Cassandra.ReplicationFactor = int(l.integer("CASSANDRA_REPLICATION_FACTOR", 0))
+	l.atLeast("CASSANDRA_REPLICATION_FACTOR", int64(config.Cassandra.ReplicationFactor), 1)
+	config.Cassandra.ReadConsistency = l.str("CASSANDRA_READ_CONSISTENCY")
+	config.Cassandra.WriteConsistency = l.str("CASSANDRA_WRITE_CONSISTENCY")
+	config.Cassandra.CompletedTTL = l.duration("CASSANDRA_COMPLETED_TTL")
+	config.Cassandra.Owner = l.str("CASSANDRA_OWNER")
+	config.Cassandra.Timeout = l.duration("CASSANDRA_TIMEOUT")
+
+	// Cache configuration
+	config.Cache.Driver = l.oneOf("CACHE_DRIVER", "none", "lru", "redis")
+	config.Cache.TTL = l.duration("CACHE_TTL")
+	config.Cache.LRUSize = int(l.integer("CACHE_LRU_SIZE", 0))
+	l.atLeast("CACHE_LRU_SIZE", int64(config.Cache.LRUSize), 1)
+	config.Cache.PubSub = l.boolean("CACHE_PUBSUB")
+	config.Cache.Channel = l.str("CACHE_INVALIDATION_CHANNEL")
+
+	// Redis configuration
+	config.Redis.Addr = l.str("REDIS_ADDR")
+	config.Redis.Password = l.str("REDIS_PASSWORD")
+	config.Redis.DB = int(l.integer("REDIS_DB", 0))
+	l.atLeast("REDIS_DB", int64(config.Redis.DB), 0)
+
+	// Rate limit configuration
+	config.RateLimit.Store = l.oneOf("RATE_LIMIT_STORE", "memory", "redis")
+	limits, err := parseRateLimits(l.str("RATE_LIMITS"))
 	if err != nil {
-		return nil, fmt.Errorf("invalid DB_PORT: %v", err)
+		l.invalid("RATE_LIMITS", "%v", err)
+	}
+	config.RateLimit.Limits = limits
+
+	// Idempotency key configuration
+	config.Idempotency.Store = l.oneOf("IDEMPOTENCY_STORE", "memory", "redis", "postgres")
+	if config.Idempotency.Store == "postgres" && config.Storage.Driver != "postgres" {
+		l.invalid("IDEMPOTENCY_STORE", "postgres needs STORAGE_DRIVER=postgres")
+	}
+	config.Idempotency.TTL = l.duration("IDEMPOTENCY_TTL")
+	if config.Idempotency.TTL == 0 {
+		l.invalid("IDEMPOTENCY_TTL", "must be positive")
 	}
-	config.Database.Port = port
-	config.Database.User = getEnv("DB_USER", "postgres")
-	config.Database.Password = getEnv("DB_PASSWORD", "password")
-	config.Database.DBName = getEnv("DB_NAME", "todolist_db")
-	config.Database.SSLMode = getEnv("DB_SSLMODE", "disable")
+
+	// GraphQL configuration
+	config.GraphQL.MaxDepth = int(l.integer("GRAPHQL_MAX_DEPTH", 0))
+	l.atLeast("GRAPHQL_MAX_DEPTH", int64(config.GraphQL.MaxDepth), 1)
+	config.GraphQL.MaxComplexity = int(l.integer("GRAPHQL_MAX_COMPLEXITY", 0))
+	l.atLeast("GRAPHQL_MAX_COMPLEXITY", int64(config.GraphQL.MaxComplexity), 1)
+	config.GraphQL.PersistedQueryStore = l.oneOf("GRAPHQL_PERSISTED_QUERY_STORE", "memory", "redis")
+	config.GraphQL.PersistedQueryCacheSize = int(l.integer("GRAPHQL_PERSISTED_QUERY_CACHE_SIZE", 0))
+	l.atLeast("GRAPHQL_PERSISTED_QUERY_CACHE_SIZE", int64(config.GraphQL.PersistedQueryCacheSize), 1)
+	config.GraphQL.PersistedQueryTTL = l.duration("GRAPHQL_PERSISTED_QUERY_TTL")
+	if config.GraphQL.PersistedQueryTTL == 0 {
+		l.invalid("GRAPHQL_PERSISTED_QUERY_TTL", "must be positive")
+	}
+
+	// gRPC configuration
+	config.GRPC.Enabled = l.boolean("GRPC_ENABLED")
+	config.GRPC.Port = l.port("GRPC_PORT")
+	config.GRPC.Reflection = l.boolean("GRPC_REFLECTION")
 
 	// Server configuration
-	config.Server.Host = getEnv("SERVER_HOST", "localhost")
-	serverPort, err := strconv.Atoi(getEnv("SERVER_PORT", "8080"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid SERVER_PORT: %v", err)
+	config.Server.Host = l.str("SERVER_HOST")
+	config.Server.Port = l.port("SERVER_PORT")
+	if config.GRPC.Enabled && config.GRPC.Port == config.Server.Port {
+		l.invalid("GRPC_PORT", "must differ from SERVER_PORT")
+	}
+	config.Server.ReadTimeout = l.duration("SERVER_READ_TIMEOUT")
+	config.Server.ReadHeaderTimeout = l.duration("SERVER_READ_HEADER_TIMEOUT")
+	config.Server.WriteTimeout = l.duration("SERVER_WRITE_TIMEOUT")
+	config.Server.IdleTimeout = l.duration("SERVER_IDLE_TIMEOUT")
+	config.Server.ShutdownTimeout = l.duration("SERVER_SHUTDOWN_TIMEOUT")
+	config.Server.MaxHeaderBytes = int(l.integer("SERVER_MAX_HEADER_BYTES", 0))
+	l.atLeast("SERVER_MAX_HEADER_BYTES", int64(config.Server.MaxHeaderBytes), 1)
+	config.Server.MaxBodyBytes = l.integer("SERVER_MAX_BODY_BYTES", 64)
+	l.atLeast("SERVER_MAX_BODY_BYTES", config.Server.MaxBodyBytes, 1)
+	config.Server.HealthTimeout = l.duration("SERVER_HEALTH_TIMEOUT")
+	for _, proxy := range l.list("SERVER_TRUSTED_PROXIES") {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				l.invalid("SERVER_TRUSTED_PROXIES", "%q is not an IP address or CIDR", proxy)
+				continue
+			}
+		}
+		config.Server.TrustedProxies = append(config.Server.TrustedProxies, proxy)
+	}
+
+	// Admin listener configuration
+	config.Admin.Host = l.str("ADMIN_HOST")
+	config.Admin.Port = l.port("ADMIN_PORT")
+	if config.Admin.Port == config.Server.Port || (config.GRPC.Enabled && config.Admin.Port == config.GRPC.Port) {
+		l.invalid("ADMIN_PORT", "must differ from SERVER_PORT and GRPC_PORT")
 	}
-	config.Server.Port = serverPort
+
+	// CORS configuration
+	config.CORS.AllowedOrigins = l.list("CORS_ALLOWED_ORIGINS")
+
+	// OpenAPI configuration
+	config.OpenAPI.Validate = l.boolean("OPENAPI_VALIDATE")
 
 	// JWT configuration
-	config.JWT.Secret = getEnv("JWT_SECRET", "your-secret-key-here")
+	config.JWT.Secret = l.str("JWT_SECRET")
+	config.JWT.TTL = l.duration("JWT_TTL")
+	if config.JWT.TTL == 0 {
+		l.invalid("JWT_TTL", "must be positive")
+	}
 
+	// Authentication configuration
+	config.Auth.Required = l.boolean("AUTH_REQUIRED")
+	config.Auth.APITokens = l.list("API_TOKENS")
+
+	// Tracing configuration
+	config.Tracing.Exporter = l.oneOf("TRACING_EXPORTER", "stdout", "otlp", "none")
+	config.Tracing.OTLPEndpoint = l.str("TRACING_OTLP_ENDPOINT")
+	config.Tracing.ServiceName = l.str("TRACING_SERVICE_NAME")
+	config.Tracing.SampleRatio = l.float("TRACING_SAMPLE_RATIO")
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		l.invalid("TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	}
+
+	checkProfile(l, config)
+
+	config.values, err = l.result()
+	if err != nil {
+		return nil, err
+	}
+	config.source = l.opts
 	return config, nil
 }
 
+// checkProfile refuses the insecure development defaults and the development
+// only features in the staging and prod profiles
+func checkProfile(l *loader, config *Config) {
+	if config.Env == "dev" {
+		return
+	}
+
+	if config.OpenAPI.Validate {
+		l.invalid("OPENAPI_VALIDATE", "is only available in the dev profile")
+	}
+
+	if config.JWT.Secret == defaultJWTSecret || len(config.JWT.Secret) < 32 {
+		l.invalid("JWT_SECRET", "must be a random secret of at least 32 characters in %s", config.Env)
+	}
+	switch config.Storage.Driver {
+	case "postgres":
+		if config.Database.Password == defaultDBPassword || config.Database.Password == "" {
+			l.invalid("DB_PASSWORD", "must be set to a non-default password in %s", config.Env)
+		}
+		if config.Env == "prod" && config.Database.SSLMode == "disable" {
+			l.invalid("DB_SSLMODE", "must enable TLS in prod")
+		}
+	case "mysql":
+		if config.MySQL.Password == "" {
+			l.invalid("MYSQL_PASSWORD", "must be set in %s", config.Env)
+		}
+	}
+	usesRedis := config.Cache.Driver == "redis" || config.Cache.PubSub || config.RateLimit.Store == "redis" ||
+		config.Idempotency.Store == "redis" || config.GraphQL.PersistedQueryStore == "redis"
+	if config.Env == "prod" && usesRedis && config.Redis.Password == "" {
+		l.invalid("REDIS_PASSWORD", "must be set in prod")
+	}
+}
+
+// Values returns the effective value and source of every setting, in schema order
+func (c *Config) Values() []Value {
+	return c.values
+}
+
+// parseRateLimits parses comma-separated group=requests/window entries such as "api=300/1m"
+func parseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		group, spec, ok := strings.Cut(entry, "=")
+		requests, window, ok2 := strings.Cut(spec, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("%q is not group=requests/window", entry)
+		}
+
+		n, err := strconv.Atoi(requests)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%q: requests must be a positive integer", entry)
+		}
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%q: window must be a positive duration", entry)
+		}
+		limits[strings.TrimSpace(group)] = RateLimit{Requests: n, Window: d}
+	}
+	return limits, nil
+}
+
 // GetDSN returns the database connection string
 func (d *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		d.Host, d.Port, d.User, d.Password, d.DBName, d.SSLMode)
 }
 
+// GetDSN returns the go-sql-driver/mysql connection string. Times are read and
+// written in UTC, migration files may hold several statements, and UPDATE
+// reports matched rather than changed rows.
+func (m *MySQLConfig) GetDSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=true&loc=UTC&multiStatements=true&clientFoundRows=true",
+		m.User, m.Password, m.Host, m.Port, m.DBName)
+}
+
 // GetServerAddr returns the server address
 func (s *ServerConfig) GetServerAddr() string {
 	return fmt.Sprintf("%s:%d", s.Host, s.Port)
 }
 
-// getEnv gets an environment variable with a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
+// GetAddr returns the admin listener address
+func (a *AdminConfig) GetAddr() string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
 }
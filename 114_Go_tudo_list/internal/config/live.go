@@ -0,0 +1,138 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is one version of the running configuration
+type Snapshot struct {
+	Config   *Config
+	Version  int
+	LoadedAt time.Time
+	// Checksum identifies the effective values, so instances running the same
+	// configuration can be recognised whatever their version numbers
+	Checksum string
+}
+
+// Live holds the running configuration. Reload swaps in a new snapshot in one
+// atomic step, taking only the settings marked Reload from the reloaded
+// sources; changes to any other setting are rejected until a restart.
+type Live struct {
+	mu       sync.Mutex // serializes reloads
+	current  atomic.Pointer[Snapshot]
+	onChange []func(*Config)
+}
+
+// Change describes a setting whose value differs between two configurations
+type Change struct {
+	Env      string
+	From, To string
+}
+
+// NewLive starts at version 1 with cfg
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.current.Store(newSnapshot(cfg, 1))
+	return l
+}
+
+func newSnapshot(cfg *Config, version int) *Snapshot {
+	sum := sha256.New()
+	for _, v := range cfg.values {
+		fmt.Fprintf(sum, "%s=%s\n", v.Env, v.Value)
+	}
+	return &Snapshot{
+		Config:   cfg,
+		Version:  version,
+		LoadedAt: time.Now(),
+		Checksum: hex.EncodeToString(sum.Sum(nil))[:12],
+	}
+}
+
+// Snapshot returns the current version of the configuration
+func (l *Live) Snapshot() *Snapshot {
+	return l.current.Load()
+}
+
+// Current returns the current configuration
+func (l *Live) Current() *Config {
+	return l.current.Load().Config
+}
+
+// File returns the config file being watched, if any
+func (l *Live) File() string {
+	return l.Current().source.File
+}
+
+// OnChange registers fn to run with the new configuration after every reload
+// that changes a setting. Hooks run in order and must not block.
+func (l *Live) OnChange(fn func(*Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onChange = append(l.onChange, fn)
+}
+
+// Reload loads the configuration again from the sources it came from. An
+// invalid configuration is returned as an error and changes nothing. Applied
+// lists the reloadable settings that changed; rejected lists the changes to
+// restart-only settings, which keep their running values.
+func (l *Live) Reload() (applied, rejected []Change, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := l.current.Load()
+	next, err := Load(current.Config.source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged, applied, rejected, err := current.Config.merge(next)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(applied) == 0 {
+		return nil, rejected, nil
+	}
+
+	l.current.Store(newSnapshot(merged, current.Version+1))
+	for _, fn := range l.onChange {
+		fn(merged)
+	}
+	return applied, rejected, nil
+}
+
+// merge returns c with the reloadable settings of next. The merged Config is
+// built again from the merged values, so exactly the settings marked Reload
+// change whichever fields they are read into.
+func (c *Config) merge(next *Config) (*Config, []Change, []Change, error) {
+	var applied, rejected []Change
+	values := make([]Value, len(c.values))
+	for i, v := range c.values {
+		values[i] = v
+		n := next.values[i]
+		if n.Value == v.Value {
+			continue
+		}
+		change := Change{Env: v.Env, From: v.Redacted(), To: n.Redacted()}
+		if v.Reload {
+			values[i] = n
+			applied = append(applied, change)
+		} else {
+			rejected = append(rejected, change)
+		}
+	}
+
+	merged, err := build(valuesLoader(values))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Keep the layer each value came from and the sources to reload from
+	merged.values = values
+	merged.source = c.source
+	return merged, applied, rejected, nil
+}
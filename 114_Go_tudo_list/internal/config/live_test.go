@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReloadAppliesOnlyReloadableSettings(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	write(`
+storage: {driver: memory}
+log: {level: info}
+server: {port: 8080, read_timeout: 15s}
+database: {replica_max_lag: 5s}
+rate_limit: {limits: [api=100/1m]}
+cors: {allowed_origins: [https://a.example]}
+jwt: {ttl: 1h}
+`)
+	cfg, err := Load(Options{File: file})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	live := NewLive(cfg)
+
+	write(`
+storage: {driver: memory}
+log: {level: debug}
+server: {port: 9999, read_timeout: 30s}
+database: {replica_max_lag: 1s}
+rate_limit: {limits: [api=200/1m]}
+cors: {allowed_origins: [https://b.example]}
+jwt: {ttl: 2h}
+`)
+	applied, rejected, err := live.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	envs := func(changes []Change) []string {
+		var names []string
+		for _, c := range changes {
+			names = append(names, c.Env)
+		}
+		return names
+	}
+	if want := []string{"LOG_LEVEL", "DB_REPLICA_MAX_LAG", "RATE_LIMITS", "CORS_ALLOWED_ORIGINS"}; !reflect.DeepEqual(envs(applied), want) {
+		t.Errorf("applied: got %v, want %v", envs(applied), want)
+	}
+	if want := []string{"SERVER_PORT", "SERVER_READ_TIMEOUT", "JWT_TTL"}; !reflect.DeepEqual(envs(rejected), want) {
+		t.Errorf("rejected: got %v, want %v", envs(rejected), want)
+	}
+
+	got := live.Current()
+	if got.Log.Level != "debug" || got.Database.ReplicaMaxLag != time.Second ||
+		got.RateLimit.Limits["api"].Requests != 200 || !reflect.DeepEqual(got.CORS.AllowedOrigins, []string{"https://b.example"}) {
+		t.Errorf("reloadable settings were not applied: %+v", got)
+	}
+	if got.Server.Port != 8080 || got.Server.ReadTimeout != 15*time.Second || got.JWT.TTL != time.Hour {
+		t.Errorf("restart-only settings changed: port %d, read timeout %s, jwt ttl %s", got.Server.Port, got.Server.ReadTimeout, got.JWT.TTL)
+	}
+	if live.Snapshot().Version != 2 || live.File() != file {
+		t.Errorf("snapshot: got version %d for %q, want 2 for %q", live.Snapshot().Version, live.File(), file)
+	}
+}
+
+func TestEveryReloadableSettingChangesTheConfig(t *testing.T) {
+	base := map[string]string{"storage.driver": "memory"}
+	cfg, err := Load(Options{Overrides: base})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, s := range settings {
+		if !s.Reload {
+			continue
+		}
+		t.Run(s.Env, func(t *testing.T) {
+			next, err := Load(Options{Overrides: map[string]string{"storage.driver": "memory", s.Path: reloadedValue(s.Env)}})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			merged, applied, rejected, err := cfg.merge(next)
+			if err != nil {
+				t.Fatalf("merge: %v", err)
+			}
+			if len(applied) != 1 || applied[0].Env != s.Env || len(rejected) != 0 {
+				t.Fatalf("got applied %v and rejected %v, want only %s applied", applied, rejected, s.Env)
+			}
+			// The change must reach the field the setting is read into
+			next.values, next.source = merged.values, merged.source
+			if !reflect.DeepEqual(merged, next) {
+				t.Fatalf("merged config differs from the reloaded one:\n got %+v\nwant %+v", merged, next)
+			}
+		})
+	}
+}
+
+// reloadedValue returns a valid value for a reloadable setting that differs from its default
+func reloadedValue(env string) string {
+	switch env {
+	case "LOG_LEVEL":
+		return "debug"
+	case "RATE_LIMITS":
+		return "api=1/1s"
+	case "CORS_ALLOWED_ORIGINS":
+		return "https://app.example"
+	default:
+		return "42s"
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+// setting is one configuration value. It is read from the Path key of the
+// config file, the Env environment variable and the --Path flag.
+type setting struct {
+	Env     string
+	Path    string
+	Default string
+	// Sep joins the items of a list given as an array in the config file
+	Sep string
+	// Secret values are hidden by `config print --redacted`
+	Secret bool
+	// Reload marks settings a running server picks up from a changed config
+	// file or SIGHUP; changing any other setting needs a restart
+	Reload bool
+}
+
+// Insecure defaults refused outside the dev profile
+const (
+	defaultJWTSecret  = "your-secret-key-here"
+	defaultDBPassword = "password"
+)
+
+// settings is the configuration schema, in the order it is printed
+var settings = []setting{
+	{Env: "APP_ENV", Path: "app.env", Default: "dev"},
+	{Env: "LOG_LEVEL", Path: "log.level", Default: "info", Reload: true},
+
+	{Env: "STORAGE_DRIVER", Path: "storage.driver", Default: "postgres"},
+	{Env: "MEMORY_SNAPSHOT_PATH", Path: "storage.memory_snapshot_path"},
+	{Env: "SQLITE_PATH", Path: "storage.sqlite_path", Default: "./data/todos.db"},
+
+	{Env: "DB_HOST", Path: "database.host", Default: "localhost"},
+	{Env: "DB_PORT", Path: "database.port", Default: "5432"},
+	{Env: "DB_USER", Path: "database.user", Default: "postgres"},
+	{Env: "DB_PASSWORD", Path: "database.password", Default: defaultDBPassword, Secret: true},
+	{Env: "DB_NAME", Path: "database.name", Default: "todolist_db"},
+	{Env: "DB_SSLMODE", Path: "database.sslmode", Default: "disable"},
+	{Env: "DB_MAX_CONNS", Path: "database.max_conns", Default: "25"},
+	{Env: "DB_MIN_CONNS", Path: "database.min_conns", Default: "0"},
+	{Env: "DB_MAX_CONN_LIFETIME", Path: "database.max_conn_lifetime", Default: "5m"},
+	{Env: "DB_MAX_CONN_IDLE_TIME", Path: "database.max_conn_idle_time", Default: "5m"},
+	{Env: "DB_HEALTH_CHECK_PERIOD", Path: "database.health_check_period", Default: "1m"},
+	{Env: "DB_STATEMENT_CACHE_CAPACITY", Path: "database.statement_cache_capacity", Default: "512"},
+	{Env: "DB_CONNECT_RETRY_TIMEOUT", Path: "database.connect_retry_timeout", Default: "1m"},
+	{Env: "DB_BREAKER_THRESHOLD", Path: "database.breaker_threshold", Default: "5"},
+	{Env: "DB_BREAKER_COOLDOWN", Path: "database.breaker_cooldown", Default: "10s"},
+	{Env: "DB_READ_ATTEMPTS", Path: "database.read_attempts", Default: "3"},
+	{Env: "DB_REPLICA_DSNS", Path: "database.replica_dsns", Sep: ";", Secret: true},
+	{Env: "DB_REPLICA_MAX_LAG", Path: "database.replica_max_lag", Default: "5s", Reload: true},
+	{Env: "DB_REPLICA_CHECK_INTERVAL", Path: "database.replica_check_interval", Default: "5s", Reload: true},
+	{Env: "DB_READ_YOUR_WRITES_WINDOW", Path: "database.read_your_writes_window", Default: "5s", Reload: true},
+
+	{Env: "MYSQL_HOST", Path: "mysql.host", Default: "localhost"},
+	{Env: "MYSQL_PORT", Path: "mysql.port", Default: "3306"},
+	{Env: "MYSQL_USER", Path: "mysql.user", Default: "root"},
+	{Env: "MYSQL_PASSWORD", Path: "mysql.password", Secret: true},
+	{Env: "MYSQL_DATABASE", Path: "mysql.database", Default: "todolist_db"},
+
+	{Env: "CASSANDRA_HOSTS", Path: "cassandra.hosts", Default: "localhost:9042", Sep: ","},
+	{Env: "CASSANDRA_KEYSPACE", Path: "cassandra.keyspace", Default: "todo_app"},
+	{Env: "CASSANDRA_REPLICATION_FACTOR", Path: "cassandra.replication_factor", Default: "1"},
+	{Env: "CASSANDRA_READ_CONSISTENCY", Path: "cassandra.read_consistency", Default: "LOCAL_QUORUM"},
+	{Env: "CASSANDRA_WRITE_CONSISTENCY", Path: "cassandra.write_consistency", Default: "LOCAL_QUORUM"},
+	{Env: "CASSANDRA_COMPLETED_TTL", Path: "cassandra.completed_ttl", Default: "0s"},
+	{Env: "CASSANDRA_OWNER", Path: "cassandra.owner", Default: "default"},
+	{Env: "CASSANDRA_TIMEOUT", Path: "cassandra.timeout", Default: "5s"},
+
+	{Env: "CACHE_DRIVER", Path: "cache.driver", Default: "none"},
+	{Env: "CACHE_TTL", Path: "cache.ttl", Default: "30s"},
+	{Env: "CACHE_LRU_SIZE", Path: "cache.lru_size", Default: "1000"},
+	{Env: "CACHE_PUBSUB", Path: "cache.pubsub", Default: "false"},
+	{Env: "CACHE_INVALIDATION_CHANNEL", Path: "cache.invalidation_channel", Default: "todo-app:cache:invalidate"},
+
+	{Env: "REDIS_ADDR", Path: "redis.addr", Default: "localhost:6379"},
+	{Env: "REDIS_PASSWORD", Path: "redis.password", Secret: true},
+	{Env: "REDIS_DB", Path: "redis.db", Default: "0"},
+
+	{Env: "RATE_LIMIT_STORE", Path: "rate_limit.store", Default: "memory"},
+	{Env: "RATE_LIMITS", Path: "rate_limit.limits", Default: "api=300/1m,auth=600/1m,web=1200/1m", Sep: ",", Reload: true},
+
+	{Env: "IDEMPOTENCY_STORE", Path: "idempotency.store", Default: "memory"},
+	{Env: "IDEMPOTENCY_TTL", Path: "idempotency.ttl", Default: "24h"},
+
+	{Env: "GRAPHQL_MAX_DEPTH", Path: "graphql.max_depth", Default: "8"},
+	{Env: "GRAPHQL_MAX_COMPLEXITY", Path: "graphql.max_complexity", Default: "500"},
+	{Env: "GRAPHQL_PERSISTED_QUERY_STORE", Path: "graphql.persisted_query_store", Default: "memory"},
+	{Env: "GRAPHQL_PERSISTED_QUERY_CACHE_SIZE", Path: "graphql.persisted_query_cache_size", Default: "1000"},
+	{Env: "GRAPHQL_PERSISTED_QUERY_TTL", Path: "graphql.persisted_query_ttl", Default: "24h"},
+
+	{Env: "GRPC_ENABLED", Path: "grpc.enabled", Default: "true"},
+	{Env: "GRPC_PORT", Path: "grpc.port", Default: "9090"},
+	{Env: "GRPC_REFLECTION", Path: "grpc.reflection", Default: "false"},
+
+	{Env: "SERVER_HOST", Path: "server.host", Default: "localhost"},
+	{Env: "SERVER_PORT", Path: "server.port", Default: "8080"},
+	{Env: "SERVER_READ_TIMEOUT", Path: "server.read_timeout", Default: "15s"},
+	{Env: "SERVER_READ_HEADER_TIMEOUT", Path: "server.read_header_timeout", Default: "5s"},
+	{Env: "SERVER_WRITE_TIMEOUT", Path: "server.write_timeout", Default: "15s"},
+	{Env: "SERVER_IDLE_TIMEOUT", Path: "server.idle_timeout", Default: "1m"},
+	{Env: "SERVER_SHUTDOWN_TIMEOUT", Path: "server.shutdown_timeout", Default: "20s"},
+	{Env: "SERVER_MAX_HEADER_BYTES", Path: "server.max_header_bytes", Default: "1048576"},
+	{Env: "SERVER_MAX_BODY_BYTES", Path: "server.max_body_bytes", Default: "1048576"},
+	{Env: "SERVER_HEALTH_TIMEOUT", Path: "server.health_timeout", Default: "2s"},
+	{Env: "SERVER_TRUSTED_PROXIES", Path: "server.trusted_proxies", Default: "127.0.0.1,::1", Sep: ","},
+
+	{Env: "ADMIN_HOST", Path: "admin.host", Default: "127.0.0.1"},
+	{Env: "ADMIN_PORT", Path: "admin.port", Default: "8081"},
+
+	{Env: "CORS_ALLOWED_ORIGINS", Path: "cors.allowed_origins", Default: "*", Sep: ",", Reload: true},
+
+	{Env: "OPENAPI_VALIDATE", Path: "openapi.validate", Default: "false"},
+
+	{Env: "JWT_SECRET", Path: "jwt.secret", Default: defaultJWTSecret, Secret: true},
+	{Env: "JWT_TTL", Path: "jwt.ttl", Default: "1h"},
+
+	{Env: "AUTH_REQUIRED", Path: "auth.required", Default: "false"},
+	{Env: "API_TOKENS", Path: "auth.api_tokens", Sep: ",", Secret: true},
+
+	{Env: "TRACING_EXPORTER", Path: "tracing.exporter", Default: "none"},
+	{Env: "TRACING_OTLP_ENDPOINT", Path: "tracing.otlp_endpoint", Default: "localhost:4318"},
+	{Env: "TRACING_SERVICE_NAME", Path: "tracing.service_name", Default: "todo-app"},
+	{Env: "TRACING_SAMPLE_RATIO", Path: "tracing.sample_ratio", Default: "1"},
+}
@@ -0,0 +1,363 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Options are the sources layered over the built-in defaults. Later layers
+// win: the config file, then the environment (and .env), then Overrides.
+type Options struct {
+	// File is a YAML or TOML config file; empty falls back to CONFIG_FILE
+	File string
+	// Overrides are values set on the command line, keyed by setting path
+	Overrides map[string]string
+}
+
+// AddFlags registers --config and one --<path> flag per setting on fs, such
+// as --server.port, recording the flags that are set into o
+func (o *Options) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.File, "config", "", "YAML or TOML config `file` (default $CONFIG_FILE)")
+	for _, s := range settings {
+		fs.Var(override{o, s.Path}, s.Path, "overrides "+s.Env)
+	}
+}
+
+// override is the flag.Value behind a per-setting flag
+type override struct {
+	opts *Options
+	path string
+}
+
+func (o override) String() string {
+	if o.opts == nil {
+		return ""
+	}
+	return o.opts.Overrides[o.path]
+}
+
+func (o override) Set(value string) error {
+	if o.opts.Overrides == nil {
+		o.opts.Overrides = make(map[string]string)
+	}
+	o.opts.Overrides[o.path] = value
+	return nil
+}
+
+// Value is the effective value of one setting and the layer it came from
+type Value struct {
+	Env    string
+	Path   string
+	Value  string
+	Source string // default, file, env or flag
+	Secret bool
+	Reload bool
+}
+
+// Redacted returns the value, or REDACTED for a non-empty secret
+func (v Value) Redacted() string {
+	if v.Secret && v.Value != "" {
+		return "REDACTED"
+	}
+	return v.Value
+}
+
+// ValidationError lists every problem found while loading the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// loader resolves settings through the layers, collecting every problem
+// instead of stopping at the first
+type loader struct {
+	opts     Options
+	file     map[string]any
+	values   map[string]Value
+	problems []string
+	// failed holds settings that already have a problem, so a bad value is
+	// reported once rather than again by every later check
+	failed map[string]bool
+}
+
+func newLoader(opts Options) *loader {
+	l := &loader{opts: opts, values: make(map[string]Value), failed: make(map[string]bool)}
+	if l.opts.File == "" {
+		l.opts.File = os.Getenv("CONFIG_FILE")
+	}
+	if l.opts.File != "" {
+		file, err := readFile(l.opts.File)
+		if err != nil {
+			l.problems = append(l.problems, err.Error())
+		}
+		l.file = file
+		l.checkFileKeys("", file)
+	}
+	for path := range l.opts.Overrides {
+		if lookupPath(path) == nil {
+			l.problems = append(l.problems, fmt.Sprintf("unknown flag --%s", path))
+		}
+	}
+	return l
+}
+
+// valuesLoader resolves every setting to its entry in values, ignoring the
+// config file and the environment
+func valuesLoader(values []Value) *loader {
+	overrides := make(map[string]string, len(values))
+	for _, v := range values {
+		overrides[v.Path] = v.Value
+	}
+	return &loader{opts: Options{Overrides: overrides}, values: make(map[string]Value), failed: make(map[string]bool)}
+}
+
+// readFile parses a config file, picking YAML or TOML by its extension
+func readFile(name string) (map[string]any, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	file := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q (use .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", name, err)
+	}
+	return file, nil
+}
+
+// checkFileKeys reports keys in the config file that are not in the schema,
+// which are almost always typos
+func (l *loader) checkFileKeys(prefix string, node map[string]any) {
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path, value := prefix+key, node[key]
+		if lookupPath(path) != nil {
+			continue
+		}
+		if child, ok := value.(map[string]any); ok && isSection(path) {
+			l.checkFileKeys(path+".", child)
+			continue
+		}
+		l.problems = append(l.problems, fmt.Sprintf("unknown key %q in %s", path, l.opts.File))
+	}
+}
+
+// lookupPath returns the setting with the given path, or nil
+func lookupPath(path string) *setting {
+	for i := range settings {
+		if settings[i].Path == path {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// isSection reports whether path is the section part of some setting path
+func isSection(path string) bool {
+	for _, s := range settings {
+		if strings.HasPrefix(s.Path, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupEnv returns the setting read from the env variable, panicking on a
+// name missing from the schema since that is a bug in Load
+func lookupEnv(env string) *setting {
+	for i := range settings {
+		if settings[i].Env == env {
+			return &settings[i]
+		}
+	}
+	panic("config: setting " + env + " is not in the schema")
+}
+
+// fromFile returns the value at a dotted path in the config file
+func (l *loader) fromFile(s *setting) (string, bool) {
+	var node any = l.file
+	for _, key := range strings.Split(s.Path, ".") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if node, ok = m[key]; !ok {
+			return "", false
+		}
+	}
+	return fileString(node, s.Sep), true
+}
+
+// fileString flattens a config file value into the same text form its
+// environment variable uses
+func fileString(value any, sep string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fileString(item, sep)
+		}
+		return strings.Join(items, sep)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = key + "=" + fileString(v[key], sep)
+		}
+		return strings.Join(items, sep)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// get resolves a setting through the flag, env, file and default layers
+func (l *loader) get(env string) string {
+	s := lookupEnv(env)
+	v := Value{Env: s.Env, Path: s.Path, Value: s.Default, Source: "default", Secret: s.Secret, Reload: s.Reload}
+	if value, ok := l.opts.Overrides[s.Path]; ok {
+		v.Value, v.Source = value, "flag"
+	} else if value := os.Getenv(s.Env); value != "" {
+		v.Value, v.Source = value, "env"
+	} else if value, ok := l.fromFile(s); ok {
+		v.Value, v.Source = value, "file"
+	}
+	v.Value = strings.TrimSpace(v.Value)
+	l.values[env] = v
+	return v.Value
+}
+
+// invalid records a problem with a setting, naming where its value came from
+func (l *loader) invalid(env, format string, args ...any) {
+	if l.failed[env] {
+		return
+	}
+	l.failed[env] = true
+
+	v := l.values[env]
+	source := v.Source
+	if source == "flag" {
+		source = "flag --" + v.Path
+	}
+	l.problems = append(l.problems, fmt.Sprintf("%s=%q (%s): %s", env, v.Redacted(), source, fmt.Sprintf(format, args...)))
+}
+
+func (l *loader) str(env string) string {
+	return l.get(env)
+}
+
+func (l *loader) oneOf(env string, allowed ...string) string {
+	value := l.get(env)
+	for _, a := range allowed {
+		if value == a {
+			return value
+		}
+	}
+	l.invalid(env, "must be one of %s", strings.Join(allowed, ", "))
+	return value
+}
+
+func (l *loader) integer(env string, bitSize int) int64 {
+	n, err := strconv.ParseInt(l.get(env), 10, bitSize)
+	if err != nil {
+		l.invalid(env, "must be an integer")
+	}
+	return n
+}
+
+func (l *loader) boolean(env string) bool {
+	b, err := strconv.ParseBool(l.get(env))
+	if err != nil {
+		l.invalid(env, "must be true or false")
+	}
+	return b
+}
+
+func (l *loader) float(env string) float64 {
+	f, err := strconv.ParseFloat(l.get(env), 64)
+	if err != nil {
+		l.invalid(env, "must be a number")
+	}
+	return f
+}
+
+func (l *loader) duration(env string) time.Duration {
+	d, err := time.ParseDuration(l.get(env))
+	if err != nil {
+		l.invalid(env, "must be a duration such as 30s or 5m")
+	} else if d < 0 {
+		l.invalid(env, "must not be negative")
+	}
+	return d
+}
+
+// list splits a setting on its separator, dropping empty items
+func (l *loader) list(env string) []string {
+	var items []string
+	for _, item := range strings.Split(l.get(env), lookupEnv(env).Sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// port reads a TCP port number
+func (l *loader) port(env string) int {
+	n := int(l.integer(env, 0))
+	if n < 1 || n > 65535 {
+		l.invalid(env, "must be a port between 1 and 65535")
+	}
+	return n
+}
+
+// atLeast records a problem when an already read setting is below min
+func (l *loader) atLeast(env string, n, min int64) {
+	if n < min {
+		l.invalid(env, "must be at least %d", min)
+	}
+}
+
+// result returns the resolved values in schema order and any problems
+func (l *loader) result() ([]Value, error) {
+	values := make([]Value, 0, len(l.values))
+	for _, s := range settings {
+		if v, ok := l.values[s.Env]; ok {
+			values = append(values, v)
+		}
+	}
+	if len(l.problems) > 0 {
+		return values, &ValidationError{Problems: l.problems}
+	}
+	return values, nil
+}
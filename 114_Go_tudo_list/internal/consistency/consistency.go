@@ -0,0 +1,17 @@
+// Package consistency carries read-consistency hints from the HTTP layer to the repositories
+package consistency
+
+import "context"
+
+type primaryKey struct{}
+
+// PinPrimary marks ctx so that reads go to the primary database instead of a replica
+func PinPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryPinned reports whether reads for ctx must go to the primary
+func PrimaryPinned(ctx context.Context) bool {
+	pinned, _ := ctx.Value(primaryKey{}).(bool)
+	return pinned
+}
@@ -1,23 +1,89 @@
 package domain
 
-import "errors"
+import (
+	"time"
+)
+
+// Kind classifies domain errors so each transport can map them to its own
+// status codes without knowing every error
+type Kind int
+
+// Kinds of domain error
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindNotFound
+	KindConflict
+)
+
+// Error is a domain error with a stable, machine-readable code. The sentinel
+// values below are matched with errors.Is, which also sees through wrapping;
+// errors.As extracts the code and kind of any of them.
+type Error struct {
+	Kind Kind
+	Code string
+	// Field is the input field the error is about, if any
+	Field   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
 
 var (
 	// ErrTodoNotFound is returned when a todo is not found
-	ErrTodoNotFound = errors.New("todo not found")
+	ErrTodoNotFound = &Error{Kind: KindNotFound, Code: "todo_not_found", Message: "todo not found"}
 
 	// ErrInvalidTitle is returned when the title is invalid
-	ErrInvalidTitle = errors.New("title cannot be empty")
+	ErrInvalidTitle = &Error{Kind: KindInvalid, Code: "invalid_title", Field: "title", Message: "title cannot be empty"}
 
 	// ErrTitleTooLong is returned when the title is too long
-	ErrTitleTooLong = errors.New("title cannot be longer than 200 characters")
+	ErrTitleTooLong = &Error{Kind: KindInvalid, Code: "title_too_long", Field: "title", Message: "title cannot be longer than 200 characters"}
 
 	// ErrDescriptionTooLong is returned when the description is too long
-	ErrDescriptionTooLong = errors.New("description cannot be longer than 1000 characters")
+	ErrDescriptionTooLong = &Error{Kind: KindInvalid, Code: "description_too_long", Field: "description", Message: "description cannot be longer than 1000 characters"}
 
 	// ErrInvalidPriority is returned when the priority is invalid
-	ErrInvalidPriority = errors.New("priority must be one of: low, medium, high")
+	ErrInvalidPriority = &Error{Kind: KindInvalid, Code: "invalid_priority", Field: "priority", Message: "priority must be one of: low, medium, high"}
 
 	// ErrInvalidID is returned when the ID is invalid
-	ErrInvalidID = errors.New("invalid ID format")
+	ErrInvalidID = &Error{Kind: KindInvalid, Code: "invalid_id", Field: "id", Message: "invalid ID format"}
+
+	// ErrInvalidFieldset is returned when a read asks for a field a todo does not have
+	ErrInvalidFieldset = &Error{Kind: KindInvalid, Code: "invalid_fieldset", Field: "fields", Message: "fields must be a comma-separated list of: id, title, description, completed, priority, due_date, created_at, updated_at"}
+
+	// ErrPatchTestFailed is returned when a test operation of a JSON Patch fails
+	ErrPatchTestFailed = &Error{Kind: KindConflict, Code: "patch_test_failed", Message: "a test operation of the patch failed"}
+
+	// ErrPatchConflict is returned when a patch does not apply to the current todo
+	ErrPatchConflict = &Error{Kind: KindConflict, Code: "patch_conflict", Message: "the patch does not apply to the current todo"}
+
+	// ErrInvalidStatusFilter is returned when todos are filtered by an unknown status
+	ErrInvalidStatusFilter = &Error{Kind: KindInvalid, Code: "invalid_status_filter", Field: "status", Message: "status must be one of: completed, pending"}
 )
+
+var (
+	// ErrUserNotFound is returned when a user is not found
+	ErrUserNotFound = &Error{Kind: KindNotFound, Code: "user_not_found", Message: "user not found"}
+
+	// ErrUserExists is returned when a user with the same email already exists
+	ErrUserExists = &Error{Kind: KindConflict, Code: "user_exists", Field: "email", Message: "a user with this email already exists"}
+
+	// ErrInvalidEmail is returned when the email is invalid
+	ErrInvalidEmail = &Error{Kind: KindInvalid, Code: "invalid_email", Field: "email", Message: "invalid email address"}
+
+	// ErrPasswordTooShort is returned when the password is too short
+	ErrPasswordTooShort = &Error{Kind: KindInvalid, Code: "password_too_short", Field: "password", Message: "password must be at least 8 characters"}
+)
+
+// UnavailableError is returned when storage is temporarily unavailable and
+// requests are rejected without reaching it
+type UnavailableError struct {
+	// RetryAfter is how long until storage will be tried again
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return "storage is temporarily unavailable"
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// TodoEventType says how a todo changed
+type TodoEventType string
+
+// Todo event types
+const (
+	TodoCreated TodoEventType = "created"
+	TodoUpdated TodoEventType = "updated"
+	TodoDeleted TodoEventType = "deleted"
+)
+
+// TodoEvent reports a change to a todo. Todo is its state after the change,
+// or nil when it was deleted.
+type TodoEvent struct {
+	Type TodoEventType
+	ID   uuid.UUID
+	Todo *Todo
+}
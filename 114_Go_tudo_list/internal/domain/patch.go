@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+)
+
+// Field is one field of a TodoPatch. A patch leaves the field alone unless Set
+// is true; it then sets it to Value, or clears it when Null is true.
+type Field[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// Value returns a field that sets the value v
+func Value[T any](v T) Field[T] {
+	return Field[T]{Value: v, Set: true}
+}
+
+// Null returns a field that clears the value
+func Null[T any]() Field[T] {
+	return Field[T]{Set: true, Null: true}
+}
+
+// TodoPatch changes some fields of a todo and leaves the others. Clearing a
+// field resets it to its default: no description, medium priority, not
+// completed and no due date. A todo always has a title, so clearing it leaves
+// the todo invalid.
+type TodoPatch struct {
+	Title       Field[string]
+	Description Field[string]
+	Priority    Field[string]
+	Completed   Field[bool]
+	DueDate     Field[time.Time]
+}
+
+// TodoPatcher computes a patch from the current state of a todo, such as a
+// JSON Patch whose test operations compare against it
+type TodoPatcher interface {
+	Patch(current *Todo) (*TodoPatch, error)
+}
+
+// Patch returns p itself, which does not depend on the current todo
+func (p *TodoPatch) Patch(*Todo) (*TodoPatch, error) {
+	return p, nil
+}
+
+// Apply changes the fields of t that p sets. The result is not validated.
+func (p *TodoPatch) Apply(t *Todo) {
+	apply(p.Title, &t.Title, "")
+	apply(p.Description, &t.Description, "")
+	apply(p.Priority, &t.Priority, "medium")
+	apply(p.Completed, &t.Completed, false)
+
+	switch {
+	case p.DueDate.Null:
+		t.DueDate = nil
+	case p.DueDate.Set:
+		dueDate := p.DueDate.Value
+		t.DueDate = &dueDate
+	}
+}
+
+// apply sets *dst as f says, using def when f clears it
+func apply[T any](f Field[T], dst *T, def T) {
+	switch {
+	case f.Null:
+		*dst = def
+	case f.Set:
+		*dst = f.Value
+	}
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,25 +19,43 @@ type Todo struct {
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// TodoFields are the fields of a todo, named as in its JSON form and its
+// storage columns
+var TodoFields = []string{"id", "title", "description", "completed", "priority", "due_date", "created_at", "updated_at"}
+
 // TodoRepository defines the interface for todo data access
 type TodoRepository interface {
-	Create(todo *Todo) error
-	GetByID(id uuid.UUID) (*Todo, error)
-	GetAll() ([]*Todo, error)
-	Update(todo *Todo) error
-	Delete(id uuid.UUID) error
-	GetByStatus(completed bool) ([]*Todo, error)
+	Create(ctx context.Context, todo *Todo) error
+	// CreateMany stores todos in one bulk operation, assigning IDs and timestamps.
+	// Transactional backends store either all of them or none.
+	CreateMany(ctx context.Context, todos []*Todo) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Todo, error)
+	// GetByIDs returns the todos with the given IDs in one read, in no
+	// particular order. IDs without a todo are left out.
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*Todo, error)
+	GetAll(ctx context.Context) ([]*Todo, error)
+	Update(ctx context.Context, todo *Todo) error
+	// ToggleCompleted atomically flips the completed flag and returns the updated todo
+	ToggleCompleted(ctx context.Context, id uuid.UUID) (*Todo, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetByStatus(ctx context.Context, completed bool) ([]*Todo, error)
+	// Search returns todos whose title or description contain every word of query
+	Search(ctx context.Context, query string) ([]*Todo, error)
 }
 
 // TodoService defines the interface for todo business logic
 type TodoService interface {
-	CreateTodo(title, description, priority string, dueDate *time.Time) (*Todo, error)
-	GetTodo(id uuid.UUID) (*Todo, error)
-	GetAllTodos() ([]*Todo, error)
-	UpdateTodo(id uuid.UUID, title, description, priority string, completed bool, dueDate *time.Time) (*Todo, error)
-	DeleteTodo(id uuid.UUID) error
-	GetTodosByStatus(completed bool) ([]*Todo, error)
-	ToggleComplete(id uuid.UUID) (*Todo, error)
+	CreateTodo(ctx context.Context, title, description, priority string, dueDate *time.Time) (*Todo, error)
+	GetTodo(ctx context.Context, id uuid.UUID) (*Todo, error)
+	GetTodosByIDs(ctx context.Context, ids []uuid.UUID) ([]*Todo, error)
+	GetAllTodos(ctx context.Context) ([]*Todo, error)
+	UpdateTodo(ctx context.Context, id uuid.UUID, title, description, priority string, completed bool, dueDate *time.Time) (*Todo, error)
+	PatchTodo(ctx context.Context, id uuid.UUID, patcher TodoPatcher) (*Todo, error)
+	DeleteTodo(ctx context.Context, id uuid.UUID) error
+	GetTodosByStatus(ctx context.Context, completed bool) ([]*Todo, error)
+	SearchTodos(ctx context.Context, query string) ([]*Todo, error)
+	ToggleComplete(ctx context.Context, id uuid.UUID) (*Todo, error)
+	ImportTodos(ctx context.Context, todos []*Todo) (int, error)
 }
 
 // CreateTodoRequest represents the request to create a new todo
@@ -47,12 +66,13 @@ type CreateTodoRequest struct {
 	DueDate     *time.Time `json:"due_date"`
 }
 
-// UpdateTodoRequest represents the request to update a todo
+// UpdateTodoRequest represents the request to replace a todo. Omitted fields
+// take their defaults, as when a todo is created.
 type UpdateTodoRequest struct {
-	Title       *string    `json:"title" binding:"omitempty,min=1,max=200"`
-	Description *string    `json:"description" binding:"omitempty,max=1000"`
-	Priority    *string    `json:"priority" binding:"omitempty,oneof=low medium high"`
-	Completed   *bool      `json:"completed"`
+	Title       string     `json:"title" binding:"required,min=1,max=200"`
+	Description string     `json:"description" binding:"max=1000"`
+	Priority    string     `json:"priority" binding:"omitempty,oneof=low medium high"`
+	Completed   bool       `json:"completed"`
 	DueDate     *time.Time `json:"due_date"`
 }
 
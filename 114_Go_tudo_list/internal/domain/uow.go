@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+// Repositories are the repositories available inside a unit of work
+type Repositories struct {
+	Todos TodoRepository
+}
+
+// UnitOfWork runs a function against repositories that share one transaction.
+// The transaction commits if fn returns nil and rolls back otherwise. fn may be
+// called again if the transaction conflicts with a concurrent one, so it must
+// not have effects outside the repositories it is given.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
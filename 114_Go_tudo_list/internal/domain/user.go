@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User represents an account that can sign in to the todo app
+type User struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Email        string    `json:"email" db:"email"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Disabled     bool      `json:"disabled" db:"disabled"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UserRepository defines the interface for user data access
+type UserRepository interface {
+	Create(ctx context.Context, user *User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	Update(ctx context.Context, user *User) error
+}
+
+// UserService defines the interface for user administration
+type UserService interface {
+	CreateUser(ctx context.Context, email, password string) (*User, error)
+	DisableUser(ctx context.Context, email string) (*User, error)
+	ResetPassword(ctx context.Context, email, password string) (*User, error)
+}
+
+// Validate validates the user domain model
+func (u *User) Validate() error {
+	if _, err := mail.ParseAddress(u.Email); err != nil || len(u.Email) > 255 {
+		return ErrInvalidEmail
+	}
+	return nil
+}
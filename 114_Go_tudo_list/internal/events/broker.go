@@ -0,0 +1,70 @@
+// Package events fans todo changes out to in-process subscribers, such as
+// GraphQL subscriptions and gRPC watch streams
+package events
+
+import (
+	"context"
+	"sync"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/telemetry"
+)
+
+// stats is published at /debug/vars as "todo_events"
+var stats = telemetry.NewMetrics("todo_events")
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped
+const subscriberBuffer = 64
+
+// Broker delivers every published event to every current subscriber. It only
+// sees changes made through this instance.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan domain.TodoEvent]struct{}
+}
+
+// NewBroker creates a Broker without subscribers
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[chan domain.TodoEvent]struct{})}
+}
+
+// Publish sends event to every subscriber without blocking. A subscriber that
+// has fallen too far behind is dropped: its channel is closed rather than
+// silently missing events.
+func (b *Broker) Publish(event domain.TodoEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats.Add("published", 1)
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+			stats.Add("dropped_subscribers", 1)
+		}
+	}
+}
+
+// Subscribe returns a channel of the events published from now on. It is
+// closed when ctx is cancelled or the subscriber is dropped.
+func (b *Broker) Subscribe(ctx context.Context) <-chan domain.TodoEvent {
+	ch := make(chan domain.TodoEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"todo-app/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// TodoService is a domain.TodoService decorator that publishes a TodoEvent
+// after every successful change. Subscribers share the published todos and
+// must not modify them.
+type TodoService struct {
+	domain.TodoService
+	broker *Broker
+}
+
+// NewTodoService wraps next so that its changes are published to broker
+func NewTodoService(next domain.TodoService, broker *Broker) *TodoService {
+	return &TodoService{TodoService: next, broker: broker}
+}
+
+// CreateTodo creates a todo and publishes it
+func (s *TodoService) CreateTodo(ctx context.Context, title, description, priority string, dueDate *time.Time) (*domain.Todo, error) {
+	todo, err := s.TodoService.CreateTodo(ctx, title, description, priority, dueDate)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(domain.TodoCreated, todo)
+	return todo, nil
+}
+
+// UpdateTodo replaces a todo and publishes it
+func (s *TodoService) UpdateTodo(ctx context.Context, id uuid.UUID, title, description, priority string, completed bool, dueDate *time.Time) (*domain.Todo, error) {
+	todo, err := s.TodoService.UpdateTodo(ctx, id, title, description, priority, completed, dueDate)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(domain.TodoUpdated, todo)
+	return todo, nil
+}
+
+// PatchTodo patches a todo and publishes it
+func (s *TodoService) PatchTodo(ctx context.Context, id uuid.UUID, patcher domain.TodoPatcher) (*domain.Todo, error) {
+	todo, err := s.TodoService.PatchTodo(ctx, id, patcher)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(domain.TodoUpdated, todo)
+	return todo, nil
+}
+
+// DeleteTodo deletes a todo and publishes its ID
+func (s *TodoService) DeleteTodo(ctx context.Context, id uuid.UUID) error {
+	if err := s.TodoService.DeleteTodo(ctx, id); err != nil {
+		return err
+	}
+	s.broker.Publish(domain.TodoEvent{Type: domain.TodoDeleted, ID: id})
+	return nil
+}
+
+// ToggleComplete toggles a todo and publishes it
+func (s *TodoService) ToggleComplete(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	todo, err := s.TodoService.ToggleComplete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(domain.TodoUpdated, todo)
+	return todo, nil
+}
+
+// ImportTodos imports todos and publishes each of them
+func (s *TodoService) ImportTodos(ctx context.Context, todos []*domain.Todo) (int, error) {
+	imported, err := s.TodoService.ImportTodos(ctx, todos)
+	if err != nil {
+		return imported, err
+	}
+	for _, todo := range todos {
+		s.publish(domain.TodoCreated, todo)
+	}
+	return imported, nil
+}
+
+// publish sends a copy of todo, so later changes by the caller are not seen
+func (s *TodoService) publish(eventType domain.TodoEventType, todo *domain.Todo) {
+	published := *todo
+	s.broker.Publish(domain.TodoEvent{Type: eventType, ID: todo.ID, Todo: &published})
+}
@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"context"
+	"math"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/problem"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+type requestIDKey struct{}
+
+// withRequestID returns ctx tagged with the ID of the request running its operations
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestID returns the request ID withRequestID added to ctx
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// fieldError is the error of a field that failed to resolve. It is described
+// as the problem the REST API would answer with, so clients see the same codes.
+type fieldError struct {
+	problem *problem.Problem
+}
+
+// resolverError maps err to the error a resolver returns. Internal errors are
+// logged and their details are not exposed.
+func resolverError(ctx context.Context, err error) error {
+	p := problem.From(err)
+	if p.Status >= 500 {
+		logging.Errorf("GraphQL resolver failed (request %s): %v", requestID(ctx), err)
+	}
+	return &fieldError{p}
+}
+
+func (e *fieldError) Error() string {
+	if e.problem.Detail != "" {
+		return e.problem.Detail
+	}
+	return e.problem.Title
+}
+
+// Extensions returns the problem code and status, and the invalid fields if any
+func (e *fieldError) Extensions() map[string]any {
+	extensions := map[string]any{
+		"code":   e.problem.Code,
+		"status": e.problem.Status,
+	}
+	if len(e.problem.Errors) > 0 {
+		extensions["errors"] = e.problem.Errors
+	}
+	if e.problem.RetryAfter > 0 {
+		extensions["retry_after"] = max(int(math.Ceil(e.problem.RetryAfter.Seconds())), 1)
+	}
+	return extensions
+}
+
+// requestError returns an error that stops an operation before it runs
+func requestError(code, message string) *gqlerrors.QueryError {
+	return &gqlerrors.QueryError{Message: message, Extensions: map[string]any{"code": code}}
+}
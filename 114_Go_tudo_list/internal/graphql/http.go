@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/middleware"
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Handle serves /graphql. A POST runs the JSON request in its body; a GET runs
+// the request in its query string, which may not be a mutation so that GETs
+// stay safe; and a WebSocket upgrade opens a graphql-transport-ws connection
+// for subscriptions. Requests that are not GraphQL are answered with a
+// problem; anything else is a GraphQL response with status 200.
+func (s *Server) Handle(c *gin.Context) {
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		s.serveWebSocket(c)
+		return
+	}
+
+	var req Request
+	if c.Request.Method == http.MethodGet {
+		if err := readQueryString(c, &req); err != nil {
+			c.Error(err)
+			return
+		}
+	} else {
+		if c.ContentType() != "application/json" {
+			c.Error(problem.New("unsupported_media_type", "GraphQL requests must be sent as application/json"))
+			return
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+	}
+
+	ctx := withRequestID(c.Request.Context(), middleware.GetRequestID(c))
+	query, operation, resp := s.prepare(ctx, &req)
+	if resp == nil {
+		switch {
+		case operation == ast.Mutation && c.Request.Method == http.MethodGet:
+			c.Header("Allow", http.MethodPost)
+			c.Error(problem.New("method_not_allowed", "Mutations must be sent with POST"))
+			return
+		case operation == ast.Subscription:
+			resp = &graphqlgo.Response{Errors: []*gqlerrors.QueryError{requestError("bad_request",
+				"subscriptions need a WebSocket connection using the graphql-transport-ws protocol")}}
+		default:
+			resp = s.exec(ctx, query, &req)
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// readQueryString reads a request from the query, operationName, variables
+// and extensions parameters; the last two hold JSON objects
+func readQueryString(c *gin.Context, req *Request) error {
+	req.Query = c.Query("query")
+	req.OperationName = c.Query("operationName")
+	if variables := c.Query("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			return &domain.Error{Kind: domain.KindInvalid, Code: "invalid_json", Field: "variables", Message: "variables must be a JSON object"}
+		}
+	}
+	if extensions := c.Query("extensions"); extensions != "" {
+		if err := json.Unmarshal([]byte(extensions), &req.Extensions); err != nil {
+			return &domain.Error{Kind: domain.KindInvalid, Code: "invalid_json", Field: "extensions", Message: "extensions must be a JSON object"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// listFactor is how many items a list field is assumed to return when
+// estimating the complexity of an operation
+const listFactor = 10
+
+// limits rejects operations too deep or too complex to run. It parses and
+// validates them against its own copy of the schema.
+type limits struct {
+	schema        *ast.Schema
+	maxDepth      int
+	maxComplexity int
+}
+
+// newLimits returns limits for operations on the schema sdl
+func newLimits(sdl string, maxDepth, maxComplexity int) (*limits, error) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema: %v", err)
+	}
+	return &limits{schema: schema, maxDepth: maxDepth, maxComplexity: maxComplexity}, nil
+}
+
+// check validates query and checks the operation named operationName, or
+// every operation when the name matches none, against the limits. It returns
+// the type of that operation.
+func (l *limits) check(query, operationName string) (ast.Operation, []*gqlerrors.QueryError) {
+	doc, errs := gqlparser.LoadQuery(l.schema, query)
+	if len(errs) > 0 {
+		queryErrs := make([]*gqlerrors.QueryError, len(errs))
+		for i, err := range errs {
+			queryErrs[i] = &gqlerrors.QueryError{Message: err.Message}
+			for _, loc := range err.Locations {
+				queryErrs[i].Locations = append(queryErrs[i].Locations, gqlerrors.Location{Line: loc.Line, Column: loc.Column})
+			}
+		}
+		return "", queryErrs
+	}
+
+	ops := doc.Operations
+	if op := doc.Operations.ForName(operationName); op != nil {
+		ops = ast.OperationList{op}
+	}
+
+	var operation ast.Operation
+	for _, op := range ops {
+		operation = op.Operation
+		if d := depth(op.SelectionSet); d > l.maxDepth {
+			return operation, []*gqlerrors.QueryError{requestError("query_too_deep",
+				fmt.Sprintf("operation nests fields %d levels deep, more than the limit of %d", d, l.maxDepth))}
+		}
+		if c := complexity(op.SelectionSet); c > l.maxComplexity {
+			return operation, []*gqlerrors.QueryError{requestError("query_too_complex",
+				fmt.Sprintf("operation has a complexity of %d, more than the limit of %d", c, l.maxComplexity))}
+		}
+	}
+	return operation, nil
+}
+
+// depth returns how deeply set nests fields. Introspection fields are not
+// counted: they read the schema, not todos.
+func depth(set ast.SelectionSet) int {
+	deepest := 0
+	for _, sel := range set {
+		d := 0
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			d = 1 + depth(sel.SelectionSet)
+		case *ast.InlineFragment:
+			d = depth(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			d = depth(sel.Definition.SelectionSet)
+		}
+		deepest = max(deepest, d)
+	}
+	return deepest
+}
+
+// complexity estimates how many fields resolving set takes: one per field,
+// with the fields under a list counted listFactor times
+func complexity(set ast.SelectionSet) int {
+	total := 0
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			children := complexity(sel.SelectionSet)
+			if sel.Definition != nil && sel.Definition.Type.Elem != nil {
+				children *= listFactor
+			}
+			total += 1 + children
+		case *ast.InlineFragment:
+			total += complexity(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			total += complexity(sel.Definition.SelectionSet)
+		}
+	}
+	return total
+}
@@ -0,0 +1,57 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"todo-app/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait is how long a loader collects keys before it loads them in one batch
+const loaderWait = 2 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch and cache the reads of one operation
+type loaders struct {
+	todos *dataloader.Loader[uuid.UUID, *domain.Todo]
+}
+
+// withLoaders returns ctx with fresh loaders reading from todos
+func withLoaders(ctx context.Context, todos domain.TodoService) context.Context {
+	batch := func(ctx context.Context, ids []uuid.UUID) []*dataloader.Result[*domain.Todo] {
+		found, err := todos.GetTodosByIDs(ctx, ids)
+		results := make([]*dataloader.Result[*domain.Todo], len(ids))
+		if err != nil {
+			for i := range results {
+				results[i] = &dataloader.Result[*domain.Todo]{Error: err}
+			}
+			return results
+		}
+
+		byID := make(map[uuid.UUID]*domain.Todo, len(found))
+		for _, todo := range found {
+			byID[todo.ID] = todo
+		}
+		for i, id := range ids {
+			if todo, ok := byID[id]; ok {
+				results[i] = &dataloader.Result[*domain.Todo]{Data: todo}
+			} else {
+				results[i] = &dataloader.Result[*domain.Todo]{Error: domain.ErrTodoNotFound}
+			}
+		}
+		return results
+	}
+
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		todos: dataloader.NewBatchedLoader(batch, dataloader.WithWait[uuid.UUID, *domain.Todo](loaderWait)),
+	})
+}
+
+// loadersFrom returns the loaders withLoaders added to ctx
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,61 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/repository/cache"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// persistedQueries implements Apollo's automatic persisted queries: a client
+// sends the SHA-256 hash of a query instead of its text and, the first time,
+// the text along with the hash so the server can register it
+type persistedQueries struct {
+	store cache.Store
+	ttl   time.Duration
+}
+
+// persistedQuery is the persistedQuery member of a request's extensions
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+// resolve returns the query text of a request. A request without a persisted
+// query keeps its own; one with both a hash and a query registers the query.
+// Errors carry the codes Apollo clients look for.
+func (p *persistedQueries) resolve(ctx context.Context, query string, persisted *persistedQuery) (string, *gqlerrors.QueryError) {
+	if persisted == nil {
+		return query, nil
+	}
+	if persisted.Version != 1 {
+		return "", requestError("PERSISTED_QUERY_NOT_SUPPORTED", "Unsupported persisted query version")
+	}
+
+	key := "graphql:apq:" + persisted.SHA256Hash
+	if query == "" {
+		data, ok, err := p.store.Get(ctx, key)
+		if err != nil {
+			logging.Warnf("Persisted query lookup failed: %v", err)
+		}
+		if !ok {
+			return "", requestError("PERSISTED_QUERY_NOT_FOUND", "PersistedQueryNotFound")
+		}
+		return string(data), nil
+	}
+
+	sum := sha256.Sum256([]byte(query))
+	if hex.EncodeToString(sum[:]) != persisted.SHA256Hash {
+		return "", requestError("PERSISTED_QUERY_HASH_MISMATCH", "provided sha256Hash does not match query")
+	}
+	if err := p.store.Set(ctx, key, []byte(query), p.ttl); err != nil {
+		// The query still runs; the client sends it again next time
+		logging.Warnf("Persisted query registration failed: %v", err)
+	}
+	return query, nil
+}
@@ -0,0 +1,368 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/events"
+	"todo-app/internal/projection"
+
+	"github.com/google/uuid"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+)
+
+// resolver is the root resolver of the schema: its methods resolve the
+// fields of Query, Mutation and Subscription
+type resolver struct {
+	todos  domain.TodoService
+	broker *events.Broker
+}
+
+func (r *resolver) Todo(ctx context.Context, args struct{ ID graphqlgo.ID }) (*todoResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	todo, err := loadersFrom(ctx).todos.Load(ctx, id)()
+	if errors.Is(err, domain.ErrTodoNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return &todoResolver{todo}, nil
+}
+
+func (r *resolver) Todos(ctx context.Context, args struct {
+	IDs    *[]graphqlgo.ID
+	Search *string
+	Status *string
+}) ([]*todoResolver, error) {
+	if args.IDs != nil {
+		return r.todosByID(ctx, *args.IDs)
+	}
+
+	// Read only the columns of the selected fields, as a sparse fieldset would
+	var fields []string
+	for _, name := range graphqlgo.SelectedFieldNames(ctx) {
+		if column, ok := columns[name]; ok {
+			fields = append(fields, column)
+		}
+	}
+	ctx = projection.WithFields(ctx, fields)
+
+	var todos []*domain.Todo
+	var err error
+	switch {
+	case args.Search != nil:
+		todos, err = r.todos.SearchTodos(ctx, *args.Search)
+	case args.Status != nil:
+		todos, err = r.todos.GetTodosByStatus(ctx, *args.Status == "COMPLETED")
+	default:
+		todos, err = r.todos.GetAllTodos(ctx)
+	}
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return todoResolvers(todos), nil
+}
+
+// todosByID loads the todos with the given IDs in one batch, in order
+func (r *resolver) todosByID(ctx context.Context, ids []graphqlgo.ID) ([]*todoResolver, error) {
+	keys := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		var err error
+		if keys[i], err = parseID(id); err != nil {
+			return nil, resolverError(ctx, err)
+		}
+	}
+
+	loaded, errs := loadersFrom(ctx).todos.LoadMany(ctx, keys)()
+	var todos []*domain.Todo
+	for i, todo := range loaded {
+		if errs != nil && errs[i] != nil {
+			if errors.Is(errs[i], domain.ErrTodoNotFound) {
+				continue
+			}
+			return nil, resolverError(ctx, errs[i])
+		}
+		todos = append(todos, todo)
+	}
+	return todoResolvers(todos), nil
+}
+
+func (r *resolver) Stats(ctx context.Context) (*statsResolver, error) {
+	todos, err := r.todos.GetAllTodos(projection.WithFields(ctx, []string{"completed", "priority", "due_date"}))
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+
+	stats := &statsResolver{byPriority: map[string]int32{}}
+	now := time.Now()
+	for _, todo := range todos {
+		stats.total++
+		if todo.Completed {
+			stats.completed++
+		} else if todo.DueDate != nil && todo.DueDate.Before(now) {
+			stats.overdue++
+		}
+		stats.byPriority[priorityEnum(todo.Priority)]++
+	}
+	return stats, nil
+}
+
+func (r *resolver) CreateTodo(ctx context.Context, args struct {
+	Input struct {
+		Title       string
+		Description *string
+		Priority    *string
+		DueDate     *graphqlgo.Time
+	}
+}) (*todoResolver, error) {
+	in := args.Input
+	todo, err := r.todos.CreateTodo(ctx, in.Title, valueOf(in.Description), priorityValue(in.Priority), timeOf(in.DueDate))
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return changed(ctx, todo), nil
+}
+
+func (r *resolver) UpdateTodo(ctx context.Context, args struct {
+	ID    graphqlgo.ID
+	Input struct {
+		Title       string
+		Description *string
+		Priority    *string
+		Completed   *bool
+		DueDate     *graphqlgo.Time
+	}
+}) (*todoResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	in := args.Input
+	todo, err := r.todos.UpdateTodo(ctx, id, in.Title, valueOf(in.Description), priorityValue(in.Priority), valueOf(in.Completed), timeOf(in.DueDate))
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return changed(ctx, todo), nil
+}
+
+func (r *resolver) PatchTodo(ctx context.Context, args struct {
+	ID    graphqlgo.ID
+	Input struct {
+		Title       graphqlgo.NullString
+		Description graphqlgo.NullString
+		Priority    nullPriority
+		Completed   graphqlgo.NullBool
+		DueDate     graphqlgo.NullTime
+	}
+}) (*todoResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	in := args.Input
+	patch := &domain.TodoPatch{
+		Title:       field(in.Title.Set, in.Title.Value),
+		Description: field(in.Description.Set, in.Description.Value),
+		Priority:    field(in.Priority.Set, in.Priority.Value),
+		Completed:   field(in.Completed.Set, in.Completed.Value),
+		DueDate:     field(in.DueDate.Set, timeOf(in.DueDate.Value)),
+	}
+	todo, err := r.todos.PatchTodo(ctx, id, patch)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return changed(ctx, todo), nil
+}
+
+func (r *resolver) ToggleTodo(ctx context.Context, args struct{ ID graphqlgo.ID }) (*todoResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	todo, err := r.todos.ToggleComplete(ctx, id)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return changed(ctx, todo), nil
+}
+
+func (r *resolver) DeleteTodo(ctx context.Context, args struct{ ID graphqlgo.ID }) (graphqlgo.ID, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return "", resolverError(ctx, err)
+	}
+	if err := r.todos.DeleteTodo(ctx, id); err != nil {
+		return "", resolverError(ctx, err)
+	}
+	loadersFrom(ctx).todos.Clear(ctx, id)
+	return args.ID, nil
+}
+
+func (r *resolver) TodoChanged(ctx context.Context, args struct{ ID *graphqlgo.ID }) (<-chan *todoEventResolver, error) {
+	var only uuid.UUID
+	if args.ID != nil {
+		var err error
+		if only, err = parseID(*args.ID); err != nil {
+			return nil, resolverError(ctx, err)
+		}
+	}
+
+	events := r.broker.Subscribe(ctx)
+	out := make(chan *todoEventResolver)
+	go func() {
+		defer close(out)
+		for event := range events {
+			if only != uuid.Nil && event.ID != only {
+				continue
+			}
+			select {
+			case out <- &todoEventResolver{event}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// changed primes the todo loader with a todo a mutation returned, so later
+// fields of the operation read its new state
+func changed(ctx context.Context, todo *domain.Todo) *todoResolver {
+	loadersFrom(ctx).todos.Clear(ctx, todo.ID).Prime(ctx, todo.ID, todo)
+	return &todoResolver{todo}
+}
+
+// statsResolver resolves the fields of TodoStats
+type statsResolver struct {
+	total, completed, overdue int32
+	byPriority                map[string]int32
+}
+
+func (r *statsResolver) Total() int32 {
+	return r.total
+}
+
+func (r *statsResolver) Completed() int32 {
+	return r.completed
+}
+
+func (r *statsResolver) Pending() int32 {
+	return r.total - r.completed
+}
+
+func (r *statsResolver) Overdue() int32 {
+	return r.overdue
+}
+
+func (r *statsResolver) ByPriority() []*priorityCountResolver {
+	var counts []*priorityCountResolver
+	for _, priority := range []string{"LOW", "MEDIUM", "HIGH"} {
+		counts = append(counts, &priorityCountResolver{priority, r.byPriority[priority]})
+	}
+	return counts
+}
+
+// priorityCountResolver resolves the fields of PriorityCount
+type priorityCountResolver struct {
+	priority string
+	count    int32
+}
+
+func (r *priorityCountResolver) Priority() string {
+	return r.priority
+}
+
+func (r *priorityCountResolver) Count() int32 {
+	return r.count
+}
+
+// todoEventResolver resolves the fields of TodoEvent
+type todoEventResolver struct {
+	event domain.TodoEvent
+}
+
+func (r *todoEventResolver) Type() string {
+	return strings.ToUpper(string(r.event.Type))
+}
+
+func (r *todoEventResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.event.ID.String())
+}
+
+func (r *todoEventResolver) Todo() *todoResolver {
+	if r.event.Todo == nil {
+		return nil
+	}
+	return &todoResolver{r.event.Todo}
+}
+
+// nullPriority is a Priority argument that tells null apart from a missing one
+type nullPriority struct {
+	Value *string
+	Set   bool
+}
+
+func (nullPriority) ImplementsGraphQLType(name string) bool {
+	return name == "Priority"
+}
+
+func (p *nullPriority) UnmarshalGraphQL(input any) error {
+	p.Set = true
+	if input == nil {
+		return nil
+	}
+	priority, ok := input.(string)
+	if !ok {
+		return errors.New("wrong type for Priority")
+	}
+	value := priorityValue(&priority)
+	p.Value = &value
+	return nil
+}
+
+func (p *nullPriority) Nullable() {}
+
+// parseID parses the ID of a todo
+func parseID(id graphqlgo.ID) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(string(id))
+	if err != nil {
+		return uuid.Nil, domain.ErrInvalidID
+	}
+	return parsed, nil
+}
+
+// field returns the patch field of a nullable argument: unchanged when it is
+// missing, cleared when it is null and set otherwise
+func field[T any](set bool, value *T) domain.Field[T] {
+	switch {
+	case !set:
+		return domain.Field[T]{}
+	case value == nil:
+		return domain.Null[T]()
+	}
+	return domain.Value(*value)
+}
+
+// valueOf returns *v, or the zero value for nil
+func valueOf[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
+// timeOf returns the time t holds, or nil
+func timeOf(t *graphqlgo.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
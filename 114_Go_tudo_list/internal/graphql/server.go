@@ -0,0 +1,124 @@
+// Package graphql serves a GraphQL API over the todo service, alongside the
+// REST endpoints: queries and mutations over HTTP, and subscriptions to todo
+// changes over WebSocket with the graphql-transport-ws protocol
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"sync"
+	"time"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/domain"
+	"todo-app/internal/events"
+	"todo-app/internal/repository/cache"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Options configures a Server
+type Options struct {
+	// MaxDepth and MaxComplexity reject operations before they run
+	MaxDepth      int
+	MaxComplexity int
+	// PersistedQueries keeps the queries registered by clients for
+	// PersistedQueryTTL after their last registration
+	PersistedQueries  cache.Store
+	PersistedQueryTTL time.Duration
+	// AllowedOrigins returns the origins browsers may open subscriptions from;
+	// "*" allows any. It is read on every connection.
+	AllowedOrigins func() []string
+	// Verifier checks the credentials WebSocket clients send in
+	// connection_init when the upgrade request carried none
+	Verifier *auth.Verifier
+}
+
+// Request is a GraphQL request, as sent in a POST body or a subscribe message
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *persistedQuery `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// Server runs GraphQL operations against a domain.TodoService
+type Server struct {
+	schema         *graphqlgo.Schema
+	todos          domain.TodoService
+	limits         *limits
+	persisted      *persistedQueries
+	allowedOrigins func() []string
+	verifier       *auth.Verifier
+
+	// done is closed on Shutdown to end the open subscriptions
+	done     chan struct{}
+	shutdown sync.Once
+}
+
+// NewServer returns a Server reading and changing todos through todos, whose
+// changes must be published to broker for subscriptions to see them
+func NewServer(todos domain.TodoService, broker *events.Broker, opts Options) (*Server, error) {
+	schema, err := graphqlgo.ParseSchema(schemaSDL, &resolver{todos: todos, broker: broker},
+		graphqlgo.UseStringDescriptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL schema: %v", err)
+	}
+	limits, err := newLimits(schemaSDL, opts.MaxDepth, opts.MaxComplexity)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		schema:         schema,
+		todos:          todos,
+		limits:         limits,
+		persisted:      &persistedQueries{store: opts.PersistedQueries, ttl: opts.PersistedQueryTTL},
+		allowedOrigins: opts.AllowedOrigins,
+		verifier:       opts.Verifier,
+		done:           make(chan struct{}),
+	}, nil
+}
+
+// Shutdown closes the open subscriptions. The HTTP server does not track
+// WebSocket connections, so it must be called when the server shuts down.
+func (s *Server) Shutdown() {
+	s.shutdown.Do(func() { close(s.done) })
+}
+
+// prepare resolves the query of req and checks it against the limits,
+// returning the query and the type of the operation to run
+func (s *Server) prepare(ctx context.Context, req *Request) (string, ast.Operation, *graphqlgo.Response) {
+	query, err := s.persisted.resolve(ctx, req.Query, req.Extensions.PersistedQuery)
+	if err != nil {
+		return "", "", &graphqlgo.Response{Errors: []*gqlerrors.QueryError{err}}
+	}
+	if query == "" {
+		return "", "", &graphqlgo.Response{Errors: []*gqlerrors.QueryError{requestError("bad_request", "query is required")}}
+	}
+	operation, errs := s.limits.check(query, req.OperationName)
+	if len(errs) > 0 {
+		return "", "", &graphqlgo.Response{Errors: errs}
+	}
+	return query, operation, nil
+}
+
+// exec runs a query or mutation
+func (s *Server) exec(ctx context.Context, query string, req *Request) *graphqlgo.Response {
+	ctx = withLoaders(ctx, s.todos)
+	return s.schema.Exec(ctx, query, req.OperationName, req.Variables)
+}
+
+// subscribe runs an operation of any type and returns its responses: one for
+// a query or mutation, and one per event for a subscription until ctx ends
+func (s *Server) subscribe(ctx context.Context, query string, req *Request) (<-chan any, error) {
+	ctx = withLoaders(ctx, s.todos)
+	return s.schema.Subscribe(ctx, query, req.OperationName, req.Variables)
+}
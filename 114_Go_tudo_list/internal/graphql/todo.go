@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"strings"
+
+	"todo-app/internal/domain"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+)
+
+// columns maps the fields of the Todo type to the columns they read, for
+// projecting list queries onto the selected fields
+var columns = map[string]string{
+	"id":          "id",
+	"title":       "title",
+	"description": "description",
+	"completed":   "completed",
+	"priority":    "priority",
+	"dueDate":     "due_date",
+	"createdAt":   "created_at",
+	"updatedAt":   "updated_at",
+}
+
+// todoResolver resolves the fields of a Todo
+type todoResolver struct {
+	todo *domain.Todo
+}
+
+func (r *todoResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.todo.ID.String())
+}
+
+func (r *todoResolver) Title() string {
+	return r.todo.Title
+}
+
+func (r *todoResolver) Description() string {
+	return r.todo.Description
+}
+
+func (r *todoResolver) Completed() bool {
+	return r.todo.Completed
+}
+
+func (r *todoResolver) Priority() string {
+	return priorityEnum(r.todo.Priority)
+}
+
+func (r *todoResolver) DueDate() *graphqlgo.Time {
+	if r.todo.DueDate == nil {
+		return nil
+	}
+	return &graphqlgo.Time{Time: *r.todo.DueDate}
+}
+
+func (r *todoResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.todo.CreatedAt}
+}
+
+func (r *todoResolver) UpdatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.todo.UpdatedAt}
+}
+
+// todoResolvers wraps todos for resolving
+func todoResolvers(todos []*domain.Todo) []*todoResolver {
+	resolvers := make([]*todoResolver, len(todos))
+	for i, todo := range todos {
+		resolvers[i] = &todoResolver{todo}
+	}
+	return resolvers
+}
+
+// priorityEnum returns the Priority value of a stored priority. Todos stored
+// before priorities were required have none and count as medium.
+func priorityEnum(priority string) string {
+	if priority == "" {
+		return "MEDIUM"
+	}
+	return strings.ToUpper(priority)
+}
+
+// priorityValue returns the stored priority of a Priority value, or "" for nil
+func priorityValue(priority *string) string {
+	if priority == nil {
+		return ""
+	}
+	return strings.ToLower(*priority)
+}
@@ -0,0 +1,293 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// subprotocol is the GraphQL over WebSocket protocol spoken on /graphql
+const subprotocol = "graphql-transport-ws"
+
+const (
+	// initTimeout closes connections that do not send connection_init in time
+	initTimeout = 10 * time.Second
+	// pingInterval is how often the server pings; a connection silent for two
+	// intervals is closed
+	pingInterval = 30 * time.Second
+	writeTimeout = 10 * time.Second
+	// maxMessageBytes limits the messages a client sends
+	maxMessageBytes = 1 << 20
+)
+
+// Close codes of the graphql-transport-ws protocol
+const (
+	closeBadRequest   = 4400
+	closeUnauthorized = 4401
+	closeForbidden    = 4403
+	closeInitTimeout  = 4408
+	closeDuplicateID  = 4409
+	closeTooManyInits = 4429
+	closeGoingAway    = websocket.CloseGoingAway
+)
+
+// message is a graphql-transport-ws message
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsConn is one graphql-transport-ws connection
+type wsConn struct {
+	server *Server
+	conn   *websocket.Conn
+
+	// writeMu serialises writes, which gorilla/websocket does not allow concurrently
+	writeMu sync.Mutex
+
+	mu  sync.Mutex
+	ops map[string]context.CancelFunc
+}
+
+// serveWebSocket upgrades the request and serves the connection until it
+// closes. The operations it runs share the context of the upgrade request.
+func (s *Server) serveWebSocket(c *gin.Context) {
+	upgrader := websocket.Upgrader{Subprotocols: []string{subprotocol}, CheckOrigin: s.checkOrigin}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already answered with an HTTP error
+		return
+	}
+	ws := &wsConn{server: s, conn: conn, ops: make(map[string]context.CancelFunc)}
+	if conn.Subprotocol() != subprotocol {
+		ws.close(websocket.CloseProtocolError, "Subprotocol not acceptable")
+		return
+	}
+	ws.run(withRequestID(c.Request.Context(), middleware.GetRequestID(c)))
+}
+
+// checkOrigin allows WebSocket connections from the origins CORS allows, from
+// the API's own origin and from clients that are not browsers
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if allowed := s.allowedOrigins(); slices.Contains(allowed, "*") || slices.Contains(allowed, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// run reads messages until the connection closes, then ends its operations
+func (w *wsConn) run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer w.conn.Close()
+
+	initTimer := time.AfterFunc(initTimeout, func() {
+		w.close(closeInitTimeout, "Connection initialisation timeout")
+	})
+	defer initTimer.Stop()
+	go w.keepAlive(ctx)
+
+	w.conn.SetReadLimit(maxMessageBytes)
+	acknowledged := false
+	for {
+		w.conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+		_, data, err := w.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			w.close(closeBadRequest, "Invalid message received")
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if acknowledged {
+				w.close(closeTooManyInits, "Too many initialisation requests")
+				return
+			}
+			initTimer.Stop()
+			if ctx, err = w.authenticate(ctx, msg.Payload); err != nil {
+				w.close(closeForbidden, "Forbidden")
+				return
+			}
+			acknowledged = true
+			w.send(message{Type: "connection_ack"})
+		case "ping":
+			w.send(message{Type: "pong", Payload: msg.Payload})
+		case "pong":
+		case "subscribe":
+			if !acknowledged {
+				w.close(closeUnauthorized, "Unauthorized")
+				return
+			}
+			var req Request
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil {
+				w.close(closeBadRequest, "Invalid message received")
+				return
+			}
+			if !w.start(ctx, msg.ID, &req) {
+				w.close(closeDuplicateID, "Subscriber for "+msg.ID+" already exists")
+				return
+			}
+		case "complete":
+			w.mu.Lock()
+			if stop, ok := w.ops[msg.ID]; ok {
+				delete(w.ops, msg.ID)
+				stop()
+			}
+			w.mu.Unlock()
+		default:
+			w.close(closeBadRequest, "Invalid message received")
+			return
+		}
+	}
+}
+
+// authenticate returns ctx carrying the caller of the connection. The
+// identity verified on the upgrade request is kept; otherwise the payload of
+// connection_init may carry the same credentials as the HTTP headers, as
+// {"Authorization": "Bearer <token>"} or {"X-API-Key": "<token>"}, with keys in
+// any case. Invalid credentials, or none when they are required, are refused.
+func (w *wsConn) authenticate(ctx context.Context, payload json.RawMessage) (context.Context, error) {
+	if id := auth.FromContext(ctx); !id.Anonymous() || w.server.verifier == nil {
+		return ctx, nil
+	}
+
+	var params map[string]any
+	if len(payload) > 0 && string(payload) != "null" {
+		if err := json.Unmarshal(payload, &params); err != nil {
+			return ctx, auth.ErrInvalidCredentials
+		}
+	}
+	credential := func(name string) string {
+		for key, value := range params {
+			if s, ok := value.(string); ok && strings.EqualFold(key, name) {
+				return s
+			}
+		}
+		return ""
+	}
+
+	id, err := w.server.verifier.Verify(ctx, credential("Authorization"), credential(auth.APIKeyHeader))
+	if err == nil {
+		err = w.server.verifier.Require(id)
+	}
+	if err != nil {
+		return ctx, err
+	}
+	return auth.WithIdentity(ctx, id), nil
+}
+
+// start runs the operation of a subscribe message with the given id in the
+// background, sending its results as next messages and then completing it.
+// It returns false if an operation with that id is already running.
+func (w *wsConn) start(ctx context.Context, id string, req *Request) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.ops[id]; ok {
+		return false
+	}
+	ctx, stop := context.WithCancel(ctx)
+	w.ops[id] = stop
+
+	go func() {
+		defer stop()
+		query, _, resp := w.server.prepare(ctx, req)
+		if resp != nil {
+			// Operations that cannot run are answered with an error message, which ends them
+			if w.finish(id) {
+				w.sendPayload(id, "error", resp.Errors)
+			}
+			return
+		}
+
+		responses, err := w.server.subscribe(ctx, query, req)
+		if err != nil {
+			if w.finish(id) {
+				w.sendPayload(id, "error", []*gqlerrors.QueryError{{Message: err.Error()}})
+			}
+			return
+		}
+		for resp := range responses {
+			w.sendPayload(id, "next", resp)
+		}
+		// A client that completed the operation itself is not told it completed
+		if w.finish(id) {
+			w.send(message{ID: id, Type: "complete"})
+		}
+	}()
+	return true
+}
+
+// finish forgets the operation id and reports whether it was still running
+func (w *wsConn) finish(id string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.ops[id]
+	delete(w.ops, id)
+	return ok
+}
+
+// keepAlive pings the client until ctx ends, and closes the connection when
+// the server shuts down
+func (w *wsConn) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.server.done:
+			w.close(closeGoingAway, "Server shutting down")
+			return
+		case <-ticker.C:
+			w.send(message{Type: "ping"})
+		}
+	}
+}
+
+// sendPayload sends a message whose payload is v encoded as JSON
+func (w *wsConn) sendPayload(id, msgType string, v any) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	w.send(message{ID: id, Type: msgType, Payload: payload})
+}
+
+// send writes msg. Errors are ignored: a broken connection fails the next read,
+// which ends it.
+func (w *wsConn) send(msg message) {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	w.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	w.conn.WriteJSON(msg)
+}
+
+// close closes the connection with a close code and reason, which ends run
+func (w *wsConn) close(code int, reason string) {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	w.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeTimeout))
+	w.conn.Close()
+}
@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/problem"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain is the domain of the ErrorInfo details of every status
+const errorDomain = "todo-app"
+
+type requestIDKey struct{}
+
+// withRequestID returns ctx tagged with the ID of the call it serves
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestID returns the request ID withRequestID added to ctx
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// statusError maps err to a gRPC status. It is described as the problem the
+// REST API would answer with: the code becomes the ErrorInfo reason, invalid
+// fields a BadRequest and Retry-After a RetryInfo. Internal errors are logged
+// and their details are not exposed.
+func statusError(ctx context.Context, method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	p := problem.From(err)
+	if p.Status >= 500 {
+		logging.Errorf("gRPC %s failed (request %s): %v", method, requestID(ctx), err)
+	}
+
+	msg := p.Error()
+	var violations []*errdetails.BadRequest_FieldViolation
+	var messages []string
+	for _, fe := range p.Errors {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message, Reason: fe.Code})
+		messages = append(messages, fe.Message)
+	}
+	if len(messages) > 0 {
+		msg += ": " + strings.Join(messages, "; ")
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   p.Code,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"request_id": requestID(ctx)},
+	}}
+	if len(violations) > 0 {
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	if p.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(p.RetryAfter)})
+	}
+
+	st := status.New(codeOf(p.Status), msg)
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// codeOf returns the gRPC code matching an HTTP status
+func codeOf(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
@@ -0,0 +1,222 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/logging"
+	"todo-app/internal/middleware"
+	"todo-app/internal/problem"
+	"todo-app/internal/ratelimit"
+
+	"github.com/google/uuid"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// step is one step of the pipeline every call passes through, unary or
+// streaming: it runs the rest of the pipeline by calling next
+type step func(ctx context.Context, method string, next func(context.Context) error) error
+
+// unary runs s for unary calls
+func unary(s step) grpcgo.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpcgo.UnaryServerInfo, handler grpcgo.UnaryHandler) (any, error) {
+		var resp any
+		err := s(ctx, info.FullMethod, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+// stream runs s for streaming calls
+func stream(s step) grpcgo.StreamServerInterceptor {
+	return func(srv any, ss grpcgo.ServerStream, info *grpcgo.StreamServerInfo, handler grpcgo.StreamHandler) error {
+		return s(ss.Context(), info.FullMethod, func(ctx context.Context) error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})
+	}
+}
+
+// serverStream is a stream whose context a step replaced
+type serverStream struct {
+	grpcgo.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// requestIDs tags every call with an ID, echoed in the x-request-id response
+// header and in error details. An ID the client sent is kept; otherwise a new
+// one is generated.
+func requestIDs(ctx context.Context, method string, next func(context.Context) error) error {
+	var id string
+	if ids := metadata.ValueFromIncomingContext(ctx, middleware.RequestIDHeader); len(ids) > 0 {
+		id = ids[0]
+	}
+	if !middleware.ValidRequestID(id) {
+		id = uuid.NewString()
+	}
+	grpcgo.SetHeader(ctx, metadata.Pairs(middleware.RequestIDHeader, id))
+	return next(withRequestID(ctx, id))
+}
+
+// accessLog logs every call with its status code, at warn level for client
+// errors and error level for server errors
+func accessLog(ctx context.Context, method string, next func(context.Context) error) error {
+	start := time.Now()
+	err := next(ctx)
+
+	code := status.Code(err)
+	log := logging.Infof
+	switch code {
+	case codes.OK:
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded:
+		log = logging.Errorf
+	default:
+		log = logging.Warnf
+	}
+	log("gRPC | %s | %v | %s | %s (request %s)", code, time.Since(start), clientIP(ctx), method, requestID(ctx))
+	return err
+}
+
+// statusErrors maps the errors of the calls to gRPC statuses
+func statusErrors(ctx context.Context, method string, next func(context.Context) error) error {
+	if err := next(ctx); err != nil {
+		return statusError(ctx, method, err)
+	}
+	return nil
+}
+
+// recovery turns a panicking call into an internal error
+func recovery(ctx context.Context, method string, next func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return next(ctx)
+}
+
+// authenticate verifies the JWT in the authorization metadata or the API token
+// in x-api-key with the verifier of the HTTP API, and puts the caller in the
+// context. Invalid credentials, and missing ones when they are required, get
+// UNAUTHENTICATED; health checks and reflection need none, so probes and
+// tools keep working when AUTH_REQUIRED is set.
+func authenticate(verifier *auth.Verifier) step {
+	return func(ctx context.Context, method string, next func(context.Context) error) error {
+		if verifier == nil {
+			return next(ctx)
+		}
+		id, err := verifier.Verify(ctx, incoming(ctx, "authorization"), incoming(ctx, auth.APIKeyHeader))
+		if err == nil && !strings.HasPrefix(method, "/grpc.health.") && !strings.HasPrefix(method, "/grpc.reflection.") {
+			err = verifier.Require(id)
+		}
+		if err != nil && auth.Rejected(err) {
+			return problem.New("unauthorized", err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		return next(auth.WithIdentity(ctx, id))
+	}
+}
+
+// incoming returns the first value of the metadata key sent by the client
+func incoming(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// rateLimit limits each client like the api group of the HTTP API, counting
+// calls in the same buckets so REST and gRPC share one quota. Clients are
+// counted per user, else per API token, else per IP. The limit is
+// sent in ratelimit-* response headers; a client over it gets
+// RESOURCE_EXHAUSTED with a RetryInfo. If the store fails the call is let
+// through.
+func rateLimit(store ratelimit.Store, limit func(group string) (ratelimit.Limit, bool)) step {
+	return limitBy(store, limit, "api", func(ctx context.Context) string {
+		if key := auth.FromContext(ctx).Key(); key != "" {
+			return key
+		}
+		return "ip:" + clientIP(ctx)
+	})
+}
+
+// limitAttempts limits the calls carrying credentials per IP before they are
+// verified, like the auth group of the HTTP API, so that guessing tokens is
+// throttled
+func limitAttempts(store ratelimit.Store, limit func(group string) (ratelimit.Limit, bool)) step {
+	return limitBy(store, limit, "auth", func(ctx context.Context) string {
+		if incoming(ctx, "authorization") == "" && incoming(ctx, auth.APIKeyHeader) == "" {
+			return ""
+		}
+		return "ip:" + clientIP(ctx)
+	})
+}
+
+// limitBy counts calls under group in the bucket key returns; calls it
+// returns "" for are not limited
+func limitBy(store ratelimit.Store, limit func(group string) (ratelimit.Limit, bool), group string, key func(context.Context) string) step {
+	return func(ctx context.Context, method string, next func(context.Context) error) error {
+		if store == nil {
+			return next(ctx)
+		}
+		limit, ok := limit(group)
+		if !ok {
+			return next(ctx)
+		}
+		client := key(ctx)
+		if client == "" {
+			return next(ctx)
+		}
+
+		result, err := store.Take(ctx, group+":"+client, limit)
+		if err != nil {
+			logging.Warnf("Rate limiter unavailable, allowing call: %v", err)
+			return next(ctx)
+		}
+
+		grpcgo.SetHeader(ctx, metadata.Pairs(
+			"ratelimit-limit", strconv.Itoa(limit.Requests),
+			"ratelimit-remaining", strconv.Itoa(result.Remaining),
+			"ratelimit-reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))),
+			"ratelimit-policy", fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Window.Seconds())),
+		))
+		if !result.Allowed {
+			p := problem.New("rate_limited", "Too many requests, please retry later")
+			p.RetryAfter = result.RetryAfter
+			return p
+		}
+		return next(ctx)
+	}
+}
+
+// clientIP returns the IP address of the caller
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,95 @@
+// Package grpc serves the todo.TodoService of proto/todo.proto on top of
+// domain.TodoService. Calls pass through the same steps as REST requests:
+// request IDs, access logs, tracing, authentication, the auth and api rate
+// limit groups and errors
+// mapped from typed domain errors, here to gRPC status codes.
+package grpc
+
+import (
+	"context"
+	"net"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/domain"
+	"todo-app/internal/events"
+	"todo-app/internal/ratelimit"
+	todopb "todo-app/proto"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Options configures a Server
+type Options struct {
+	// MaxRecvBytes limits the size of a request message
+	MaxRecvBytes int
+	// Reflection registers the reflection service so tools such as grpcurl
+	// can list the services
+	Reflection bool
+	// Limiter keeps the rate limit buckets, shared with the HTTP API; nil
+	// disables rate limiting
+	Limiter ratelimit.Store
+	// Limit is asked for the current limit of a group on every call; when it
+	// reports none the call is not limited. Calls count against the api group,
+	// and those carrying credentials first against the auth group per IP.
+	Limit func(group string) (ratelimit.Limit, bool)
+	// Verifier authenticates callers like the HTTP API; nil lets every call
+	// through anonymously
+	Verifier *auth.Verifier
+}
+
+// Server is the gRPC server of the todo API
+type Server struct {
+	server *grpcgo.Server
+	health *health.Server
+	// done is closed on shutdown to end the open WatchTodos streams
+	done chan struct{}
+}
+
+// NewServer returns a server serving todos, with changes to watch read from broker
+func NewServer(todos domain.TodoService, broker *events.Broker, opts Options) *Server {
+	s := &Server{health: health.NewServer(), done: make(chan struct{})}
+	authn := authenticate(opts.Verifier)
+	attempts := limitAttempts(opts.Limiter, opts.Limit)
+	limit := rateLimit(opts.Limiter, opts.Limit)
+	s.server = grpcgo.NewServer(
+		grpcgo.StatsHandler(otelgrpc.NewServerHandler()),
+		grpcgo.MaxRecvMsgSize(opts.MaxRecvBytes),
+		grpcgo.ChainUnaryInterceptor(unary(requestIDs), unary(accessLog), unary(statusErrors), unary(recovery), unary(attempts), unary(authn), unary(limit)),
+		grpcgo.ChainStreamInterceptor(stream(requestIDs), stream(accessLog), stream(statusErrors), stream(recovery), stream(attempts), stream(authn), stream(limit)),
+	)
+
+	todopb.RegisterTodoServiceServer(s.server, &todoServer{todos: todos, broker: broker, done: s.done})
+	healthpb.RegisterHealthServer(s.server, s.health)
+	s.health.SetServingStatus(todopb.TodoService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	if opts.Reflection {
+		reflection.Register(s.server)
+	}
+	return s
+}
+
+// Serve accepts connections on lis until Shutdown
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown reports not serving, ends the WatchTodos streams and waits for
+// in-flight calls to finish. Calls still running when ctx ends are cancelled.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+	close(s.done)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
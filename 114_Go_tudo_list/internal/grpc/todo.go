@@ -0,0 +1,324 @@
+package grpc
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/events"
+	"todo-app/internal/projection"
+	todopb "todo-app/proto"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var (
+	// errInvalidUpdateMask is returned when an update mask names a field that cannot be written
+	errInvalidUpdateMask = &domain.Error{Kind: domain.KindInvalid, Code: "invalid_update_mask", Field: "update_mask", Message: "update_mask paths must be among: title, description, priority, completed, due_date"}
+
+	// errInvalidReadMask is returned when a read mask names a field a todo does not have
+	errInvalidReadMask = &domain.Error{Kind: domain.KindInvalid, Code: "invalid_fieldset", Field: "read_mask", Message: "read_mask paths must be among: id, title, description, completed, priority, due_date, created_at, updated_at"}
+)
+
+// todoServer implements todo.TodoService
+type todoServer struct {
+	todopb.UnimplementedTodoServiceServer
+	todos  domain.TodoService
+	broker *events.Broker
+	// done is closed when the server shuts down
+	done <-chan struct{}
+}
+
+func (s *todoServer) CreateTodo(ctx context.Context, in *todopb.TodoItem) (*todopb.TodoItem, error) {
+	dueDate, err := todopb.TimeOf(in.GetDueDate())
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.CreateTodo(ctx, in.GetTitle(), in.GetDescription(), in.GetPriority().Value(), dueDate)
+	if err != nil {
+		return nil, err
+	}
+	return todopb.NewTodoItem(todo), nil
+}
+
+func (s *todoServer) GetTodos(ctx context.Context, _ *todopb.Empty) (*todopb.TodoList, error) {
+	todos, err := s.todos.GetAllTodos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return todopb.NewTodoList(todos), nil
+}
+
+func (s *todoServer) GetTodo(ctx context.Context, in *todopb.TodoId) (*todopb.TodoItem, error) {
+	id, err := parseID(in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.GetTodo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return todopb.NewTodoItem(todo), nil
+}
+
+func (s *todoServer) UpdateTodo(ctx context.Context, in *todopb.TodoItem) (*todopb.TodoItem, error) {
+	id, err := parseID(in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	dueDate, err := todopb.TimeOf(in.GetDueDate())
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.UpdateTodo(ctx, id, in.GetTitle(), in.GetDescription(), in.GetPriority().Value(), in.GetCompleted(), dueDate)
+	if err != nil {
+		return nil, err
+	}
+	return todopb.NewTodoItem(todo), nil
+}
+
+func (s *todoServer) DeleteTodo(ctx context.Context, in *todopb.TodoId) (*todopb.Empty, error) {
+	id, err := parseID(in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.todos.DeleteTodo(ctx, id); err != nil {
+		return nil, err
+	}
+	return &todopb.Empty{}, nil
+}
+
+func (s *todoServer) PatchTodo(ctx context.Context, in *todopb.PatchTodoRequest) (*todopb.TodoItem, error) {
+	item := in.GetTodo()
+	id, err := parseID(item.GetId())
+	if err != nil {
+		return nil, err
+	}
+	patch, err := patchOf(item, in.GetUpdateMask())
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.PatchTodo(ctx, id, patch)
+	if err != nil {
+		return nil, err
+	}
+	return todopb.NewTodoItem(todo), nil
+}
+
+func (s *todoServer) ToggleTodo(ctx context.Context, in *todopb.TodoId) (*todopb.TodoItem, error) {
+	id, err := parseID(in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.ToggleComplete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return todopb.NewTodoItem(todo), nil
+}
+
+func (s *todoServer) ListTodos(ctx context.Context, in *todopb.ListTodosRequest) (*todopb.TodoList, error) {
+	// Read only the fields of the read mask, as a sparse fieldset would
+	if paths := in.GetReadMask().GetPaths(); len(paths) > 0 {
+		for _, path := range paths {
+			if !slices.Contains(domain.TodoFields, path) {
+				return nil, errInvalidReadMask
+			}
+		}
+		ctx = projection.WithFields(ctx, paths)
+	}
+
+	if len(in.GetIds()) > 0 {
+		return s.listByID(ctx, in.GetIds())
+	}
+
+	var todos []*domain.Todo
+	var err error
+	switch {
+	case in.GetSearch() != "":
+		todos, err = s.todos.SearchTodos(ctx, in.GetSearch())
+	case in.GetStatus() != todopb.TodoStatus_TODO_STATUS_UNSPECIFIED:
+		todos, err = s.todos.GetTodosByStatus(ctx, in.GetStatus() == todopb.TodoStatus_TODO_STATUS_COMPLETED)
+	default:
+		todos, err = s.todos.GetAllTodos(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return todopb.NewTodoList(todos), nil
+}
+
+// listByID reads the todos with the given IDs in one batch and returns them
+// in the order given, leaving out unknown IDs
+func (s *todoServer) listByID(ctx context.Context, ids []string) (*todopb.TodoList, error) {
+	keys := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		var err error
+		if keys[i], err = parseID(id); err != nil {
+			return nil, err
+		}
+	}
+
+	found, err := s.todos.GetTodosByIDs(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*domain.Todo, len(found))
+	for _, todo := range found {
+		byID[todo.ID] = todo
+	}
+	list := &todopb.TodoList{}
+	for _, id := range keys {
+		if todo, ok := byID[id]; ok {
+			list.Items = append(list.Items, todopb.NewTodoItem(todo))
+		}
+	}
+	return list, nil
+}
+
+func (s *todoServer) GetStats(ctx context.Context, _ *todopb.Empty) (*todopb.TodoStats, error) {
+	todos, err := s.todos.GetAllTodos(projection.WithFields(ctx, []string{"completed", "priority", "due_date"}))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &todopb.TodoStats{}
+	byPriority := map[todopb.Priority]int32{}
+	now := time.Now()
+	for _, todo := range todos {
+		stats.Total++
+		if todo.Completed {
+			stats.Completed++
+		} else if todo.DueDate != nil && todo.DueDate.Before(now) {
+			stats.Overdue++
+		}
+		byPriority[todopb.PriorityOf(todo.Priority)]++
+	}
+	stats.Pending = stats.Total - stats.Completed
+	for _, priority := range []todopb.Priority{todopb.Priority_PRIORITY_LOW, todopb.Priority_PRIORITY_MEDIUM, todopb.Priority_PRIORITY_HIGH} {
+		stats.ByPriority = append(stats.ByPriority, &todopb.PriorityCount{Priority: priority, Count: byPriority[priority]})
+	}
+	return stats, nil
+}
+
+func (s *todoServer) WatchTodos(in *todopb.WatchTodosRequest, stream todopb.TodoService_WatchTodosServer) error {
+	var only uuid.UUID
+	if in.GetId() != "" {
+		var err error
+		if only, err = parseID(in.GetId()); err != nil {
+			return err
+		}
+	}
+
+	// Subscribe before sending the headers, so the client sees every change
+	// made once it knows the stream is open
+	changes := s.broker.Subscribe(stream.Context())
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return nil
+		case event, ok := <-changes:
+			if !ok {
+				if err := stream.Context().Err(); err != nil {
+					return err
+				}
+				return status.Error(codes.Aborted, "the stream fell too far behind the changes, watch again")
+			}
+			if only != uuid.Nil && event.ID != only {
+				continue
+			}
+			if err := stream.Send(todoEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// patchOf returns the patch writing the fields of item named in mask, or
+// every field of item that is set when there is no mask. Fields in the mask
+// that are not set in item are reset to their defaults.
+func patchOf(item *todopb.TodoItem, mask *fieldmaskpb.FieldMask) (*domain.TodoPatch, error) {
+	paths := mask.GetPaths()
+	if len(paths) == 0 {
+		if item.GetTitle() != "" {
+			paths = append(paths, "title")
+		}
+		if item.GetDescription() != "" {
+			paths = append(paths, "description")
+		}
+		if item.GetPriority() != todopb.Priority_PRIORITY_UNSPECIFIED {
+			paths = append(paths, "priority")
+		}
+		if item.GetCompleted() {
+			paths = append(paths, "completed")
+		}
+		if item.GetDueDate() != nil {
+			paths = append(paths, "due_date")
+		}
+	}
+
+	patch := &domain.TodoPatch{}
+	for _, path := range paths {
+		switch path {
+		case "title":
+			patch.Title = domain.Value(item.GetTitle())
+		case "description":
+			patch.Description = field(item.GetDescription(), "")
+		case "priority":
+			patch.Priority = field(item.GetPriority().Value(), "")
+		case "completed":
+			patch.Completed = field(item.GetCompleted(), false)
+		case "due_date":
+			dueDate, err := todopb.TimeOf(item.GetDueDate())
+			if err != nil {
+				return nil, err
+			}
+			if dueDate == nil {
+				patch.DueDate = domain.Null[time.Time]()
+			} else {
+				patch.DueDate = domain.Value(*dueDate)
+			}
+		default:
+			return nil, errInvalidUpdateMask
+		}
+	}
+	return patch, nil
+}
+
+// field returns a patch field setting v, or clearing the value when v is unset
+func field[T comparable](v, unset T) domain.Field[T] {
+	if v == unset {
+		return domain.Null[T]()
+	}
+	return domain.Value(v)
+}
+
+// todoEvent converts a change event to its message
+func todoEvent(event domain.TodoEvent) *todopb.TodoEvent {
+	msg := &todopb.TodoEvent{
+		Type: todopb.TodoEventType(todopb.TodoEventType_value["TODO_EVENT_TYPE_"+strings.ToUpper(string(event.Type))]),
+		Id:   event.ID.String(),
+	}
+	if event.Todo != nil {
+		msg.Todo = todopb.NewTodoItem(event.Todo)
+	}
+	return msg
+}
+
+// parseID parses the ID of a todo
+func parseID(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, domain.ErrInvalidID
+	}
+	return parsed, nil
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"todo-app/internal/config"
+	"todo-app/internal/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves operational endpoints about the running instance
+type AdminHandler struct {
+	live *config.Live
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(live *config.Live) *AdminHandler {
+	return &AdminHandler{live: live}
+}
+
+// Vars handles GET /debug/vars. It serves telemetry.Metrics in the JSON form
+// of expvar, without the cmdline and memstats variables of expvar.Handler.
+func (h *AdminHandler) Vars(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(telemetry.Metrics.String()))
+}
+
+// configValue is one setting in the /admin/config response
+type configValue struct {
+	Path       string `json:"path"`
+	Value      string `json:"value"`
+	Source     string `json:"source"`
+	Reloadable bool   `json:"reloadable"`
+}
+
+// Config handles GET /admin/config. It reports the version of the running
+// configuration, bumped by every reload that changes a setting, and the
+// effective value of each setting with secrets redacted.
+func (h *AdminHandler) Config(c *gin.Context) {
+	snapshot := h.live.Snapshot()
+
+	settings := make(map[string]configValue)
+	for _, v := range snapshot.Config.Values() {
+		settings[v.Env] = configValue{
+			Path:       v.Path,
+			Value:      v.Redacted(),
+			Source:     v.Source,
+			Reloadable: v.Reload,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version":   snapshot.Version,
+		"checksum":  snapshot.Checksum,
+		"loaded_at": snapshot.LoadedAt.UTC().Format(time.RFC3339),
+		"profile":   snapshot.Config.Env,
+		"file":      h.live.File(),
+		"settings":  settings,
+	})
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/problem"
+	todopb "todo-app/proto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+// Media types the todo endpoints read and write. Protobuf bodies are the
+// messages of proto/todo.proto; CSV is only offered for lists.
+const (
+	JSONType     = "application/json"
+	MsgPackType  = "application/msgpack"
+	ProtobufType = "application/x-protobuf"
+	CSVType      = "text/csv"
+)
+
+var (
+	// todoFormats are the media types a todo is returned in, the default first
+	todoFormats = []string{JSONType, MsgPackType, ProtobufType}
+	// listFormats are the media types a list of todos is returned in
+	listFormats = []string{JSONType, MsgPackType, ProtobufType, CSVType}
+)
+
+// msgpackHandle writes times as MessagePack timestamp extensions, which
+// other MessagePack libraries decode as times
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// negotiate returns the offer the Accept header of the request prefers,
+// weighing its q-values; a missing Accept takes the first offer. When no
+// offer is acceptable it records a not_acceptable problem and returns false.
+func negotiate(c *gin.Context, offers ...string) (string, bool) {
+	accept := c.GetHeader("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		// The most specific range matching the offer sets its quality
+		q, specificity := 0.0, -1
+		for _, part := range strings.Split(accept, ",") {
+			media, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			s := matchSpecificity(media, offer)
+			if s <= specificity {
+				continue
+			}
+			specificity, q = s, 1
+			if value, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(value, 64); err != nil {
+					q = 0
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	if best == "" {
+		c.Error(problem.New("not_acceptable", "Accept must allow one of: "+strings.Join(offers, ", ")))
+		return "", false
+	}
+	return best, true
+}
+
+// matchSpecificity returns how closely the media range matches offer: 2 for
+// the same type, 1 for type/*, 0 for */* and -1 when it does not match
+func matchSpecificity(media, offer string) int {
+	switch {
+	case media == offer:
+		return 2
+	case media == "*/*":
+		return 0
+	case strings.HasSuffix(media, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(media, "*")):
+		return 1
+	}
+	return -1
+}
+
+// todoRequest is a request body bindTodo decodes
+type todoRequest interface {
+	domain.CreateTodoRequest | domain.UpdateTodoRequest
+}
+
+// bindTodo decodes the body of a create or replace into req by its
+// Content-Type: JSON, MessagePack or a todo.TodoItem. A body without a
+// Content-Type is read as JSON, as before formats were negotiated. The result
+// is validated by the binding tags of req whatever the format; other media
+// types are answered with unsupported_media_type.
+func bindTodo[T todoRequest](c *gin.Context, req *T) error {
+	switch c.ContentType() {
+	case JSONType, "":
+		return c.ShouldBindJSON(req)
+	case MsgPackType:
+		body, err := c.GetRawData()
+		if err != nil {
+			return err
+		}
+		if err := codec.NewDecoderBytes(body, msgpackHandle).Decode(req); err != nil {
+			return fmt.Errorf("request body is not valid MessagePack: %w", err)
+		}
+	case ProtobufType:
+		body, err := c.GetRawData()
+		if err != nil {
+			return err
+		}
+		var item todopb.TodoItem
+		if err := proto.Unmarshal(body, &item); err != nil {
+			return fmt.Errorf("request body is not a valid todo.TodoItem: %w", err)
+		}
+		dueDate, err := todopb.TimeOf(item.GetDueDate())
+		if err != nil {
+			return err
+		}
+		switch req := any(req).(type) {
+		case *domain.CreateTodoRequest:
+			*req = domain.CreateTodoRequest{Title: item.GetTitle(), Description: item.GetDescription(), Priority: item.GetPriority().Value(), DueDate: dueDate}
+		case *domain.UpdateTodoRequest:
+			*req = domain.UpdateTodoRequest{Title: item.GetTitle(), Description: item.GetDescription(), Priority: item.GetPriority().Value(), Completed: item.GetCompleted(), DueDate: dueDate}
+		}
+	default:
+		return problem.New("unsupported_media_type", "Content-Type must be one of: "+strings.Join(todoFormats, ", "))
+	}
+	return binding.Validator.ValidateStruct(req)
+}
+
+// renderTodo writes todo, trimmed to fields, in format: as the JSON or
+// MessagePack envelope with message when it is not empty, or as a
+// todo.TodoItem
+func renderTodo(c *gin.Context, format string, status int, message string, todo *domain.Todo, fields []string) {
+	if format == ProtobufType {
+		c.ProtoBuf(status, projectItem(todopb.NewTodoItem(todo), fields))
+		return
+	}
+	body := gin.H{"data": project(todo.ToResponse(), fields)}
+	if message != "" {
+		body["message"] = message
+	}
+	renderEnvelope(c, format, status, body)
+}
+
+// renderTodos writes todos, trimmed to fields, in format: as the JSON or
+// MessagePack envelope with their count, a todo.TodoList or a CSV table
+func renderTodos(c *gin.Context, format string, todos []*domain.Todo, fields []string) {
+	switch format {
+	case ProtobufType:
+		list := todopb.NewTodoList(todos)
+		for _, item := range list.Items {
+			projectItem(item, fields)
+		}
+		c.ProtoBuf(http.StatusOK, list)
+	case CSVType:
+		c.Data(http.StatusOK, CSVType+"; charset=utf-8", todoTable(todos, fields))
+	default:
+		responses := make([]any, len(todos))
+		for i, todo := range todos {
+			responses[i] = project(todo.ToResponse(), fields)
+		}
+		renderEnvelope(c, format, http.StatusOK, gin.H{"data": responses, "count": len(responses)})
+	}
+}
+
+// renderMessage writes a response without a todo: the JSON or MessagePack
+// envelope with message, or a todo.Empty
+func renderMessage(c *gin.Context, format string, status int, message string) {
+	if format == ProtobufType {
+		c.ProtoBuf(status, &todopb.Empty{})
+		return
+	}
+	renderEnvelope(c, format, status, gin.H{"message": message})
+}
+
+// renderEnvelope writes body as JSON or MessagePack
+func renderEnvelope(c *gin.Context, format string, status int, body gin.H) {
+	if format == MsgPackType {
+		c.Render(status, msgpackRender{body})
+		return
+	}
+	c.JSON(status, body)
+}
+
+// msgpackRender writes data as MessagePack with msgpackHandle; gin's own
+// renderer writes times in a form other libraries do not read as times
+type msgpackRender struct {
+	data any
+}
+
+func (r msgpackRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return codec.NewEncoder(w, msgpackHandle).Encode(r.data)
+}
+
+func (r msgpackRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", MsgPackType)
+}
+
+// projectItem clears the fields of item that fields does not name, except
+// its id, and returns it; it is left whole when fields is nil
+func projectItem(item *todopb.TodoItem, fields []string) *todopb.TodoItem {
+	if fields == nil {
+		return item
+	}
+	m := item.ProtoReflect()
+	descriptors := m.Descriptor().Fields()
+	for i := range descriptors.Len() {
+		fd := descriptors.Get(i)
+		if name := string(fd.Name()); name != "id" && !slices.Contains(fields, name) {
+			m.Clear(fd)
+		}
+	}
+	return item
+}
+
+// todoTable returns todos as a CSV table with a header row, one column per
+// member named by fields in the order of domain.TodoFields, id always first
+func todoTable(todos []*domain.Todo, fields []string) []byte {
+	var columns []string
+	for _, field := range domain.TodoFields {
+		if fields == nil || field == "id" || slices.Contains(fields, field) {
+			columns = append(columns, field)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(columns)
+	for _, todo := range todos {
+		members := todoMembers(todo.ToResponse())
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = csvCell(members[column])
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// csvCell formats a todo member for a CSV cell. Times are RFC 3339 and a
+// missing due date is empty. Text starting with a character spreadsheets
+// read as a formula is prefixed with a quote so it is shown, not evaluated.
+func csvCell(value any) string {
+	switch v := value.(type) {
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// todoMembers returns the members of todo keyed by their JSON names
+func todoMembers(todo *domain.TodoResponse) map[string]any {
+	v := reflect.ValueOf(todo).Elem()
+	members := make(map[string]any, v.NumField())
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		members[name] = v.Field(i).Interface()
+	}
+	return members
+}
@@ -0,0 +1,82 @@
+package handler_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	todopb "todo-app/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCreateTodoContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        int
+	}{
+		{"JSON", "application/json", http.StatusCreated},
+		{"JSON with charset", "application/json; charset=utf-8", http.StatusCreated},
+		{"no Content-Type is JSON", "", http.StatusCreated},
+		{"unsupported", "text/plain", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t, nil)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/todos", strings.NewReader(`{"title":"Buy milk"}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status: got %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestCreateAndReplaceTodoFromProtobuf(t *testing.T) {
+	router := newTestRouter(t, nil)
+	send := func(method, path string, item *todopb.TodoItem) *httptest.ResponseRecorder {
+		t.Helper()
+		body, err := proto.Marshal(item)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("Accept", "application/x-protobuf")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(http.MethodPost, "/api/v1/todos", &todopb.TodoItem{Title: "Buy milk", Priority: todopb.Priority_PRIORITY_HIGH})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: got %d; body %s", rec.Code, rec.Body)
+	}
+	var created todopb.TodoItem
+	if err := proto.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if created.GetTitle() != "Buy milk" || created.GetPriority() != todopb.Priority_PRIORITY_HIGH {
+		t.Fatalf("created: got %v", &created)
+	}
+
+	rec = send(http.MethodPut, "/api/v1/todos/"+created.GetId(), &todopb.TodoItem{Title: "Buy oat milk", Completed: true})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("replace: got %d; body %s", rec.Code, rec.Body)
+	}
+	var replaced todopb.TodoItem
+	if err := proto.Unmarshal(rec.Body.Bytes(), &replaced); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if replaced.GetTitle() != "Buy oat milk" || !replaced.GetCompleted() {
+		t.Fatalf("replaced: got %v", &replaced)
+	}
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"todo-app/internal/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthCheck is a named readiness probe. Check may return details that are
+// included in the /readyz response whether it passes or not.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) (details any, err error)
+}
+
+// CheckResult is the outcome of a single health check
+type CheckResult struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Details    any    `json:"details,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	checks       []HealthCheck
+	timeout      time.Duration
+	startedAt    time.Time
+	shuttingDown atomic.Bool
+}
+
+// NewHealthHandler creates a new HealthHandler running checks with the given per-check timeout
+func NewHealthHandler(timeout time.Duration, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		checks:    checks,
+		timeout:   timeout,
+		startedAt: time.Now(),
+	}
+}
+
+// SetShuttingDown makes /readyz report not-ready so load balancers stop routing new traffic
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Livez handles GET /livez. It only reports that the process is able to serve
+// requests and never touches external dependencies.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"checks": map[string]CheckResult{
+			"process": {
+				Status:  "ok",
+				Details: gin.H{"uptime": time.Since(h.startedAt).Round(time.Second).String()},
+			},
+		},
+	})
+}
+
+// Readyz handles GET /readyz. It runs every dependency check concurrently and
+// answers 503 if any of them fails or the server is shutting down.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	results := make(map[string]CheckResult, len(h.checks)+1)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			result := h.run(c.Request.Context(), check)
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, result := range results {
+		if result.Status != "ok" {
+			ready = false
+		}
+	}
+
+	if h.shuttingDown.Load() {
+		ready = false
+		results["shutdown"] = CheckResult{Status: "fail", Error: "server is shutting down"}
+	}
+
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "fail", http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": results,
+	})
+}
+
+// run executes a single check under the configured timeout
+func (h *HealthHandler) run(ctx context.Context, check HealthCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check.Check(ctx)
+	result := CheckResult{
+		Status:     "ok",
+		DurationMs: time.Since(start).Milliseconds(),
+		Details:    details,
+	}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// DatabaseCheck pings the database
+func DatabaseCheck(ping func(ctx context.Context) error) HealthCheck {
+	return HealthCheck{
+		Name: "database",
+		Check: func(ctx context.Context) (any, error) {
+			return nil, ping(ctx)
+		},
+	}
+}
+
+// SchemaCheck verifies the applied migration version matches what the binary expects
+func SchemaCheck(current func(ctx context.Context) (int, error), expected int) HealthCheck {
+	return HealthCheck{
+		Name: "migrations",
+		Check: func(ctx context.Context) (any, error) {
+			version, err := current(ctx)
+			if err != nil {
+				return nil, err
+			}
+			details := gin.H{"current": version, "expected": expected}
+			if version != expected {
+				return details, fmt.Errorf("schema version %d does not match expected %d", version, expected)
+			}
+			return details, nil
+		},
+	}
+}
+
+// WorkersCheck reports background worker status and fails while any worker is
+// down after an error, waiting to be restarted
+func WorkersCheck(workers *worker.Group) HealthCheck {
+	return HealthCheck{
+		Name: "workers",
+		Check: func(ctx context.Context) (any, error) {
+			status := workers.Status()
+			for name, s := range status {
+				if s.Error != "" {
+					return status, fmt.Errorf("worker %s failed: %s", name, s.Error)
+				}
+			}
+			return status, nil
+		},
+	}
+}
@@ -0,0 +1,468 @@
+package handler
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/domain"
+	"todo-app/internal/openapi"
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// docsPage is the Swagger UI page, loading its assets from the same directory
+//
+//go:embed docs.html
+var docsPage []byte
+
+// OpenAPIHandler serves the API description and its interactive documentation
+type OpenAPIHandler struct {
+	spec   []byte
+	assets http.Handler
+}
+
+// NewOpenAPIHandler creates a new OpenAPIHandler serving doc
+func NewOpenAPIHandler(doc *openapi.Document) *OpenAPIHandler {
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		// The document is built from static types, so this is a programming error
+		panic(fmt.Sprintf("failed to encode OpenAPI document: %v", err))
+	}
+	return &OpenAPIHandler{
+		spec:   spec,
+		assets: http.StripPrefix("/docs", http.FileServer(http.FS(swaggerFiles.FS))),
+	}
+}
+
+// Spec handles GET /openapi.json
+func (h *OpenAPIHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// Docs handles GET /docs/*file, serving Swagger UI pointed at /openapi.json
+func (h *OpenAPIHandler) Docs(c *gin.Context) {
+	switch c.Param("file") {
+	case "/", "/index.html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	default:
+		h.assets.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// NewDocument describes every route SetupRoutes registers, except the static
+// web files and the documentation pages. Request and response schemas are
+// generated from the types the handlers bind and render, so the limits in
+// their binding tags are documented as they are enforced.
+func NewDocument() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:   "Todo API",
+		Version: "1.0.0",
+		Description: "Todo list REST API. Errors are RFC 7807 application/problem+json documents; " +
+			"every response carries an X-Request-ID header to quote when reporting a problem. " +
+			"The todo endpoints read and write JSON, MessagePack (the same documents, times as timestamp extensions) " +
+			"and Protobuf (the messages of proto/todo.proto), chosen by Content-Type and Accept; lists are also offered as CSV.",
+	})
+	doc.Servers = []openapi.Server{{URL: "/"}}
+	doc.Tags = []openapi.Tag{
+		{Name: "todos", Description: "Create, list, update and delete todos"},
+		{Name: "graphql", Description: "The todos as a GraphQL API, with subscriptions to their changes"},
+		{Name: "health", Description: "Liveness and readiness probes"},
+		{Name: "meta", Description: "Problem types and this document"},
+	}
+
+	// Error responses shared by the operations
+	problemBody := map[string]openapi.MediaType{problem.ContentType: {Schema: doc.Response(problem.Problem{})}}
+	retryAfter := &openapi.Header{Description: "Seconds to wait before retrying", Schema: openapi.Integer()}
+	doc.Components.Responses["ValidationFailed"] = &openapi.Response{
+		Description: "validation_failed or malformed_body: the request has invalid fields or its body cannot be decoded",
+		Content:     problemBody,
+	}
+	doc.Components.Responses["TodoNotFound"] = &openapi.Response{
+		Description: "todo_not_found: no todo has the given ID",
+		Content:     problemBody,
+	}
+	doc.Components.Responses["PatchConflict"] = &openapi.Response{
+		Description: "patch_test_failed, patch_conflict or idempotency_key_in_use: a JSON Patch test failed, " +
+			"the patch refers to a member the todo does not have, or a request with the same Idempotency-Key is still being handled",
+		Headers: map[string]*openapi.Header{"Retry-After": retryAfter},
+		Content: problemBody,
+	}
+	doc.Components.Responses["UnsupportedMediaType"] = &openapi.Response{
+		Description: "unsupported_media_type: the body is not in a media type the operation reads",
+		Headers: map[string]*openapi.Header{"Accept-Patch": {
+			Description: "The accepted patch media types, sent by PATCH",
+			Schema:      openapi.String(),
+		}},
+		Content: problemBody,
+	}
+	doc.Components.Responses["NotAcceptable"] = &openapi.Response{
+		Description: "not_acceptable: Accept allows none of the media types the operation responds in",
+		Content:     problemBody,
+	}
+	doc.Components.Responses["BodyTooLarge"] = &openapi.Response{
+		Description: "body_too_large: the body exceeds SERVER_MAX_BODY_BYTES",
+		Content:     problemBody,
+	}
+	doc.Components.Responses["IdempotencyKeyInUse"] = &openapi.Response{
+		Description: "idempotency_key_in_use: a request with the same Idempotency-Key is still being handled",
+		Headers:     map[string]*openapi.Header{"Retry-After": retryAfter},
+		Content:     problemBody,
+	}
+	doc.Components.Responses["IdempotencyKeyReused"] = &openapi.Response{
+		Description: "idempotency_key_reused: the Idempotency-Key was already used for a different request",
+		Content:     problemBody,
+	}
+	doc.Components.Responses["Unauthorized"] = &openapi.Response{
+		Description: "unauthorized: the credentials are invalid or expired, or none were sent while AUTH_REQUIRED is set",
+		Headers: map[string]*openapi.Header{"WWW-Authenticate": {
+			Description: "The scheme to authenticate with",
+			Schema:      openapi.String(),
+		}},
+		Content: problemBody,
+	}
+	doc.Components.Responses["RateLimited"] = &openapi.Response{
+		Description: "rate_limited: the client exceeded its rate limit",
+		Headers:     map[string]*openapi.Header{"Retry-After": retryAfter},
+		Content:     problemBody,
+	}
+	doc.Components.Responses["ServiceUnavailable"] = &openapi.Response{
+		Description: "service_unavailable: storage is temporarily unavailable",
+		Headers:     map[string]*openapi.Header{"Retry-After": retryAfter},
+		Content:     problemBody,
+	}
+	doc.Components.Responses["InternalError"] = &openapi.Response{
+		Description: "internal_error: the server failed to handle the request",
+		Content:     problemBody,
+	}
+
+	// Response envelopes of the todo endpoints
+	todo := doc.Response(domain.TodoResponse{})
+	todoBody := todoContent(openapi.Object(map[string]*openapi.Schema{
+		"data":    todo,
+		"message": openapi.String(),
+	}, "message", "data"), "TodoItem")
+	// Reads return every member unless the fields parameter trims them
+	partial := *doc.Components.Schemas["TodoResponse"]
+	partial.Required = []string{"id"}
+	doc.Components.Schemas["PartialTodo"] = openapi.Describe(&partial, "A todo with the members named by the fields parameter, and always its id")
+	readBody := todoContent(openapi.Object(map[string]*openapi.Schema{"data": openapi.Ref("PartialTodo")}, "data"), "TodoItem")
+	listBody := todoContent(openapi.Object(map[string]*openapi.Schema{
+		"data":  openapi.ArrayOf(openapi.Ref("PartialTodo")),
+		"count": openapi.Integer(),
+	}, "data", "count"), "TodoList")
+	listBody[CSVType] = openapi.MediaType{Schema: openapi.Describe(openapi.String(),
+		"A header row naming the columns, then a row per todo; times are RFC 3339 and a missing due date is empty")}
+	messageBody := todoContent(openapi.Object(map[string]*openapi.Schema{"message": openapi.String()}, "message"), "Empty")
+
+	readParameters := []*openapi.Parameter{
+		{Name: "fields", In: "query", Schema: openapi.String(),
+			Description: "Comma-separated todo members to return, such as id,title,completed; only their columns are read"},
+	}
+	id := &openapi.Parameter{
+		Name:     "id",
+		In:       "path",
+		Required: true,
+		Schema:   &openapi.Schema{Type: openapi.Types{"string"}, Format: "uuid"},
+	}
+	// idempotent documents the Idempotency-Key header of an unsafe operation
+	maxKeyLength := 255
+	idempotencyKey := &openapi.Parameter{
+		Name: "Idempotency-Key",
+		In:   "header",
+		Description: "Makes the request safe to retry: a retry with the same key and request gets the saved " +
+			"response with Idempotent-Replayed: true instead of running again. Use a new random key, such as a UUID, per request.",
+		Schema: &openapi.Schema{Type: openapi.Types{"string"}, MaxLength: &maxKeyLength},
+	}
+	idempotent := func(op *openapi.Operation) *openapi.Operation {
+		op.Parameters = append(op.Parameters, idempotencyKey)
+		op.Responses["409"] = openapi.ResponseRef("IdempotencyKeyInUse")
+		op.Responses["422"] = openapi.ResponseRef("IdempotencyKeyReused")
+		return op
+	}
+	// apiResponses adds the errors every /api/v1 operation may answer with
+	apiResponses := func(responses map[string]*openapi.Response) map[string]*openapi.Response {
+		responses["429"] = openapi.ResponseRef("RateLimited")
+		responses["500"] = openapi.ResponseRef("InternalError")
+		responses["503"] = openapi.ResponseRef("ServiceUnavailable")
+		return responses
+	}
+	// negotiated adds the errors of a todo operation choosing its media types
+	negotiated := func(op *openapi.Operation) *openapi.Operation {
+		op.Responses["406"] = openapi.ResponseRef("NotAcceptable")
+		if op.RequestBody != nil {
+			op.Responses["415"] = openapi.ResponseRef("UnsupportedMediaType")
+		}
+		return op
+	}
+
+	doc.Add(http.MethodPost, "/api/v1/todos", negotiated(idempotent(&openapi.Operation{
+		Tags:        []string{"todos"},
+		Summary:     "Create a todo",
+		Description: "Priority defaults to medium.",
+		OperationID: "createTodo",
+		RequestBody: &openapi.RequestBody{Required: true, Content: todoContent(doc.Request(domain.CreateTodoRequest{}), "TodoItem")},
+		Responses: apiResponses(map[string]*openapi.Response{
+			"201": {Description: "The created todo", Content: todoBody},
+			"400": openapi.ResponseRef("ValidationFailed"),
+			"413": openapi.ResponseRef("BodyTooLarge"),
+		}),
+	})))
+	doc.Add(http.MethodGet, "/api/v1/todos", negotiated(&openapi.Operation{
+		Tags:        []string{"todos"},
+		Summary:     "List todos",
+		Description: "Lists every todo, the todos matching a search, or the todos with a status. q takes precedence over status.",
+		OperationID: "listTodos",
+		Parameters: append([]*openapi.Parameter{
+			{Name: "q", In: "query", Description: "Only todos whose title or description contain every word", Schema: openapi.String()},
+			{Name: "status", In: "query", Description: "Only completed or only pending todos", Schema: openapi.String("completed", "pending")},
+		}, readParameters...),
+		Responses: apiResponses(map[string]*openapi.Response{
+			"200": {Description: "The todos", Content: listBody},
+			"400": openapi.ResponseRef("ValidationFailed"),
+		}),
+	}))
+	doc.Add(http.MethodGet, "/api/v1/todos/:id", negotiated(&openapi.Operation{
+		Tags:        []string{"todos"},
+		Summary:     "Get a todo",
+		OperationID: "getTodo",
+		Parameters:  append([]*openapi.Parameter{id}, readParameters...),
+		Responses: apiResponses(map[string]*openapi.Response{
+			"200": {Description: "The todo", Content: readBody},
+			"400": openapi.ResponseRef("ValidationFailed"),
+			"404": openapi.ResponseRef("TodoNotFound"),
+		}),
+	}))
+	doc.Add(http.MethodPut, "/api/v1/todos/:id", negotiated(idempotent(&openapi.Operation{
+		Tags:        []string{"todos"},
+		Summary:     "Replace a todo",
+		Description: "Replaces every field; omitted fields take their defaults as on create. Use PATCH to change some fields.",
+		OperationID: "updateTodo",
+		Parameters:  []*openapi.Parameter{id},
+		RequestBody: &openapi.RequestBody{Required: true, Content: todoContent(doc.Request(domain.UpdateTodoRequest{}), "TodoItem")},
+		Responses: apiResponses(map[string]*openapi.Response{
+			"200": {Description: "The updated todo", Content: todoBody},
+			"400": openapi.ResponseRef("ValidationFailed"),
+			"404": openapi.ResponseRef("TodoNotFound"),
+			"413": openapi.ResponseRef("BodyTooLarge"),
+		}),
+	})))
+	// A merge patch has the fields of a replacement, all optional and nullable
+	mergeFields := map[string]*openapi.Schema{}
+	for name, field := range doc.Components.Schemas["UpdateTodoRequest"].Properties {
+		if !slices.Contains(field.Type, "null") {
+			field = openapi.Nullable(field)
+		}
+		mergeFields[name] = field
+	}
+	doc.Components.Schemas["TodoMergePatch"] = openapi.Describe(openapi.Object(mergeFields),
+		"RFC 7396 merge patch: members set a field, null resets it to its default, and missing members are left alone")
+	doc.Components.Schemas["JSONPatchOperation"] = openapi.Describe(openapi.Object(map[string]*openapi.Schema{
+		"op":    openapi.String("add", "remove", "replace", "move", "copy", "test"),
+		"path":  openapi.Describe(openapi.String(), "JSON Pointer to a member of the todo, such as /title"),
+		"from":  openapi.Describe(openapi.String(), "JSON Pointer read by move and copy"),
+		"value": openapi.Describe(&openapi.Schema{}, "Value of add, replace and test"),
+	}, "op", "path"), "RFC 6902 operation, applied to the todo as this API returns it")
+	patch := idempotent(&openapi.Operation{
+		Tags:    []string{"todos"},
+		Summary: "Change some fields of a todo",
+		Description: "Applies a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to the todo as GET returns it. " +
+			"A member set to null or removed resets the field to its default: no description, medium priority, not completed, no due date; " +
+			"the title cannot be cleared and id, created_at and updated_at cannot be changed. " +
+			"A JSON Patch is applied atomically, so its test operations guard against concurrent changes.",
+		OperationID: "patchTodo",
+		Parameters:  []*openapi.Parameter{id},
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+			MergePatchType: {Schema: openapi.Ref("TodoMergePatch")},
+			JSONPatchType:  {Schema: openapi.ArrayOf(openapi.Ref("JSONPatchOperation"))},
+		}},
+		Responses: apiResponses(map[string]*openapi.Response{
+			"200": {Description: "The updated todo", Content: todoBody},
+			"400": openapi.ResponseRef("ValidationFailed"),
+			"404": openapi.ResponseRef("TodoNotFound"),
+			"413": openapi.ResponseRef("BodyTooLarge"),
+			"415": openapi.ResponseRef("UnsupportedMediaType"),
+		}),
+	})
+	patch.Responses["409"] = openapi.ResponseRef("PatchConflict")
+	doc.Add(http.MethodPatch, "/api/v1/todos/:id", negotiated(patch))
+	doc.Add(http.MethodDelete, "/api/v1/todos/:id", negotiated(idempotent(&openapi.Operation{
+		Tags:        []string{"todos"},
+		Summary:     "Delete a todo",
+		OperationID: "deleteTodo",
+		Parameters:  []*openapi.Parameter{id},
+		Responses: apiResponses(map[string]*openapi.Response{
+			"200": {Description: "The todo was deleted", Content: messageBody},
+			"400": openapi.ResponseRef("ValidationFailed"),
+			"404": openapi.ResponseRef("TodoNotFound"),
+		}),
+	})))
+	doc.Add(http.MethodPatch, "/api/v1/todos/:id/toggle", negotiated(idempotent(&openapi.Operation{
+		Tags:        []string{"todos"},
+		Summary:     "Toggle completion",
+		Description: "Atomically flips the completed flag.",
+		OperationID: "toggleTodo",
+		Parameters:  []*openapi.Parameter{id},
+		Responses: apiResponses(map[string]*openapi.Response{
+			"200": {Description: "The updated todo", Content: todoBody},
+			"400": openapi.ResponseRef("ValidationFailed"),
+			"404": openapi.ResponseRef("TodoNotFound"),
+		}),
+	})))
+
+	// GraphQL endpoint. Operations are described by the GraphQL schema, which
+	// introspection returns, so only the transport is documented here.
+	doc.Components.Schemas["GraphQLResponse"] = openapi.Describe(openapi.Object(map[string]*openapi.Schema{
+		"data": openapi.Describe(&openapi.Schema{}, "The selected fields, or null when the operation could not run"),
+		"errors": openapi.ArrayOf(openapi.Object(map[string]*openapi.Schema{
+			"message": openapi.String(),
+			"locations": openapi.ArrayOf(openapi.Object(map[string]*openapi.Schema{
+				"line":   openapi.Integer(),
+				"column": openapi.Integer(),
+			}, "line", "column")),
+			"path": openapi.ArrayOf(&openapi.Schema{}),
+			"extensions": openapi.Describe(openapi.MapOf(&openapi.Schema{}),
+				"code, and for field errors the status and invalid fields of the matching REST problem"),
+		}, "message")),
+	}), "A GraphQL response; requests that reach the GraphQL layer always answer 200 with this")
+	persistedQuery := "Automatic persisted queries: {\"persistedQuery\": {\"version\": 1, \"sha256Hash\": \"...\"}}"
+	graphqlResponses := func(responses map[string]*openapi.Response) map[string]*openapi.Response {
+		responses["200"] = &openapi.Response{Description: "The result of the operation", Content: jsonBody(openapi.Ref("GraphQLResponse"))}
+		responses["400"] = openapi.ResponseRef("ValidationFailed")
+		return apiResponses(responses)
+	}
+	doc.Add(http.MethodPost, "/graphql", idempotent(&openapi.Operation{
+		Tags:    []string{"graphql"},
+		Summary: "Run a GraphQL query or mutation",
+		Description: "Subscriptions need a WebSocket: send a GET with Upgrade: websocket and " +
+			"Sec-WebSocket-Protocol: graphql-transport-ws.",
+		OperationID: "postGraphQL",
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonBody(openapi.Object(map[string]*openapi.Schema{
+			"query":         openapi.Describe(openapi.String(), "The document; may be left out when extensions names a persisted query"),
+			"operationName": openapi.String(),
+			"variables":     openapi.MapOf(&openapi.Schema{}),
+			"extensions":    openapi.Describe(openapi.MapOf(&openapi.Schema{}), persistedQuery),
+		}))},
+		Responses: graphqlResponses(map[string]*openapi.Response{
+			"413": openapi.ResponseRef("BodyTooLarge"),
+			"415": {Description: "unsupported_media_type: the body is not application/json", Content: problemBody},
+		}),
+	}))
+	doc.Add(http.MethodGet, "/graphql", &openapi.Operation{
+		Tags:    []string{"graphql"},
+		Summary: "Run a GraphQL query, or open a subscription WebSocket",
+		Description: "Runs the query in the query string; mutations must use POST. " +
+			"With Upgrade: websocket and Sec-WebSocket-Protocol: graphql-transport-ws it opens a WebSocket for subscriptions instead.",
+		OperationID: "getGraphQL",
+		Parameters: []*openapi.Parameter{
+			{Name: "query", In: "query", Schema: openapi.String(), Description: "The document"},
+			{Name: "operationName", In: "query", Schema: openapi.String()},
+			{Name: "variables", In: "query", Schema: openapi.String(), Description: "Variables as a JSON object"},
+			{Name: "extensions", In: "query", Schema: openapi.String(), Description: persistedQuery},
+		},
+		Responses: graphqlResponses(map[string]*openapi.Response{
+			"405": {Description: "method_not_allowed: the operation is a mutation", Content: problemBody},
+		}),
+	})
+
+	// Health probes
+	health := jsonBody(openapi.Object(map[string]*openapi.Schema{
+		"status": openapi.String("ok", "fail"),
+		"checks": openapi.MapOf(doc.Response(CheckResult{})),
+	}, "status", "checks"))
+	for path, operationID := range map[string]string{"/livez": "livez", "/health": "health"} {
+		doc.Add(http.MethodGet, path, &openapi.Operation{
+			Tags:        []string{"health"},
+			Summary:     "Liveness probe",
+			Description: "Reports that the process can serve requests, without checking dependencies. /health is an alias of /livez.",
+			OperationID: operationID,
+			Responses:   map[string]*openapi.Response{"200": {Description: "The process is alive", Content: health}},
+		})
+	}
+	doc.Add(http.MethodGet, "/readyz", &openapi.Operation{
+		Tags:        []string{"health"},
+		Summary:     "Readiness probe",
+		Description: "Runs every dependency check; fails while any check fails or the server is shutting down.",
+		OperationID: "readyz",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Ready to serve traffic", Content: health},
+			"503": {Description: "Not ready", Content: health},
+		},
+	})
+
+	// Documentation endpoints
+	doc.Add(http.MethodGet, problem.TypePrefix+":code", &openapi.Operation{
+		Tags:        []string{"meta"},
+		Summary:     "Describe a problem type",
+		Description: "The type URI of every problem+json error response resolves here.",
+		OperationID: "getProblemType",
+		Parameters:  []*openapi.Parameter{{Name: "code", In: "path", Required: true, Schema: openapi.String()}},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The problem type", Content: jsonBody(openapi.Object(map[string]*openapi.Schema{
+				"type":        openapi.String(),
+				"code":        openapi.String(),
+				"title":       openapi.String(),
+				"status":      openapi.Integer(),
+				"description": openapi.String(),
+			}, "type", "code", "title", "status", "description"))},
+			"404": {Description: "route_not_found: unknown problem type", Content: problemBody},
+		},
+	})
+	doc.Add(http.MethodGet, "/openapi.json", &openapi.Operation{
+		Tags:        []string{"meta"},
+		Summary:     "This document",
+		OperationID: "getOpenAPI",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The OpenAPI document", Content: jsonBody(&openapi.Schema{Type: openapi.Types{"object"}})},
+			"429": openapi.ResponseRef("RateLimited"),
+		},
+	})
+
+	// The REST and GraphQL APIs authenticate callers by a user's JWT or an API
+	// token; anonymous callers are allowed unless AUTH_REQUIRED is set
+	doc.Components.SecuritySchemes["userToken"] = &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "A token issued by `todo users token`, signed with JWT_SECRET",
+	}
+	doc.Components.SecuritySchemes["apiToken"] = &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        auth.APIKeyHeader,
+		Description: "One of the tokens listed in API_TOKENS",
+	}
+	for path, item := range doc.Paths {
+		if !strings.HasPrefix(path, "/api/") && path != "/graphql" {
+			continue
+		}
+		for _, op := range item {
+			op.Security = []openapi.SecurityRequirement{{"userToken": {}}, {"apiToken": {}}, {}}
+			op.Responses["401"] = openapi.ResponseRef("Unauthorized")
+		}
+	}
+
+	return doc
+}
+
+// todoContent is a body of a todo endpoint: schema s as JSON or MessagePack,
+// or the todo.proto message named message as Protobuf
+func todoContent(s *openapi.Schema, message string) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{
+		JSONType:    {Schema: s},
+		MsgPackType: {Schema: s},
+		ProtobufType: {Schema: openapi.Describe(&openapi.Schema{Type: openapi.Types{"string"}, Format: "binary"},
+			"A todo."+message+" message of proto/todo.proto")},
+	}
+}
+
+// jsonBody is an application/json body of schema s
+func jsonBody(s *openapi.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{"application/json": {Schema: s}}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"todo-app/internal/domain"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Media types accepted by PATCH /todos/:id
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// readOnlyFields are the members of a todo document a patch cannot change
+var readOnlyFields = []string{"id", "created_at", "updated_at"}
+
+// documentPatch patches a todo by changing its JSON document, as the API
+// returns it. Members removed from the document or set to null are cleared.
+type documentPatch func(doc []byte) ([]byte, error)
+
+// mergePatch returns the RFC 7396 merge patch in body, which must be a JSON
+// object
+func mergePatch(body []byte) (documentPatch, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, errors.New("a merge patch must be a JSON object")
+	}
+	return func(doc []byte) ([]byte, error) {
+		return jsonpatch.MergePatch(doc, body)
+	}, nil
+}
+
+// jsonPatch returns the RFC 6902 JSON patch in body
+func jsonPatch(body []byte) (documentPatch, error) {
+	patch, err := jsonpatch.DecodePatch(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch: %v", err)
+	}
+	return patch.Apply, nil
+}
+
+// Patch applies p to the document of current and returns the changes to the
+// writable fields it made
+func (p documentPatch) Patch(current *domain.Todo) (*domain.TodoPatch, error) {
+	before, err := json.Marshal(current.ToResponse())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode todo: %v", err)
+	}
+	after, err := p(before)
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return nil, fmt.Errorf("%w: %v", domain.ErrPatchTestFailed, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrPatchConflict, err)
+	}
+
+	var old, patched map[string]json.RawMessage
+	if err := json.Unmarshal(before, &old); err != nil {
+		return nil, fmt.Errorf("failed to decode todo: %v", err)
+	}
+	if err := json.Unmarshal(after, &patched); err != nil || patched == nil {
+		return nil, domain.ErrPatchConflict
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(patched)) {
+		if _, ok := old[name]; !ok {
+			return nil, &domain.Error{Kind: domain.KindInvalid, Code: "unknown_field", Field: name, Message: name + " is not a field of a todo"}
+		}
+	}
+	for _, name := range readOnlyFields {
+		if !sameMember(old[name], patched[name]) {
+			return nil, &domain.Error{Kind: domain.KindInvalid, Code: "read_only", Field: name, Message: name + " cannot be changed"}
+		}
+	}
+
+	patch := &domain.TodoPatch{}
+	if patch.Title, err = changedField[string](old, patched, "title"); err != nil {
+		return nil, err
+	}
+	if patch.Description, err = changedField[string](old, patched, "description"); err != nil {
+		return nil, err
+	}
+	if patch.Priority, err = changedField[string](old, patched, "priority"); err != nil {
+		return nil, err
+	}
+	if patch.Completed, err = changedField[bool](old, patched, "completed"); err != nil {
+		return nil, err
+	}
+	if patch.DueDate, err = changedField[time.Time](old, patched, "due_date"); err != nil {
+		return nil, err
+	}
+	return patch, nil
+}
+
+// changedField returns the change to the member name between two documents:
+// none if it is unchanged, a clear if it was removed or set to null, and
+// otherwise its new value
+func changedField[T any](old, patched map[string]json.RawMessage, name string) (domain.Field[T], error) {
+	raw := patched[name]
+	if sameMember(old[name], raw) {
+		return domain.Field[T]{}, nil
+	}
+	if raw == nil || string(raw) == "null" {
+		return domain.Null[T](), nil
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return domain.Field[T]{}, &domain.Error{Kind: domain.KindInvalid, Code: "type", Field: name, Message: name + " must be a " + jsonTypeOf(v)}
+	}
+	return domain.Value(v), nil
+}
+
+// jsonTypeOf names the JSON type of the field values a patch can set
+func jsonTypeOf(v any) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "RFC 3339 timestamp string"
+	}
+	return "string"
+}
+
+// sameMember reports whether two members of a document, nil when missing,
+// hold equal JSON values
+func sameMember(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
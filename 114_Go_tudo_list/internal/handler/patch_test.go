@@ -0,0 +1,174 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// todoJSON is a todo as the API returns it; a null due_date decodes to ""
+type todoJSON struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Completed   bool   `json:"completed"`
+	Priority    string `json:"priority"`
+	DueDate     string `json:"due_date"`
+}
+
+// send serves a request with a JSON body of contentType and returns the response
+func send(t *testing.T, router http.Handler, method, path, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeTodo returns the todo in the data envelope of rec
+func decodeTodo(t *testing.T, rec *httptest.ResponseRecorder) todoJSON {
+	t.Helper()
+	var body struct {
+		Data todoJSON `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %s: %v", rec.Body, err)
+	}
+	return body.Data
+}
+
+// createFullTodo creates a todo with every writable field set
+func createFullTodo(t *testing.T, router http.Handler) todoJSON {
+	t.Helper()
+	rec := send(t, router, http.MethodPost, "/api/v1/todos", "application/json",
+		`{"title":"Write report","description":"Quarterly numbers","priority":"high","due_date":"2030-01-31T09:00:00Z"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: got %d; body %s", rec.Code, rec.Body)
+	}
+	return decodeTodo(t, rec)
+}
+
+func TestMergePatchNullClearsFields(t *testing.T) {
+	router := newTestRouter(t, nil)
+	todo := createFullTodo(t, router)
+
+	rec := send(t, router, http.MethodPatch, "/api/v1/todos/"+todo.ID, "application/merge-patch+json",
+		`{"description":null,"due_date":null,"completed":true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: got %d; body %s", rec.Code, rec.Body)
+	}
+
+	got := decodeTodo(t, rec)
+	if got.Description != "" || got.DueDate != "" {
+		t.Fatalf("cleared fields: got description %q and due_date %q, want both cleared", got.Description, got.DueDate)
+	}
+	if got.Title != "Write report" || got.Priority != "high" || !got.Completed {
+		t.Fatalf("other fields: got %+v, want title and priority kept and completed set", got)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		patch      string
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "passing test applies the patch",
+			patch:      `[{"op":"test","path":"/title","value":"Write report"},{"op":"replace","path":"/title","value":"Send report"}]`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "failing test changes nothing",
+			patch:      `[{"op":"test","path":"/title","value":"Something else"},{"op":"replace","path":"/title","value":"Send report"}]`,
+			wantStatus: http.StatusConflict,
+			wantCode:   "patch_test_failed",
+		},
+		{
+			name:       "removing a missing member",
+			patch:      `[{"op":"remove","path":"/labels"}]`,
+			wantStatus: http.StatusConflict,
+			wantCode:   "patch_conflict",
+		},
+		{
+			name:       "read-only member",
+			patch:      `[{"op":"replace","path":"/id","value":"00000000-0000-0000-0000-000000000000"}]`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "validation_failed",
+		},
+		{
+			name:       "invalid value",
+			patch:      `[{"op":"replace","path":"/priority","value":"urgent"}]`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "validation_failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t, nil)
+			todo := createFullTodo(t, router)
+
+			rec := send(t, router, http.MethodPatch, "/api/v1/todos/"+todo.ID, "application/json-patch+json", tt.patch)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("patch: got %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				var p struct {
+					Code string `json:"code"`
+				}
+				json.Unmarshal(rec.Body.Bytes(), &p)
+				if p.Code != tt.wantCode {
+					t.Fatalf("code: got %q, want %q", p.Code, tt.wantCode)
+				}
+
+				// A rejected patch leaves the todo as it was
+				got := decodeTodo(t, send(t, router, http.MethodGet, "/api/v1/todos/"+todo.ID, "", ""))
+				if got != todo {
+					t.Fatalf("todo after a rejected patch: got %+v, want %+v", got, todo)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchContentTypes(t *testing.T) {
+	router := newTestRouter(t, nil)
+	todo := createFullTodo(t, router)
+
+	rec := send(t, router, http.MethodPatch, "/api/v1/todos/"+todo.ID, "application/json", `{"title":"x"}`)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("patch as application/json: got %d, want %d", rec.Code, http.StatusUnsupportedMediaType)
+	}
+	if got := rec.Header().Get("Accept-Patch"); got != "application/merge-patch+json, application/json-patch+json" {
+		t.Fatalf("Accept-Patch: got %q", got)
+	}
+}
+
+func TestPutReplacesTheWholeTodo(t *testing.T) {
+	router := newTestRouter(t, nil)
+	todo := createFullTodo(t, router)
+
+	// Only the title is sent: every other writable field is reset
+	rec := send(t, router, http.MethodPut, "/api/v1/todos/"+todo.ID, "application/json", `{"title":"Send report"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put: got %d; body %s", rec.Code, rec.Body)
+	}
+
+	got := decodeTodo(t, rec)
+	want := todoJSON{ID: todo.ID, Title: "Send report", Priority: "medium"}
+	if got != want {
+		t.Fatalf("replaced todo: got %+v, want %+v", got, want)
+	}
+
+	// A replace without the required title is refused
+	rec = send(t, router, http.MethodPut, "/api/v1/todos/"+todo.ID, "application/json", `{"description":"no title"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("put without title: got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemType handles GET /problems/:code, documenting the type URI that
+// problem+json error responses refer to
+func ProblemType(c *gin.Context) {
+	code := c.Param("code")
+	t, ok := problem.Lookup(code)
+	if !ok {
+		c.Error(problem.New("route_not_found", "Unknown problem type "+code))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":        problem.TypePrefix + code,
+		"code":        code,
+		"title":       t.Title,
+		"status":      t.Status,
+		"description": t.Description,
+	})
+}
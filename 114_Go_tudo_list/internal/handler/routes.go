@@ -1,21 +1,57 @@
 package handler
 
 import (
+	"net/http"
+	"strings"
+	"time"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/config"
 	"todo-app/internal/domain"
-	// "todo-app/internal/middleware"
+	"todo-app/internal/graphql"
+	"todo-app/internal/idempotency"
+	"todo-app/internal/logging"
+	"todo-app/internal/middleware"
+	"todo-app/internal/openapi"
+	"todo-app/internal/problem"
+	"todo-app/internal/ratelimit"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // Router holds all handlers
 type Router struct {
-	todoHandler *TodoHandler
+	todoHandler   *TodoHandler
+	graphql       *graphql.Server
+	healthHandler *HealthHandler
+	adminHandler  *AdminHandler
+	docs          *openapi.Document
+	docsHandler   *OpenAPIHandler
+	verifier      *auth.Verifier
+	limiter       ratelimit.Store
+	keys          idempotency.Store
+	live          *config.Live
 }
 
-// NewRouter creates a new router with all handlers
-func NewRouter(todoService domain.TodoService) *Router {
+// NewRouter creates a new router with all handlers. gql serves /graphql over
+// the same todoService. verifier authenticates API callers, limiter keeps the
+// rate limit buckets of the route groups configured in RATE_LIMITS, and keys
+// the responses to API requests sent with an Idempotency-Key. Middleware reads
+// reloadable settings from live on every request.
+func NewRouter(todoService domain.TodoService, gql *graphql.Server, healthHandler *HealthHandler, verifier *auth.Verifier, limiter ratelimit.Store, keys idempotency.Store, live *config.Live) *Router {
+	docs := NewDocument()
 	return &Router{
-		todoHandler: NewTodoHandler(todoService),
+		todoHandler:   NewTodoHandler(todoService),
+		graphql:       gql,
+		healthHandler: healthHandler,
+		adminHandler:  NewAdminHandler(live),
+		docs:          docs,
+		docsHandler:   NewOpenAPIHandler(docs),
+		verifier:      verifier,
+		limiter:       limiter,
+		keys:          keys,
+		live:          live,
 	}
 }
 
@@ -24,46 +60,82 @@ func (r *Router) SetupRoutes() *gin.Engine {
 	// Set Gin to release mode for production
 	gin.SetMode(gin.ReleaseMode)
 
-	router := gin.Default()
+	cfg := r.live.Current()
+	router := gin.New()
+	router.Use(middleware.AccessLog(), gin.Recovery())
+
+	// Only believe client IP headers set by our own reverse proxy
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logging.Warnf("Invalid trusted proxies, trusting none: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+
+	// Tag requests with an ID and render recorded errors as problem+json
+	router.Use(middleware.RequestID())
+	if cfg.OpenAPI.Validate {
+		logging.Infof("Validating requests and responses against the OpenAPI document")
+		router.Use(middleware.ValidateOpenAPI(r.docs))
+	}
+	router.Use(middleware.Problems())
+	router.NoRoute(func(c *gin.Context) {
+		c.Error(problem.New("route_not_found", "No endpoint matches "+c.Request.Method+" "+c.Request.URL.Path))
+	})
+
+	// Add CORS middleware
+	router.Use(middleware.CORS(func() []string {
+		return r.live.Current().CORS.AllowedOrigins
+	}))
+
+	// Trace API requests and continue incoming W3C trace-context
+	router.Use(otelgin.Middleware("todo-app", otelgin.WithFilter(func(req *http.Request) bool {
+		return strings.HasPrefix(req.URL.Path, "/api/") || req.URL.Path == "/graphql"
+	})))
 
 	// Add request logging middleware
 	// router.Use(middleware.RequestLogger())
 
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Reject oversized request bodies before they reach the handlers
+	router.Use(middleware.BodyLimit(cfg.Server.MaxBodyBytes))
 
-		c.Next()
-	})
+	// Read from the primary during and shortly after a client's writes
+	router.Use(middleware.ReadYourWrites(func() time.Duration {
+		return r.live.Current().Database.ReadYourWritesWindow
+	}))
 
 	// Serve static files - specific files first
-	router.StaticFile("/styles.css", "./web/styles.css")
-	router.StaticFile("/script.js", "./web/script.js")
-	router.StaticFile("/favicon.ico", "./web/favicon.ico")
+	web := router.Group("", r.rateLimit("web"))
+	web.StaticFile("/styles.css", "./web/styles.css")
+	web.StaticFile("/script.js", "./web/script.js")
+	web.StaticFile("/favicon.ico", "./web/favicon.ico")
 
 	// Serve entire web directory for other assets
-	router.Static("/assets", "./web")
+	web.Static("/assets", "./web")
 
 	// Serve index.html for root path (must be last)
-	router.StaticFile("/", "./web/index.html")
-
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "OK",
-			"message": "Todo API is running",
-		})
-	})
-
-	// API v1 routes
-	v1 := router.Group("/api/v1")
+	web.StaticFile("/", "./web/index.html")
+
+	// OpenAPI document and Swagger UI
+	web.GET("/openapi.json", r.docsHandler.Spec)
+	web.GET("/docs", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, "/docs/") })
+	web.GET("/docs/*file", r.docsHandler.Docs)
+
+	// Health check endpoints (/health is kept as an alias of /livez)
+	router.GET("/livez", r.healthHandler.Livez)
+	router.GET("/readyz", r.healthHandler.Readyz)
+	router.GET("/health", r.healthHandler.Livez)
+
+	// Documentation of the problem type URIs in error responses
+	router.GET(problem.TypePrefix+":code", ProblemType)
+
+	// API routes, REST and GraphQL alike
+	// Requests carrying credentials are limited per IP before they are
+	// verified, so guesses are throttled; callers are then authenticated so
+	// they are rate limited per user or API token. Retries of unsafe requests
+	// with the same Idempotency-Key are answered from the saved response; rate
+	// limited requests are never saved
+	attempts := r.rateLimitAttempts("auth")
+	idempotency := middleware.Idempotency(r.keys, cfg.Idempotency.TTL)
+	v1 := router.Group("/api/v1", attempts, middleware.Authenticate(r.verifier), r.rateLimit("api"), idempotency)
 	{
 		todos := v1.Group("/todos")
 		{
@@ -71,10 +143,60 @@ func (r *Router) SetupRoutes() *gin.Engine {
 			todos.GET("", r.todoHandler.GetAllTodos)
 			todos.GET("/:id", r.todoHandler.GetTodo)
 			todos.PUT("/:id", r.todoHandler.UpdateTodo)
+			todos.PATCH("/:id", r.todoHandler.PatchTodo)
 			todos.DELETE("/:id", r.todoHandler.DeleteTodo)
 			todos.PATCH("/:id/toggle", r.todoHandler.ToggleComplete)
 		}
 	}
 
+	// GraphQL queries and mutations, and subscriptions over WebSocket, which
+	// may authenticate in connection_init
+	gql := router.Group("/graphql", attempts, middleware.AuthenticateSubscriptions(r.verifier), r.rateLimit("api"), idempotency)
+	{
+		gql.GET("", r.graphql.Handle)
+		gql.POST("", r.graphql.Handle)
+	}
+
+	return router
+}
+
+// SetupAdminRoutes sets up the operator endpoints. They are served on their
+// own listener, bound to ADMIN_HOST, so they are never reachable through the
+// public port or a proxy in front of it.
+func (r *Router) SetupAdminRoutes() *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.AccessLog(), gin.Recovery(), middleware.RequestID(), middleware.Problems())
+	router.NoRoute(func(c *gin.Context) {
+		c.Error(problem.New("route_not_found", "No endpoint matches "+c.Request.Method+" "+c.Request.URL.Path))
+	})
+
+	// Effective configuration version, with secrets redacted
+	router.GET("/admin/config", r.adminHandler.Config)
+
+	// Cache and event counters, in the expvar format
+	router.GET("/debug/vars", r.adminHandler.Vars)
+
 	return router
 }
+
+// rateLimit returns the rate limit middleware for a route group. Groups
+// missing from the current RATE_LIMITS are not limited.
+func (r *Router) rateLimit(group string) gin.HandlerFunc {
+	return r.limited(group, middleware.RateLimit)
+}
+
+// rateLimitAttempts returns the middleware limiting requests with credentials
+// per IP before they are verified
+func (r *Router) rateLimitAttempts(group string) gin.HandlerFunc {
+	return r.limited(group, middleware.RateLimitAttempts)
+}
+
+func (r *Router) limited(group string, limiter func(string, ratelimit.Store, func() (ratelimit.Limit, bool)) gin.HandlerFunc) gin.HandlerFunc {
+	if r.limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return limiter(group, r.limiter, func() (ratelimit.Limit, bool) {
+		limit, ok := r.live.Current().RateLimit.Limits[group]
+		return ratelimit.Limit{Requests: limit.Requests, Window: limit.Window}, ok
+	})
+}
@@ -0,0 +1,125 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/config"
+	"todo-app/internal/events"
+	"todo-app/internal/graphql"
+	"todo-app/internal/handler"
+	"todo-app/internal/idempotency"
+	"todo-app/internal/ratelimit"
+	"todo-app/internal/repository/memory"
+	"todo-app/internal/service"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestRouter returns the public routes over in-memory storage, configured
+// by the defaults overlaid with overrides keyed by setting path
+func newTestRouter(t *testing.T, overrides map[string]string) http.Handler {
+	t.Helper()
+	settings := map[string]string{"storage.driver": "memory", "log.level": "error"}
+	for path, value := range overrides {
+		settings[path] = value
+	}
+	cfg, err := config.Load(config.Options{Overrides: settings})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+
+	repo := memory.NewTodoRepository()
+	broker := events.NewBroker()
+	todos := events.NewTodoService(service.NewTodoService(repo, memory.NewUnitOfWork(repo)), broker)
+	verifier := auth.NewVerifier(cfg.JWT.Secret, cfg.Auth.APITokens, cfg.Auth.Required, nil)
+	gql, err := graphql.NewServer(todos, broker, graphql.Options{
+		MaxDepth:       cfg.GraphQL.MaxDepth,
+		MaxComplexity:  cfg.GraphQL.MaxComplexity,
+		AllowedOrigins: func() []string { return cfg.CORS.AllowedOrigins },
+		Verifier:       verifier,
+	})
+	if err != nil {
+		t.Fatalf("graphql: %v", err)
+	}
+	t.Cleanup(gql.Shutdown)
+
+	health := handler.NewHealthHandler(time.Second)
+	router := handler.NewRouter(todos, gql, health, verifier, ratelimit.NewMemory(), idempotency.NewMemory(), config.NewLive(cfg))
+	return router.SetupRoutes()
+}
+
+func TestUpgradeHeadersDoNotSkipAuthentication(t *testing.T) {
+	router := newTestRouter(t, map[string]string{"auth.required": "true"})
+
+	tests := []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/api/v1/todos"},
+		{http.MethodPost, "/api/v1/todos"},
+		{http.MethodPost, "/graphql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"title":"x"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status: got %d, want %d; body %s", rec.Code, http.StatusUnauthorized, rec.Body)
+			}
+		})
+	}
+}
+
+func TestSubscriptionsAuthenticateInConnectionInit(t *testing.T) {
+	server := httptest.NewServer(newTestRouter(t, map[string]string{"auth.required": "true"}))
+	defer server.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/graphql", nil)
+	if err != nil {
+		t.Fatalf("dial: %v (response %v)", err, resp)
+	}
+	defer conn.Close()
+
+	// Without credentials in connection_init the connection is refused
+	if err := conn.WriteJSON(map[string]any{"type": "connection_init"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, 4403) {
+		t.Fatalf("read: got %v, want close 4403", err)
+	}
+}
+
+func TestFailedAuthenticationIsRateLimited(t *testing.T) {
+	router := newTestRouter(t, map[string]string{"rate_limit.limits": "api=100/1m,auth=2/1m"})
+
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, code := range want {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/todos", nil)
+		req.Header.Set("X-API-Key", "guess")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != code {
+			t.Fatalf("attempt %d: got %d, want %d", i+1, rec.Code, code)
+		}
+	}
+
+	// Anonymous requests are limited by the api group only
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/todos", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("anonymous request: got %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"todo-app/internal/domain"
+	"todo-app/internal/problem"
+	"todo-app/internal/projection"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -45,16 +49,18 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 		}
 	}
 
+	format, ok := negotiate(c, todoFormats...)
+	if !ok {
+		return
+	}
+
 	var req domain.CreateTodoRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindTodo(c, &req); err != nil {
 		// === LOG BINDING ERROR ===
-		fmt.Printf("❌ JSON Binding Error: %v\n", err)
+		fmt.Printf("❌ Binding Error: %v\n", err)
 		fmt.Printf("================================\n")
 
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
@@ -71,29 +77,15 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 
 	// === CALL SERVICE ===
 	fmt.Printf("🔧 Calling TodoService.CreateTodo...\n")
-	todo, err := h.todoService.CreateTodo(req.Title, req.Description, req.Priority, req.DueDate)
+	todo, err := h.todoService.CreateTodo(c.Request.Context(), req.Title, req.Description, req.Priority, req.DueDate)
 
 	if err != nil {
 		// === LOG SERVICE ERROR ===
 		fmt.Printf("❌ Service Error: %v\n", err)
 		fmt.Printf("❌ Error Type: %T\n", err)
-
-		if err == domain.ErrInvalidTitle || err == domain.ErrTitleTooLong ||
-			err == domain.ErrDescriptionTooLong || err == domain.ErrInvalidPriority {
-			fmt.Printf("💡 Validation Error - returning 400\n")
-			fmt.Printf("================================\n")
-
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-
-		fmt.Printf("💥 Internal Error - returning 500\n")
 		fmt.Printf("================================\n")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create todo",
-		})
+
+		c.Error(fmt.Errorf("failed to create todo: %w", err))
 		return
 	}
 
@@ -106,244 +98,242 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 	fmt.Printf("   📅 Created At: %s\n", todo.CreatedAt.Format("2006-01-02 15:04:05"))
 	fmt.Printf("================================\n")
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Todo created successfully",
-		"data":    todo.ToResponse(),
-	})
+	renderTodo(c, format, http.StatusCreated, "Todo created successfully", todo, nil)
 }
 
 // GetTodo handles GET /todos/:id
 func (h *TodoHandler) GetTodo(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid todo ID format",
-		})
+		c.Error(domain.ErrInvalidID)
 		return
 	}
 
-	todo, err := h.todoService.GetTodo(id)
+	fields, err := readFields(c)
 	if err != nil {
-		if err == domain.ErrTodoNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Todo not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get todo",
-		})
+		c.Error(err)
+		return
+	}
+	format, ok := negotiate(c, todoFormats...)
+	if !ok {
+		return
+	}
+
+	todo, err := h.todoService.GetTodo(projection.WithFields(c.Request.Context(), fields), id)
+	if err != nil {
+		c.Error(fmt.Errorf("failed to get todo: %w", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": todo.ToResponse(),
-	})
+	renderTodo(c, format, http.StatusOK, "", todo, fields)
 }
 
 // GetAllTodos handles GET /todos
 func (h *TodoHandler) GetAllTodos(c *gin.Context) {
+	fields, err := readFields(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	format, ok := negotiate(c, listFormats...)
+	if !ok {
+		return
+	}
+	ctx := projection.WithFields(c.Request.Context(), fields)
+
+	// Check for full-text search
+	if q := c.Query("q"); q != "" {
+		todos, err := h.todoService.SearchTodos(ctx, q)
+		if err != nil {
+			c.Error(fmt.Errorf("failed to search todos: %w", err))
+			return
+		}
+		renderTodos(c, format, todos, fields)
+		return
+	}
+
 	// Check for status filter
 	statusParam := c.Query("status")
 	if statusParam != "" {
 		if statusParam == "completed" {
-			todos, err := h.todoService.GetTodosByStatus(true)
+			todos, err := h.todoService.GetTodosByStatus(ctx, true)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": "Failed to get todos",
-				})
+				c.Error(fmt.Errorf("failed to get todos: %w", err))
 				return
 			}
-			h.respondWithTodos(c, todos)
+			renderTodos(c, format, todos, fields)
 			return
 		} else if statusParam == "pending" {
-			todos, err := h.todoService.GetTodosByStatus(false)
+			todos, err := h.todoService.GetTodosByStatus(ctx, false)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": "Failed to get todos",
-				})
+				c.Error(fmt.Errorf("failed to get todos: %w", err))
 				return
 			}
-			h.respondWithTodos(c, todos)
+			renderTodos(c, format, todos, fields)
 			return
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid status parameter. Use 'completed' or 'pending'",
-			})
+			c.Error(domain.ErrInvalidStatusFilter)
 			return
 		}
 	}
 
-	todos, err := h.todoService.GetAllTodos()
+	todos, err := h.todoService.GetAllTodos(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get todos",
-		})
+		c.Error(fmt.Errorf("failed to get todos: %w", err))
 		return
 	}
 
-	h.respondWithTodos(c, todos)
+	renderTodos(c, format, todos, fields)
 }
 
-// UpdateTodo handles PUT /todos/:id
+// UpdateTodo handles PUT /todos/:id, which replaces the whole todo
 func (h *TodoHandler) UpdateTodo(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid todo ID format",
-		})
+		c.Error(domain.ErrInvalidID)
+		return
+	}
+
+	format, ok := negotiate(c, todoFormats...)
+	if !ok {
 		return
 	}
 
 	var req domain.UpdateTodoRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+	if err := bindTodo(c, &req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	// Get current todo to preserve existing values
-	currentTodo, err := h.todoService.GetTodo(id)
+	todo, err := h.todoService.UpdateTodo(c.Request.Context(), id, req.Title, req.Description, req.Priority, req.Completed, req.DueDate)
 	if err != nil {
-		if err == domain.ErrTodoNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Todo not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get todo",
-		})
+		c.Error(fmt.Errorf("failed to update todo: %w", err))
 		return
 	}
 
-	// Prepare update parameters
-	title := currentTodo.Title
-	if req.Title != nil {
-		title = *req.Title
-	}
+	renderTodo(c, format, http.StatusOK, "Todo updated successfully", todo, nil)
+}
 
-	description := currentTodo.Description
-	if req.Description != nil {
-		description = *req.Description
+// PatchTodo handles PATCH /todos/:id with a JSON Merge Patch (RFC 7396) or a
+// JSON Patch (RFC 6902), applied to the todo as the API returns it
+func (h *TodoHandler) PatchTodo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(domain.ErrInvalidID)
+		return
 	}
 
-	priority := currentTodo.Priority
-	if req.Priority != nil {
-		priority = *req.Priority
+	format, ok := negotiate(c, todoFormats...)
+	if !ok {
+		return
 	}
 
-	completed := currentTodo.Completed
-	if req.Completed != nil {
-		completed = *req.Completed
+	var decode func([]byte) (documentPatch, error)
+	switch c.ContentType() {
+	case MergePatchType:
+		decode = mergePatch
+	case JSONPatchType:
+		decode = jsonPatch
+	default:
+		c.Header("Accept-Patch", MergePatchType+", "+JSONPatchType)
+		c.Error(problem.New("unsupported_media_type", "Content-Type must be "+MergePatchType+" or "+JSONPatchType))
+		return
 	}
 
-	dueDate := currentTodo.DueDate
-	if req.DueDate != nil {
-		dueDate = req.DueDate
+	body, err := c.GetRawData()
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	patch, err := decode(body)
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
 	}
 
-	todo, err := h.todoService.UpdateTodo(id, title, description, priority, completed, dueDate)
+	todo, err := h.todoService.PatchTodo(c.Request.Context(), id, patch)
 	if err != nil {
-		if err == domain.ErrTodoNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Todo not found",
-			})
-			return
-		}
-		if err == domain.ErrInvalidTitle || err == domain.ErrTitleTooLong ||
-			err == domain.ErrDescriptionTooLong || err == domain.ErrInvalidPriority {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update todo",
-		})
+		c.Error(fmt.Errorf("failed to patch todo: %w", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Todo updated successfully",
-		"data":    todo.ToResponse(),
-	})
+	renderTodo(c, format, http.StatusOK, "Todo updated successfully", todo, nil)
 }
 
 // DeleteTodo handles DELETE /todos/:id
 func (h *TodoHandler) DeleteTodo(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid todo ID format",
-		})
+		c.Error(domain.ErrInvalidID)
 		return
 	}
 
-	err = h.todoService.DeleteTodo(id)
+	format, ok := negotiate(c, todoFormats...)
+	if !ok {
+		return
+	}
+
+	err = h.todoService.DeleteTodo(c.Request.Context(), id)
 	if err != nil {
-		if err == domain.ErrTodoNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Todo not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete todo",
-		})
+		c.Error(fmt.Errorf("failed to delete todo: %w", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Todo deleted successfully",
-	})
+	renderMessage(c, format, http.StatusOK, "Todo deleted successfully")
 }
 
 // ToggleComplete handles PATCH /todos/:id/toggle
 func (h *TodoHandler) ToggleComplete(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid todo ID format",
-		})
+		c.Error(domain.ErrInvalidID)
+		return
+	}
+
+	format, ok := negotiate(c, todoFormats...)
+	if !ok {
 		return
 	}
 
-	todo, err := h.todoService.ToggleComplete(id)
+	todo, err := h.todoService.ToggleComplete(c.Request.Context(), id)
 	if err != nil {
-		if err == domain.ErrTodoNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Todo not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to toggle todo completion",
-		})
+		c.Error(fmt.Errorf("failed to toggle todo completion: %w", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Todo completion status toggled successfully",
-		"data":    todo.ToResponse(),
-	})
+	renderTodo(c, format, http.StatusOK, "Todo completion status toggled successfully", todo, nil)
 }
 
-// respondWithTodos is a helper function to respond with a list of todos
-func (h *TodoHandler) respondWithTodos(c *gin.Context, todos []*domain.Todo) {
-	responses := make([]*domain.TodoResponse, len(todos))
-	for i, todo := range todos {
-		responses[i] = todo.ToResponse()
+// readFields reads the sparse fieldset of a read from the fields query
+// parameter, a comma-separated list of todo fields. It returns nil when every
+// field is wanted.
+func readFields(c *gin.Context) ([]string, error) {
+	param := c.Query("fields")
+	if param == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(domain.TodoFields, field) {
+			return nil, domain.ErrInvalidFieldset
+		}
+		fields = append(fields, field)
 	}
+	return fields, nil
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":  responses,
-		"count": len(responses),
-	})
+// project returns the members of todo named by fields, and always its id, or
+// todo itself when fields is nil
+func project(todo *domain.TodoResponse, fields []string) any {
+	if fields == nil {
+		return todo
+	}
+	members := todoMembers(todo)
+	projected := map[string]any{"id": members["id"]}
+	for _, field := range fields {
+		projected[field] = members[field]
+	}
+	return projected
 }
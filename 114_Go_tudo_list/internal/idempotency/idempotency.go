@@ -0,0 +1,40 @@
+// Package idempotency stores the responses of requests sent with an
+// Idempotency-Key so retries of the same request are answered with the saved
+// response instead of being executed again. Keys are kept in process memory,
+// Redis or PostgreSQL.
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// PendingTTL is how long a key stays reserved for a request that has not
+// finished. A request that crashed the process frees its key after this long.
+const PendingTTL = time.Minute
+
+// Record is what is stored under a key
+type Record struct {
+	// Fingerprint identifies the request the key was first used for
+	Fingerprint string `json:"fingerprint"`
+	// Status is zero while that request is still being handled
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Pending reports whether the first request with the key is still running
+func (r *Record) Pending() bool {
+	return r.Status == 0
+}
+
+// Store keeps records by key
+type Store interface {
+	// Reserve stores a pending record for key unless the key holds an
+	// unexpired record, which is returned instead
+	Reserve(ctx context.Context, key, fingerprint string) (existing *Record, err error)
+	// Complete saves the response to a reserved key for ttl
+	Complete(ctx context.Context, key string, record Record, ttl time.Duration) error
+	// Release frees a reserved key so the request can be retried
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,85 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired records are dropped from a Memory store
+const sweepInterval = time.Minute
+
+// Memory is a Store that keeps records in process memory. Keys only protect
+// against retries that reach the same instance.
+type Memory struct {
+	mu        sync.Mutex
+	records   map[string]*entry
+	lastSweep time.Time
+	// now is the clock, replaced in tests
+	now func() time.Time
+}
+
+type entry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// NewMemory creates an empty Memory store
+func NewMemory() *Memory {
+	return &Memory{
+		records:   make(map[string]*entry),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Reserve stores a pending record for key unless it holds an unexpired one
+func (m *Memory) Reserve(ctx context.Context, key, fingerprint string) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	if e, ok := m.records[key]; ok && now.Before(e.expiresAt) {
+		existing := e.record
+		return &existing, nil
+	}
+	m.records[key] = &entry{
+		record:    Record{Fingerprint: fingerprint},
+		expiresAt: now.Add(PendingTTL),
+	}
+	return nil, nil
+}
+
+// Complete saves the response to a reserved key
+func (m *Memory) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records[key] = &entry{record: record, expiresAt: m.now().Add(ttl)}
+	return nil
+}
+
+// Release frees a reserved key
+func (m *Memory) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.records, key)
+	return nil
+}
+
+// sweep drops expired records, at most once per sweepInterval. Callers must hold the lock.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, e := range m.records {
+		if !now.Before(e.expiresAt) {
+			delete(m.records, key)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/worker"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres is a Store that keeps records in the idempotency_keys table,
+// shared by every instance. Sweep deletes expired rows.
+type Postgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgres creates a Postgres store
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool}
+}
+
+// Reserve stores a pending record for key unless it holds an unexpired one.
+// An expired row is taken over in the same statement.
+func (p *Postgres) Reserve(ctx context.Context, key, fingerprint string) (*Record, error) {
+	reserve := `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status = NULL, content_type = NULL, body = NULL,
+			created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()`
+	get := `
+		SELECT fingerprint, COALESCE(status, 0), COALESCE(content_type, ''), body
+		FROM idempotency_keys
+		WHERE key = $1 AND expires_at > NOW()`
+
+	// The row can expire between the insert and the select, so try again once
+	for range 2 {
+		tag, err := p.pool.Exec(ctx, reserve, key, fingerprint, PendingTTL.Milliseconds())
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %v", err)
+		}
+		if tag.RowsAffected() == 1 {
+			return nil, nil
+		}
+
+		existing := &Record{}
+		err = p.pool.QueryRow(ctx, get, key).Scan(&existing.Fingerprint, &existing.Status, &existing.ContentType, &existing.Body)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get idempotency record: %v", err)
+		}
+		return existing, nil
+	}
+	return nil, fmt.Errorf("failed to reserve idempotency key %q: it keeps expiring", key)
+}
+
+// Complete saves the response to a reserved key
+func (p *Postgres) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status = $2, content_type = $3, body = $4, expires_at = NOW() + $5 * INTERVAL '1 millisecond'
+		WHERE key = $1 AND fingerprint = $6`
+
+	_, err := p.pool.Exec(ctx, query, key, record.Status, record.ContentType, record.Body, ttl.Milliseconds(), record.Fingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency record: %v", err)
+	}
+	return nil
+}
+
+// Release frees a reserved key
+func (p *Postgres) Release(ctx context.Context, key string) error {
+	if _, err := p.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status IS NULL`, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+	return nil
+}
+
+// Sweep deletes expired rows every interval until ctx is cancelled. Expired
+// rows are already ignored, so this only keeps the table small.
+func (p *Postgres) Sweep(interval time.Duration) worker.Func {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+
+			tag, err := p.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logging.Warnf("Failed to delete expired idempotency keys: %v", err)
+				continue
+			}
+			if n := tag.RowsAffected(); n > 0 {
+				logging.Debugf("Deleted %d expired idempotency key(s)", n)
+			}
+		}
+	}
+}
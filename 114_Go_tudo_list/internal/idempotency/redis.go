@@ -0,0 +1,79 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a Store that keeps records in Redis, shared by every instance.
+// Records expire with the keys holding them.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis creates a Redis store. Keys are prefixed with "idempotency:".
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{
+		client: client,
+		prefix: "idempotency:",
+	}
+}
+
+// Reserve stores a pending record for key unless it holds one
+func (r *Redis) Reserve(ctx context.Context, key, fingerprint string) (*Record, error) {
+	pending, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode idempotency record: %v", err)
+	}
+
+	// The record can expire between SET NX and GET, so try again once
+	for range 2 {
+		reserved, err := r.client.SetNX(ctx, r.prefix+key, pending, PendingTTL).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %v", err)
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		data, err := r.client.Get(ctx, r.prefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get idempotency record: %v", err)
+		}
+		var existing Record
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return nil, fmt.Errorf("failed to decode idempotency record: %v", err)
+		}
+		return &existing, nil
+	}
+	return nil, fmt.Errorf("failed to reserve idempotency key %q: it keeps expiring", key)
+}
+
+// Complete saves the response to a reserved key
+func (r *Redis) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency record: %v", err)
+	}
+	if err := r.client.Set(ctx, r.prefix+key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save idempotency record: %v", err)
+	}
+	return nil
+}
+
+// Release frees a reserved key
+func (r *Redis) Release(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, r.prefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+	return nil
+}
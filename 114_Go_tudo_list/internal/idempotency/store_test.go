@@ -0,0 +1,192 @@
+package idempotency
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-app/internal/migrate"
+	"todo-app/migrations"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/redis/go-redis/v9"
+)
+
+// Variables naming disposable test backends; their stores are skipped when unset
+const (
+	postgresEnv = "TEST_POSTGRES_DSN"
+	redisEnv    = "TEST_REDIS_ADDR"
+)
+
+func TestMemory(t *testing.T) {
+	runStoreTests(t, func(t *testing.T) (Store, func(time.Duration)) {
+		now := time.Unix(1_700_000_000, 0)
+		m := NewMemory()
+		m.now = func() time.Time { return now }
+		return m, func(d time.Duration) { now = now.Add(d) }
+	})
+}
+
+func TestMemoryPendingKeysExpire(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := m.Reserve(ctx, "k", "first"); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	// A request that never completed frees its key after PendingTTL
+	now = now.Add(PendingTTL)
+	existing, err := m.Reserve(ctx, "k", "second")
+	if err != nil || existing != nil {
+		t.Fatalf("Reserve after PendingTTL: got %+v, %v; want the key reserved again", existing, err)
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv(postgresEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresEnv)
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("pgxpool.New: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+	migrator, err := migrate.New(db, migrate.Postgres, migrations.Postgres)
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	runStoreTests(t, func(t *testing.T) (Store, func(time.Duration)) {
+		return NewPostgres(pool), time.Sleep
+	})
+}
+
+func TestRedis(t *testing.T) {
+	addr := os.Getenv(redisEnv)
+	if addr == "" {
+		t.Skipf("%s is not set", redisEnv)
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+
+	runStoreTests(t, func(t *testing.T) (Store, func(time.Duration)) {
+		return NewRedis(client), time.Sleep
+	})
+}
+
+// runStoreTests checks the behaviour every Store must have. newStore returns
+// the store and a function letting time pass for it.
+func runStoreTests(t *testing.T, newStore func(t *testing.T) (Store, func(time.Duration))) {
+	ctx := context.Background()
+	// Keys are unique per test so stores shared between runs start empty
+	newKey := func() string { return "test:" + uuid.NewString() }
+	completed := Record{Fingerprint: "fp", Status: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+
+	t.Run("first request reserves the key", func(t *testing.T) {
+		store, _ := newStore(t)
+		key := newKey()
+
+		existing, err := store.Reserve(ctx, key, "fp")
+		if err != nil || existing != nil {
+			t.Fatalf("Reserve: got %+v, %v; want the key reserved", existing, err)
+		}
+		existing, err = store.Reserve(ctx, key, "fp")
+		if err != nil || existing == nil || !existing.Pending() || existing.Fingerprint != "fp" {
+			t.Fatalf("Reserve while pending: got %+v, %v; want the pending record", existing, err)
+		}
+	})
+
+	t.Run("completed response is returned", func(t *testing.T) {
+		store, _ := newStore(t)
+		key := newKey()
+
+		if _, err := store.Reserve(ctx, key, "fp"); err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		if err := store.Complete(ctx, key, completed, time.Hour); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		existing, err := store.Reserve(ctx, key, "fp")
+		if err != nil || existing == nil || !reflect.DeepEqual(*existing, completed) {
+			t.Fatalf("Reserve: got %+v, %v; want %+v", existing, err, completed)
+		}
+	})
+
+	t.Run("released key can be reserved again", func(t *testing.T) {
+		store, _ := newStore(t)
+		key := newKey()
+
+		if _, err := store.Reserve(ctx, key, "fp"); err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		if err := store.Release(ctx, key); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		existing, err := store.Reserve(ctx, key, "other")
+		if err != nil || existing != nil {
+			t.Fatalf("Reserve after Release: got %+v, %v; want the key reserved", existing, err)
+		}
+	})
+
+	t.Run("completed response expires after its ttl", func(t *testing.T) {
+		store, advance := newStore(t)
+		key := newKey()
+		ttl := 200 * time.Millisecond
+
+		if _, err := store.Reserve(ctx, key, "fp"); err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		if err := store.Complete(ctx, key, completed, ttl); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		advance(ttl + 100*time.Millisecond)
+
+		existing, err := store.Reserve(ctx, key, "other")
+		if err != nil || existing != nil {
+			t.Fatalf("Reserve after the ttl: got %+v, %v; want the key reserved again", existing, err)
+		}
+	})
+
+	t.Run("concurrent reservations", func(t *testing.T) {
+		store, _ := newStore(t)
+		key := newKey()
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		reserved := 0
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				existing, err := store.Reserve(ctx, key, "fp")
+				if err != nil {
+					t.Errorf("Reserve: %v", err)
+					return
+				}
+				if existing == nil {
+					mu.Lock()
+					reserved++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if reserved != 1 {
+			t.Fatalf("%d requests reserved the key, want 1", reserved)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+)
+
+// Level is the severity of a log message
+type Level int32
+
+// Levels in increasing severity. The zero value is LevelInfo.
+const (
+	LevelDebug Level = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// minLevel is the least severe level written; it can change while the server runs
+var minLevel atomic.Int32
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(s string) (Level, error) {
+	switch s {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", s)
+}
+
+// SetLevel sets the least severe level that is written
+func SetLevel(level Level) {
+	minLevel.Store(int32(level))
+}
+
+// Enabled reports whether messages at level are written
+func Enabled(level Level) bool {
+	return int32(level) >= minLevel.Load()
+}
+
+// Debugf logs detail only useful while troubleshooting
+func Debugf(format string, args ...any) {
+	output(LevelDebug, format, args...)
+}
+
+// Infof logs normal operation such as startup and shutdown steps
+func Infof(format string, args ...any) {
+	output(LevelInfo, format, args...)
+}
+
+// Warnf logs a problem the application recovered from
+func Warnf(format string, args ...any) {
+	output(LevelWarn, format, args...)
+}
+
+// Errorf logs a failure that needs attention
+func Errorf(format string, args ...any) {
+	output(LevelError, format, args...)
+}
+
+func output(level Level, format string, args ...any) {
+	if Enabled(level) {
+		// Skip output and the exported wrapper so file:line flags point at the caller
+		log.Output(3, fmt.Sprintf(format, args...))
+	}
+}
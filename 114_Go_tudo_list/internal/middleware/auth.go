@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate verifies the JWT in the Authorization header or the API token
+// in X-API-Key. A verified caller is recorded under UserIDKey or APITokenKey
+// for the rate limiter and in the request context for the handlers. Invalid
+// credentials, and missing ones when the verifier requires them, get 401.
+func Authenticate(verifier *auth.Verifier) gin.HandlerFunc {
+	return authenticate(verifier, false)
+}
+
+// AuthenticateSubscriptions is Authenticate for the GraphQL endpoint.
+// Browsers cannot set headers on a WebSocket upgrade, so a GET upgrade without
+// credentials is let through to authenticate in connection_init instead.
+func AuthenticateSubscriptions(verifier *auth.Verifier) gin.HandlerFunc {
+	return authenticate(verifier, true)
+}
+
+func authenticate(verifier *auth.Verifier, deferUpgrades bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := verifier.Verify(c.Request.Context(), c.GetHeader("Authorization"), c.GetHeader(auth.APIKeyHeader))
+		upgrade := deferUpgrades && c.Request.Method == http.MethodGet && c.IsWebsocket()
+		if err == nil && !upgrade {
+			err = verifier.Require(id)
+		}
+		if err != nil && !auth.Rejected(err) {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="todo-app"`)
+			c.Error(problem.New("unauthorized", err.Error()))
+			c.Abort()
+			return
+		}
+
+		if id.UserID != "" {
+			c.Set(UserIDKey, id.UserID)
+		}
+		if id.Token != "" {
+			c.Set(APITokenKey, id.Token)
+		}
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), id))
+		c.Next()
+	}
+}
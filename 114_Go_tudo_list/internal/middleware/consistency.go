@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"todo-app/internal/consistency"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readYourWritesCookie holds the Unix time in milliseconds until which the client reads from the primary
+const readYourWritesCookie = "todo_rw_until"
+
+// ReadYourWrites pins reads to the primary database for a request that writes
+// and, through a cookie, for the same client's requests during the window after
+// it, so a client never reads a replica that has not caught up with its own
+// writes. window is read on every write so it can change at runtime.
+func ReadYourWrites(window func() time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if value, err := c.Cookie(readYourWritesCookie); err == nil {
+				until, err := strconv.ParseInt(value, 10, 64)
+				if err == nil && time.Now().UnixMilli() < until {
+					c.Request = c.Request.WithContext(consistency.PinPrimary(c.Request.Context()))
+				}
+			}
+		default:
+			until := time.Now().Add(window())
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     readYourWritesCookie,
+				Value:    strconv.FormatInt(until.UnixMilli(), 10),
+				Path:     "/",
+				Expires:  until,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			c.Request = c.Request.WithContext(consistency.PinPrimary(c.Request.Context()))
+		}
+
+		c.Next()
+	}
+}
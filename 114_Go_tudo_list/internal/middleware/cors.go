@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS lets browsers call the API from the origins returned by allowed, which
+// is read on every request so the list can change at runtime. "*" allows any
+// origin; other origins get no Access-Control-Allow-Origin header and are
+// blocked by the browser.
+func CORS(allowed func() []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origins := allowed()
+		if slices.Contains(origins, "*") {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else if origin := c.GetHeader("Origin"); origin != "" {
+			// The answer depends on Origin, so caches must not share it
+			c.Header("Vary", "Origin")
+			if slices.Contains(origins, origin) {
+				c.Header("Access-Control-Allow-Origin", origin)
+			}
+		}
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, X-Request-ID, Idempotent-Replayed, Accept-Patch")
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
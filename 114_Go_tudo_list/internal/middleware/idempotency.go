@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"todo-app/internal/idempotency"
+	"todo-app/internal/logging"
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of requests sent with an idempotency key and of replayed responses
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength is the longest Idempotency-Key accepted
+const maxIdempotencyKeyLength = 255
+
+// idempotencyRetryAfter is the Retry-After sent while a key is in use or the
+// store is unavailable
+const idempotencyRetryAfter = time.Second
+
+// Idempotency makes unsafe requests sent with an Idempotency-Key header safe
+// to retry. The first request with a key runs normally and its response is
+// saved with a fingerprint of the method, URL, media types and body for ttl; a retry with
+// the same key and request gets the saved response, marked with
+// Idempotent-Replayed: true, without running the handler again. Reusing a key
+// for a different request is answered with 422, and a retry arriving while
+// the first request is still running with 409.
+//
+// 5xx responses are not saved so the request can be retried. Keys are scoped
+// to the verified user or API token; anonymous clients share one namespace
+// and should send random keys such as UUIDs.
+func Idempotency(store idempotency.Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !unsafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength || !printableASCII(key) {
+			p := problem.New("validation_failed", "The request has invalid fields")
+			p.Errors = []problem.FieldError{{
+				Field:   IdempotencyKeyHeader,
+				Code:    "invalid_idempotency_key",
+				Message: "Idempotency-Key must be 1 to 255 printable ASCII characters",
+			}}
+			c.Error(p)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		if err != nil {
+			// Let the handler report the unreadable or oversized body
+			c.Next()
+			return
+		}
+
+		scope := verifiedClient(c)
+		if scope == "" {
+			scope = "anonymous"
+		}
+		key = scope + ":" + key
+		fingerprint := requestFingerprint(c.Request, body)
+
+		existing, err := store.Reserve(c.Request.Context(), key, fingerprint)
+		if err != nil {
+			logging.Errorf("Idempotency store unavailable (request %s): %v", GetRequestID(c), err)
+			p := problem.New("service_unavailable", "Service temporarily unavailable, please retry later")
+			p.RetryAfter = idempotencyRetryAfter
+			c.Error(p)
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			replay(c, existing, fingerprint)
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		// Render a recorded error now so the problem is saved with the response
+		writeLastError(c)
+
+		// The client may have gone away, but the outcome must still be recorded
+		ctx := context.WithoutCancel(c.Request.Context())
+		if recorder.Status() >= http.StatusInternalServerError {
+			err = store.Release(ctx, key)
+		} else {
+			err = store.Complete(ctx, key, idempotency.Record{
+				Fingerprint: fingerprint,
+				Status:      recorder.Status(),
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			}, ttl)
+		}
+		if err != nil {
+			logging.Warnf("Failed to record idempotent response (request %s): %v", GetRequestID(c), err)
+		}
+	}
+}
+
+// replay answers a request whose key is already taken
+func replay(c *gin.Context, existing *idempotency.Record, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		c.Error(problem.New("idempotency_key_reused", "This Idempotency-Key was used for a different request"))
+	case existing.Pending():
+		p := problem.New("idempotency_key_in_use", "A request with this Idempotency-Key is still in progress")
+		p.RetryAfter = idempotencyRetryAfter
+		c.Error(p)
+	default:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(existing.Status, existing.ContentType, existing.Body)
+	}
+	c.Abort()
+}
+
+// requestFingerprint identifies a request by its method, URL, media types and
+// body; the media types decide how the body is read and the response written
+func requestFingerprint(r *http.Request, body []byte) string {
+	sum := sha256.New()
+	io.WriteString(sum, r.Method+" "+r.URL.RequestURI()+"\n")
+	io.WriteString(sum, r.Header.Get("Content-Type")+"\n"+r.Header.Get("Accept")+"\n")
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+func unsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func printableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"todo-app/internal/idempotency"
+	"todo-app/internal/middleware"
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotentServer serves POST /todos behind Idempotency, counting the
+// requests its handler runs. When block is not nil the handler waits on it.
+type idempotentServer struct {
+	router  *gin.Engine
+	runs    atomic.Int32
+	started chan struct{}
+	block   chan struct{}
+	status  int
+}
+
+func newIdempotentServer(ttl time.Duration) *idempotentServer {
+	s := &idempotentServer{status: http.StatusCreated}
+	gin.SetMode(gin.TestMode)
+	s.router = gin.New()
+	s.router.Use(middleware.Problems())
+	s.router.POST("/todos", middleware.Idempotency(idempotency.NewMemory(), ttl), func(c *gin.Context) {
+		n := s.runs.Add(1)
+		if s.block != nil {
+			close(s.started)
+			<-s.block
+		}
+		c.JSON(s.status, gin.H{"run": n})
+	})
+	return s
+}
+
+func (s *idempotentServer) post(key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysTheSavedResponse(t *testing.T) {
+	s := newIdempotentServer(time.Hour)
+
+	first := s.post("key-1", `{"title":"Buy milk"}`)
+	retry := s.post("key-1", `{"title":"Buy milk"}`)
+
+	if s.runs.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", s.runs.Load())
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Fatalf("replay: got %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if got := retry.Header().Get("Content-Type"); got != first.Header().Get("Content-Type") {
+		t.Fatalf("replay Content-Type: got %q, want %q", got, first.Header().Get("Content-Type"))
+	}
+	if retry.Header().Get(middleware.IdempotentReplayedHeader) != "true" || first.Header().Get(middleware.IdempotentReplayedHeader) != "" {
+		t.Fatalf("%s: got %q on the first response and %q on the replay", middleware.IdempotentReplayedHeader,
+			first.Header().Get(middleware.IdempotentReplayedHeader), retry.Header().Get(middleware.IdempotentReplayedHeader))
+	}
+}
+
+func TestIdempotencyRejectsAKeyReusedForAnotherRequest(t *testing.T) {
+	s := newIdempotentServer(time.Hour)
+
+	s.post("key-1", `{"title":"Buy milk"}`)
+	rec := s.post("key-1", `{"title":"Buy bread"}`)
+
+	assertProblem(t, rec, http.StatusUnprocessableEntity, "idempotency_key_reused")
+	if s.runs.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", s.runs.Load())
+	}
+}
+
+func TestIdempotencyRejectsARetryWhileTheFirstIsRunning(t *testing.T) {
+	s := newIdempotentServer(time.Hour)
+	s.started, s.block = make(chan struct{}), make(chan struct{})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- s.post("key-1", `{"title":"Buy milk"}`) }()
+	<-s.started
+
+	rec := s.post("key-1", `{"title":"Buy milk"}`)
+	assertProblem(t, rec, http.StatusConflict, "idempotency_key_in_use")
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("Retry-After: got %q, want %q", rec.Header().Get("Retry-After"), "1")
+	}
+
+	close(s.block)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("first request: got %d, want %d", first.Code, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyDoesNotSaveServerErrors(t *testing.T) {
+	s := newIdempotentServer(time.Hour)
+	s.status = http.StatusServiceUnavailable
+
+	s.post("key-1", `{"title":"Buy milk"}`)
+	s.status = http.StatusCreated
+	rec := s.post("key-1", `{"title":"Buy milk"}`)
+
+	if rec.Code != http.StatusCreated || s.runs.Load() != 2 {
+		t.Fatalf("retry after a 5xx: got %d after %d runs, want %d after 2", rec.Code, s.runs.Load(), http.StatusCreated)
+	}
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	ttl := 50 * time.Millisecond
+	s := newIdempotentServer(ttl)
+
+	s.post("key-1", `{"title":"Buy milk"}`)
+	time.Sleep(2 * ttl)
+	rec := s.post("key-1", `{"title":"Buy bread"}`)
+
+	if rec.Code != http.StatusCreated || rec.Header().Get(middleware.IdempotentReplayedHeader) != "" || s.runs.Load() != 2 {
+		t.Fatalf("request after the ttl: got %d replayed=%q after %d runs, want a new 201",
+			rec.Code, rec.Header().Get(middleware.IdempotentReplayedHeader), s.runs.Load())
+	}
+}
+
+func TestIdempotencyRejectsInvalidKeys(t *testing.T) {
+	s := newIdempotentServer(time.Hour)
+
+	for _, key := range []string{strings.Repeat("k", 256), "key\x01"} {
+		rec := s.post(key, `{}`)
+		assertProblem(t, rec, http.StatusBadRequest, "validation_failed")
+	}
+	if s.runs.Load() != 0 {
+		t.Fatalf("handler ran %d times, want 0", s.runs.Load())
+	}
+}
+
+// assertProblem fails t unless rec is a problem response with status and code
+func assertProblem(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	var p problem.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("body %s: %v", rec.Body, err)
+	}
+	if rec.Code != status || p.Code != code {
+		t.Fatalf("got %d %s, want %d %s", rec.Code, p.Code, status, code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != problem.ContentType {
+		t.Fatalf("Content-Type: got %q, want %q", got, problem.ContentType)
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit caps the size of request bodies. Reads beyond maxBytes fail,
+// which surfaces as a binding error in the handlers.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			c.Error(problem.New("body_too_large", fmt.Sprintf("Request body must not exceed %d bytes", maxBytes)))
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
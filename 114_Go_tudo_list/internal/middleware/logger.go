@@ -7,6 +7,8 @@ import (
 	"io"
 	"time"
 
+	"todo-app/internal/logging"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -153,3 +155,21 @@ func JSONRequestLogger() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AccessLog logs one line per request in gin's default format, skipping
+// requests below the current log level: successful responses are info, 4xx
+// responses warn and 5xx responses error
+func AccessLog() gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Skip: func(c *gin.Context) bool {
+			level := logging.LevelInfo
+			switch status := c.Writer.Status(); {
+			case status >= 500:
+				level = logging.LevelError
+			case status >= 400:
+				level = logging.LevelWarn
+			}
+			return !logging.Enabled(level)
+		},
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/openapi"
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateOpenAPI checks the requests and responses of documented routes
+// against doc. A request that breaks the document is answered with a
+// validation_failed problem before any handler runs; a response that breaks
+// it has already been sent, so it is logged as an error. It buffers every
+// body it checks and is meant for development only.
+//
+// It must run before Problems so the problem responses it checks are complete.
+func ValidateOpenAPI(doc *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op := doc.Operation(c.Request.Method, c.FullPath())
+		// A WebSocket upgrade hands the connection over, leaving no response to check
+		if op == nil || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		// Hand the handler the same body, and the same read error if it failed
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		if err == nil {
+			if violations := doc.ValidateRequest(op, c.Request, c.Param, body); len(violations) > 0 {
+				p := problem.New("validation_failed", "The request does not match the OpenAPI document")
+				for _, v := range violations {
+					if v.Keyword == "content" {
+						// The body cannot be checked at all in a media type the operation does not take
+						p = problem.New("unsupported_media_type", v.Message)
+						break
+					}
+					p.Errors = append(p.Errors, problem.FieldError{Field: v.Field, Code: v.Keyword, Message: v.Message})
+				}
+				writeProblem(c, p, p)
+				c.Abort()
+				return
+			}
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		violations := doc.ValidateResponse(op, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		for _, v := range violations {
+			logging.Errorf("OpenAPI: %s %s answered %d against the document (request %s): %s",
+				c.Request.Method, c.FullPath(), recorder.Status(), GetRequestID(c), v)
+		}
+	}
+}
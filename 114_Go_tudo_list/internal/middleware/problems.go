@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerFieldNames sync.Once
+
+// Problems turns the last error a handler recorded with c.Error into an
+// application/problem+json response. Handlers mark binding failures with
+// gin.ErrorTypeBind; 5xx problems are logged with the request ID since their
+// response hides the cause.
+func Problems() gin.HandlerFunc {
+	// Report validation errors under the JSON field names clients send
+	registerFieldNames.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterTagNameFunc(func(f reflect.StructField) string {
+				name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+				if name == "-" {
+					return ""
+				}
+				return name
+			})
+		}
+	})
+
+	return func(c *gin.Context) {
+		c.Next()
+		writeLastError(c)
+	}
+}
+
+// writeLastError sends the problem for the last error recorded with c.Error,
+// unless a response was already written. Middleware that needs the final
+// response before Problems runs calls it after c.Next.
+func writeLastError(c *gin.Context) {
+	last := c.Errors.Last()
+	if last == nil || c.Writer.Written() {
+		return
+	}
+
+	p := problem.From(last.Err)
+	if last.IsType(gin.ErrorTypeBind) {
+		p = problem.Binding(last.Err)
+	}
+	writeProblem(c, p, last.Err)
+}
+
+// writeProblem sends p for the current request. cause is logged for 5xx problems.
+func writeProblem(c *gin.Context, p *problem.Problem, cause error) {
+	// Copy so catalogued problems shared between requests are never modified
+	response := *p
+	response.Instance = c.Request.URL.Path
+	response.RequestID = GetRequestID(c)
+
+	if response.Status >= 500 {
+		logging.Errorf("%s %s failed (request %s): %v", c.Request.Method, c.Request.URL.Path, response.RequestID, cause)
+	}
+	if response.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(response.RetryAfter.Seconds())), 1)))
+	}
+	c.Header("Content-Type", problem.ContentType)
+	c.JSON(response.Status, &response)
+}
@@ -0,0 +1,135 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/middleware"
+	"todo-app/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Problems())
+	router.GET("/todos/:id", func(c *gin.Context) {
+		c.Error(fmt.Errorf("failed to get todo: %w", domain.ErrTodoNotFound))
+	})
+	router.GET("/unavailable", func(c *gin.Context) {
+		c.Error(&domain.UnavailableError{RetryAfter: 1500 * time.Millisecond})
+	})
+	router.GET("/broken", func(c *gin.Context) {
+		c.Error(errors.New("pq: password authentication failed for user postgres"))
+	})
+	router.POST("/todos", func(c *gin.Context) {
+		var req struct {
+			Title    string `json:"title" binding:"required"`
+			Priority string `json:"priority" binding:"omitempty,oneof=low medium high"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantDetail string
+		wantErrors []problem.FieldError
+		wantRetry  string
+	}{
+		{
+			name: "wrapped domain error", method: http.MethodGet, path: "/todos/42",
+			wantStatus: http.StatusNotFound, wantCode: "todo_not_found", wantDetail: "todo not found",
+		},
+		{
+			name: "retry after is rounded up", method: http.MethodGet, path: "/unavailable",
+			wantStatus: http.StatusServiceUnavailable, wantCode: "service_unavailable",
+			wantDetail: "Service temporarily unavailable, please retry later", wantRetry: "2",
+		},
+		{
+			name: "internal errors hide their cause", method: http.MethodGet, path: "/broken",
+			wantStatus: http.StatusInternalServerError, wantCode: "internal_error",
+		},
+		{
+			name: "binding errors per field under JSON names", method: http.MethodPost, path: "/todos",
+			body:       `{"priority":"urgent"}`,
+			wantStatus: http.StatusBadRequest, wantCode: "validation_failed", wantDetail: "The request has invalid fields",
+			wantErrors: []problem.FieldError{
+				{Field: "title", Code: "required", Message: "title is required"},
+				{Field: "priority", Code: "oneof", Message: "priority must be one of: low, medium, high"},
+			},
+		},
+		{
+			name: "malformed body", method: http.MethodPost, path: "/todos",
+			body:       `{"title":`,
+			wantStatus: http.StatusBadRequest, wantCode: "malformed_body", wantDetail: "Request body is not valid JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(middleware.RequestIDHeader, "req-123")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status: got %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != problem.ContentType {
+				t.Errorf("Content-Type: got %q, want %q", got, problem.ContentType)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetry {
+				t.Errorf("Retry-After: got %q, want %q", got, tt.wantRetry)
+			}
+
+			var p problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("body %s: %v", rec.Body, err)
+			}
+			if p.Code != tt.wantCode || p.Status != tt.wantStatus || p.Detail != tt.wantDetail {
+				t.Errorf("got %s %d %q, want %s %d %q", p.Code, p.Status, p.Detail, tt.wantCode, tt.wantStatus, tt.wantDetail)
+			}
+			if p.Instance != tt.path || p.RequestID != "req-123" {
+				t.Errorf("instance and request ID: got %q %q, want %q %q", p.Instance, p.RequestID, tt.path, "req-123")
+			}
+			if !reflect.DeepEqual(p.Errors, tt.wantErrors) {
+				t.Errorf("errors: got %+v, want %+v", p.Errors, tt.wantErrors)
+			}
+			if strings.Contains(rec.Body.String(), "password") {
+				t.Errorf("body exposes the cause: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestProblemsKeepsWrittenResponses(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.Problems())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "queued")
+		c.Error(errors.New("late failure"))
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "queued" {
+		t.Fatalf("got %d %q, want the handler's response", rec.Code, rec.Body)
+	}
+}
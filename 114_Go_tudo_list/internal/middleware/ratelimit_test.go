@@ -0,0 +1,178 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/middleware"
+	"todo-app/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordingStore answers every take with result and records the keys taken
+type recordingStore struct {
+	result ratelimit.Result
+	keys   []string
+}
+
+func (s *recordingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	s.keys = append(s.keys, key)
+	return s.result, nil
+}
+
+// newLimitedRouter serves GET / behind Authenticate and the api rate limit,
+// trusting X-Forwarded-For from 10.0.0.1 only
+func newLimitedRouter(t *testing.T, verifier *auth.Verifier, store ratelimit.Store) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := router.SetTrustedProxies([]string{"10.0.0.1"}); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	limit := func() (ratelimit.Limit, bool) { return ratelimit.Limit{Requests: 300, Window: time.Minute}, true }
+	router.Use(middleware.Problems())
+	router.GET("/", middleware.Authenticate(verifier), middleware.RateLimit("api", store, limit), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	verifier := auth.NewVerifier("test-secret", []string{"key-1"}, false, nil)
+	jwt, err := verifier.IssueToken("user-1", time.Hour)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"user from JWT", "192.0.2.1:1234", map[string]string{"Authorization": "Bearer " + jwt}, "api:user:user-1"},
+		{"user wins over API token", "192.0.2.1:1234", map[string]string{"Authorization": "Bearer " + jwt, "X-API-Key": "key-1"}, "api:user:user-1"},
+		{"API token", "192.0.2.1:1234", map[string]string{"X-API-Key": "key-1"}, "api:" + auth.Identity{Token: "key-1"}.Key()},
+		{"anonymous by IP", "192.0.2.1:1234", nil, "api:ip:192.0.2.1"},
+		{"X-Forwarded-For from a trusted proxy", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "api:ip:198.51.100.7"},
+		{"X-Forwarded-For from an untrusted client", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "api:ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &recordingStore{result: ratelimit.Result{Allowed: true}}
+			router := newLimitedRouter(t, verifier, store)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("status: got %d, want %d", rec.Code, http.StatusNoContent)
+			}
+			if len(store.keys) != 1 || store.keys[0] != tt.want {
+				t.Fatalf("keys: got %v, want [%s]", store.keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     ratelimit.Result
+		wantStatus int
+		want       map[string]string
+	}{
+		{
+			name:       "allowed",
+			result:     ratelimit.Result{Allowed: true, Remaining: 299, Reset: 200 * time.Millisecond},
+			wantStatus: http.StatusNoContent,
+			want: map[string]string{
+				"RateLimit-Limit":     "300",
+				"RateLimit-Remaining": "299",
+				"RateLimit-Reset":     "1",
+				"RateLimit-Policy":    "300;w=60",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:       "over the limit",
+			result:     ratelimit.Result{Allowed: false, Remaining: 0, RetryAfter: 1500 * time.Millisecond, Reset: time.Minute},
+			wantStatus: http.StatusTooManyRequests,
+			want: map[string]string{
+				"RateLimit-Limit":     "300",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"RateLimit-Policy":    "300;w=60",
+				"Retry-After":         "2",
+				"Content-Type":        "application/problem+json",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &recordingStore{result: tt.result}
+			router := newLimitedRouter(t, auth.NewVerifier("test-secret", nil, false, nil), store)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status: got %d, want %d", rec.Code, tt.wantStatus)
+			}
+			for name, want := range tt.want {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s: got %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitWithMemoryStore(t *testing.T) {
+	store := ratelimit.NewMemory()
+	router := gin.New()
+	limit := func() (ratelimit.Limit, bool) { return ratelimit.Limit{Requests: 2, Window: time.Minute}, true }
+	router.Use(middleware.Problems())
+	router.GET("/", middleware.RateLimit("api", store, limit), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for i, want := range []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != want {
+			t.Fatalf("request %d: got %d, want %d", i+1, rec.Code, want)
+		}
+		if want == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "30" {
+			t.Fatalf("Retry-After: got %q, want %q", rec.Header().Get("Retry-After"), "30")
+		}
+	}
+}
+
+func TestRateLimitAttemptsOnlyCountsCredentials(t *testing.T) {
+	store := &recordingStore{result: ratelimit.Result{Allowed: true}}
+	limit := func() (ratelimit.Limit, bool) { return ratelimit.Limit{Requests: 10, Window: time.Minute}, true }
+	router := gin.New()
+	router.GET("/", middleware.RateLimitAttempts("auth", store, limit), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, header := range []string{"", "Authorization", "X-API-Key"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if header != "" {
+			req.Header.Set(header, "guess")
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(store.keys) != 2 || store.keys[0] != "auth:ip:192.0.2.1" || store.keys[1] != "auth:ip:192.0.2.1" {
+		t.Fatalf("keys: got %v, want two takes from auth:ip:192.0.2.1", store.keys)
+	}
+}
@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Dialect holds the database-specific SQL used to track and lock migrations
+type Dialect struct {
+	// TableExists is a query returning one boolean row; the table name is its only argument
+	TableExists string
+	// CreateTable creates the schema_migrations table
+	CreateTable string
+	// InsertVersion records an applied migration from (version, name, checksum)
+	InsertVersion string
+	// DeleteVersion removes a reverted migration by version
+	DeleteVersion string
+	// Lock takes an exclusive migration lock for the session of conn and returns its release
+	Lock func(ctx context.Context, conn *sql.Conn) (unlock func(), err error)
+}
+
+// lockKey is the pg_advisory_lock key that serialises migrations across instances
+const lockKey = 7_114_001
+
+// Postgres is the PostgreSQL dialect. Migrations are serialised with a session advisory lock.
+var Postgres = Dialect{
+	TableExists: "SELECT to_regclass($1) IS NOT NULL",
+	CreateTable: `
+		CREATE TABLE schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`,
+	InsertVersion: "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+	DeleteVersion: "DELETE FROM schema_migrations WHERE version = $1",
+	Lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return nil, err
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+		}, nil
+	},
+}
+
+// SQLite is the SQLite dialect. SQLite serialises writers on the database file
+// itself, so each migration's transaction is the only lock needed.
+var SQLite = Dialect{
+	TableExists: "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?",
+	CreateTable: `
+		CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	InsertVersion: "INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)",
+	DeleteVersion: "DELETE FROM schema_migrations WHERE version = ?",
+	Lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+		return func() {}, nil
+	},
+}
+
+// mysqlLockName is the GET_LOCK name that serialises migrations across instances
+const mysqlLockName = "todo_app_schema_migrations"
+
+// MySQL is the MySQL/MariaDB dialect. Migrations are serialised with a named lock.
+// MySQL commits DDL implicitly, so a failed migration may leave its earlier statements applied.
+var MySQL = Dialect{
+	TableExists: "SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+	CreateTable: `
+		CREATE TABLE schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum VARCHAR(64) NOT NULL,
+			applied_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+		)`,
+	InsertVersion: "INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)",
+	DeleteVersion: "DELETE FROM schema_migrations WHERE version = ?",
+	Lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+		var acquired sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 3600)", mysqlLockName).Scan(&acquired); err != nil {
+			return nil, err
+		}
+		if acquired.Int64 != 1 {
+			return nil, fmt.Errorf("timed out waiting for migration lock %q", mysqlLockName)
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", mysqlLockName)
+		}, nil
+	},
+}
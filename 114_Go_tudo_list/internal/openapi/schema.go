@@ -0,0 +1,226 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Schema is the subset of JSON Schema 2020-12 used by the documents
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        Types              `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Enum        []any              `json:"enum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// AdditionalProperties is false to close an object, or the schema of
+	// every property not listed in Properties
+	AdditionalProperties any       `json:"additionalProperties,omitempty"`
+	Items                *Schema   `json:"items,omitempty"`
+	AnyOf                []*Schema `json:"anyOf,omitempty"`
+}
+
+// Types lists the JSON types a value may have. A single type is written as a string.
+type Types []string
+
+// MarshalJSON writes one type as a string and several as an array
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Ref refers to a schema in the components
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// String returns a string schema, restricted to values when any are given
+func String(values ...string) *Schema {
+	s := &Schema{Type: Types{"string"}}
+	for _, v := range values {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+// Integer returns an integer schema
+func Integer() *Schema {
+	return &Schema{Type: Types{"integer"}}
+}
+
+// Object returns a closed object schema with the given properties
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: Types{"object"}, Properties: properties, Required: required, AdditionalProperties: false}
+}
+
+// MapOf returns an object schema whose every property matches values
+func MapOf(values *Schema) *Schema {
+	return &Schema{Type: Types{"object"}, AdditionalProperties: values}
+}
+
+// ArrayOf returns an array schema
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: Types{"array"}, Items: items}
+}
+
+// Nullable returns a schema that also allows null
+func Nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{AnyOf: []*Schema{s, {Type: Types{"null"}}}}
+	}
+	n := *s
+	n.Type = append(append(Types{}, s.Type...), "null")
+	if n.Enum != nil {
+		n.Enum = append(append([]any{}, s.Enum...), nil)
+	}
+	return &n
+}
+
+// Describe sets the description of s and returns it
+func Describe(s *Schema, description string) *Schema {
+	s.Description = description
+	return s
+}
+
+var (
+	timeType = reflect.TypeFor[time.Time]()
+	uuidType = reflect.TypeFor[uuid.UUID]()
+)
+
+// Request adds the schema of a request body type to the components and
+// refers to it. Fields are required when their binding tag says so, and the
+// min, max and oneof binding rules become length limits and enums.
+func (d *Document) Request(v any) *Schema {
+	return d.reflect(reflect.TypeOf(v), false)
+}
+
+// Response adds the schema of a response type to the components and refers
+// to it. Fields are required unless their json tag has omitempty, and no
+// other fields are allowed, so drift between the code and the document shows
+// up when responses are validated.
+func (d *Document) Response(v any) *Schema {
+	return d.reflect(reflect.TypeOf(v), true)
+}
+
+func (d *Document) reflect(t reflect.Type, response bool) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: Types{"string"}, Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: Types{"string"}, Format: "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return Nullable(d.reflect(t.Elem(), response))
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.Slice, reflect.Array:
+		return ArrayOf(d.reflect(t.Elem(), response))
+	case reflect.Map:
+		return MapOf(d.reflect(t.Elem(), response))
+	case reflect.Struct:
+		return d.component(t, response)
+	}
+	// Interfaces and anything else may hold any JSON value
+	return &Schema{}
+}
+
+// component adds the schema of a named struct to the components once
+func (d *Document) component(t reflect.Type, response bool) *Schema {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	ref := Ref(string(name))
+	if _, ok := d.Components.Schemas[string(name)]; ok {
+		return ref
+	}
+
+	s := &Schema{Type: Types{"object"}, Properties: make(map[string]*Schema)}
+	// Register before the fields so recursive types refer to themselves
+	d.Components.Schemas[string(name)] = s
+	if response {
+		s.AdditionalProperties = false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		jsonName, options, _ := strings.Cut(tag, ",")
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+
+		ft, pointer := field.Type, field.Type.Kind() == reflect.Pointer
+		if pointer {
+			ft = ft.Elem()
+		}
+		fs := d.reflect(ft, response)
+		required := applyBinding(fs, field.Tag.Get("binding"))
+		if pointer {
+			fs = Nullable(fs)
+		}
+		s.Properties[jsonName] = fs
+
+		omitted := strings.Contains(options, "omitempty") || strings.Contains(options, "omitzero")
+		if required || (response && !omitted) {
+			s.Required = append(s.Required, jsonName)
+		}
+	}
+	return ref
+}
+
+// applyBinding turns the validator rules of a binding tag into schema
+// keywords and reports whether the field is required
+func applyBinding(s *Schema, tag string) (required bool) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			if len(s.Type) == 1 && s.Type[0] == "string" {
+				if name == "min" {
+					s.MinLength = &n
+				} else {
+					s.MaxLength = &n
+				}
+			} else if f := float64(n); name == "min" {
+				s.Minimum = &f
+			} else {
+				s.Maximum = &f
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		}
+	}
+	return required
+}
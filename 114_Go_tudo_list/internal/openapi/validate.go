@@ -0,0 +1,334 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Violation is one way a request or response departs from the document
+type Violation struct {
+	// Field is the JSON path of the value, or the name of the parameter
+	Field string
+	// Keyword is the schema keyword that failed, such as type or maxLength
+	Keyword string
+	Message string
+}
+
+// String formats the violation for logs
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + ": " + v.Message
+}
+
+// ValidateRequest checks the parameters and body of a request against op.
+// param returns the value of a path parameter. A body that is not valid JSON
+// is not reported, so the handler answers it as it would without validation;
+// nor are bodies in other media types, which the schemas do not describe.
+func (d *Document) ValidateRequest(op *Operation, r *http.Request, param func(string) string, body []byte) []Violation {
+	var violations []Violation
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		value, present := "", false
+		switch p.In {
+		case "path":
+			value = param(p.Name)
+			present = value != ""
+		case "query":
+			present = query.Has(p.Name)
+			value = query.Get(p.Name)
+		case "header":
+			value = r.Header.Get(p.Name)
+			present = value != ""
+		}
+		if !present {
+			if p.Required {
+				violations = append(violations, Violation{p.Name, "required", p.Name + " is required"})
+			}
+			continue
+		}
+		violations = append(violations, d.Validate(p.Schema, parameterValue(p.Schema, value), p.Name)...)
+	}
+
+	if op.RequestBody == nil {
+		return violations
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			violations = append(violations, Violation{"", "required", "request body is required"})
+		}
+		return violations
+	}
+	media, ok := op.RequestBody.Content[mediaType(r.Header.Get("Content-Type"))]
+	if !ok {
+		return append(violations, Violation{"", "content", "Content-Type must be one of: " + contentTypes(op.RequestBody.Content)})
+	}
+	if !isJSON(r.Header.Get("Content-Type")) {
+		return violations
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return violations
+	}
+	return append(violations, d.Validate(media.Schema, value, "")...)
+}
+
+// ValidateResponse checks a response of op against the response documented
+// for its status code
+func (d *Document) ValidateResponse(op *Operation, status int, contentType string, body []byte) []Violation {
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if response, ok = op.Responses["default"]; !ok {
+			return []Violation{{"", "status", fmt.Sprintf("status %d is not documented", status)}}
+		}
+	}
+	response = d.response(response)
+
+	if len(response.Content) == 0 {
+		if len(body) > 0 {
+			return []Violation{{"", "content", fmt.Sprintf("status %d is documented without a body", status)}}
+		}
+		return nil
+	}
+	media, ok := response.Content[mediaType(contentType)]
+	if !ok {
+		return []Violation{{"", "content", fmt.Sprintf("Content-Type %q is not one of: %s", contentType, contentTypes(response.Content))}}
+	}
+	if !isJSON(contentType) {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []Violation{{"", "content", fmt.Sprintf("body is not valid JSON: %v", err)}}
+	}
+	return d.Validate(media.Schema, value, "")
+}
+
+// Validate checks a decoded JSON value against s. field is the path of the
+// value, prefixed to the field of each violation.
+func (d *Document) Validate(s *Schema, value any, field string) []Violation {
+	if s.Ref != "" {
+		name, _ := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		resolved, ok := d.Components.Schemas[name]
+		if !ok {
+			return []Violation{{field, "$ref", "unknown schema " + s.Ref}}
+		}
+		return d.Validate(resolved, value, field)
+	}
+
+	if len(s.AnyOf) > 0 {
+		var first []Violation
+		for i, alternative := range s.AnyOf {
+			violations := d.Validate(alternative, value, field)
+			if len(violations) == 0 {
+				return nil
+			}
+			if i == 0 {
+				first = violations
+			}
+		}
+		// Report why the main alternative failed rather than every one of them
+		return first
+	}
+
+	name := fieldName(field)
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(value, t) }) {
+		return []Violation{{field, "type", fmt.Sprintf("%s must be %s, not %s", name, strings.Join(s.Type, " or "), typeOf(value))}}
+	}
+	if s.Enum != nil && !inEnum(s.Enum, value) {
+		return []Violation{{field, "enum", fmt.Sprintf("%s must be one of: %s", name, enumList(s.Enum))}}
+	}
+
+	var violations []Violation
+	switch v := value.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			violations = append(violations, Violation{field, "minLength", fmt.Sprintf("%s must be at least %d characters", name, *s.MinLength)})
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			violations = append(violations, Violation{field, "maxLength", fmt.Sprintf("%s must be at most %d characters", name, *s.MaxLength)})
+		}
+		if msg := checkFormat(s.Format, v); msg != "" {
+			violations = append(violations, Violation{field, "format", name + " must be " + msg})
+		}
+
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			violations = append(violations, Violation{field, "minimum", fmt.Sprintf("%s must be at least %g", name, *s.Minimum)})
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			violations = append(violations, Violation{field, "maximum", fmt.Sprintf("%s must be at most %g", name, *s.Maximum)})
+		}
+
+	case map[string]any:
+		for _, required := range s.Required {
+			if _, ok := v[required]; !ok {
+				violations = append(violations, Violation{join(field, required), "required", join(field, required) + " is required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if property, ok := s.Properties[key]; ok {
+				violations = append(violations, d.Validate(property, v[key], join(field, key))...)
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					violations = append(violations, Violation{join(field, key), "additionalProperties", join(field, key) + " is not an allowed field"})
+				}
+			case *Schema:
+				violations = append(violations, d.Validate(additional, v[key], join(field, key))...)
+			}
+		}
+
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				violations = append(violations, d.Validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i))...)
+			}
+		}
+	}
+	return violations
+}
+
+// hasType reports whether a decoded JSON value is of a JSON Schema type
+func hasType(value any, t string) bool {
+	switch v := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && v == math.Trunc(v))
+	case map[string]any:
+		return t == "object"
+	case []any:
+		return t == "array"
+	}
+	return false
+}
+
+// typeOf names the JSON type of a decoded value
+func typeOf(value any) string {
+	for _, t := range []string{"null", "boolean", "string", "number", "object", "array"} {
+		if hasType(value, t) {
+			return t
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// checkFormat returns what a string in format must be, or "" if v is valid
+func checkFormat(format, v string) string {
+	switch format {
+	case "uuid":
+		if _, err := uuid.Parse(v); err != nil {
+			return "a UUID"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return "an RFC 3339 timestamp such as 2024-01-31T09:00:00Z"
+		}
+	}
+	return ""
+}
+
+// parameterValue converts a path or query parameter to the JSON value its
+// schema expects, leaving it a string when it does not convert
+func parameterValue(s *Schema, value string) any {
+	for _, t := range s.Type {
+		switch t {
+		case "integer", "number":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b
+			}
+		}
+	}
+	return value
+}
+
+func mediaType(contentType string) string {
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return media
+}
+
+// isJSON reports whether contentType is JSON, whose bodies the schemas
+// describe; bodies in other media types are only checked for their type
+func isJSON(contentType string) bool {
+	media := mediaType(contentType)
+	return media == "application/json" || strings.HasSuffix(media, "+json")
+}
+
+func contentTypes(content map[string]MediaType) string {
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return strings.Join(types, ", ")
+}
+
+// inEnum reports whether value is one of values. Objects and arrays never
+// are, since enums only list scalars.
+func inEnum(values []any, value any) bool {
+	switch value.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return slices.Contains(values, value)
+}
+
+func enumList(values []any) string {
+	items := make([]string, len(values))
+	for i, v := range values {
+		if v == nil {
+			items[i] = "null"
+		} else {
+			items[i] = fmt.Sprint(v)
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// join appends a property to a JSON path
+func join(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
+
+// fieldName is how messages refer to the value at field
+func fieldName(field string) string {
+	if field == "" {
+		return "body"
+	}
+	return field
+}
@@ -0,0 +1,255 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// TypePrefix is the path under which each problem type is documented
+const TypePrefix = "/problems/"
+
+// Problem is an RFC 7807 problem details object, extended with a stable code,
+// the request ID and per-field validation errors
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	// RetryAfter is sent as the Retry-After header when set
+	RetryAfter time.Duration `json:"-"`
+}
+
+// FieldError describes one invalid input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error lets a Problem be recorded with gin's c.Error like any other error
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// Type documents one kind of problem at its type URI
+type Type struct {
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// types is the catalog of problem types, keyed by code
+var types = map[string]Type{
+	"validation_failed": {http.StatusBadRequest, "Validation failed",
+		"One or more fields are invalid; errors lists each field with a code and message."},
+	"malformed_body": {http.StatusBadRequest, "Malformed request body",
+		"The request body cannot be decoded in its Content-Type for this endpoint."},
+	"body_too_large": {http.StatusRequestEntityTooLarge, "Request body too large",
+		"The request body exceeds the server's size limit."},
+	"unsupported_media_type": {http.StatusUnsupportedMediaType, "Unsupported media type",
+		"The request body has a media type this endpoint does not accept; PATCH lists the accepted ones in Accept-Patch."},
+	"not_acceptable": {http.StatusNotAcceptable, "Not acceptable",
+		"The endpoint cannot respond in any media type the Accept header allows; the detail lists the ones it offers."},
+	"method_not_allowed": {http.StatusMethodNotAllowed, "Method not allowed",
+		"The endpoint does not take this request with the method used; Allow lists the methods that it does take."},
+	"unauthorized": {http.StatusUnauthorized, "Unauthorized",
+		"The request has no valid credentials: send a JWT as \"Authorization: Bearer <token>\" or an API token in X-API-Key."},
+	"todo_not_found": {http.StatusNotFound, "Todo not found",
+		"No todo exists with the given ID."},
+	"user_not_found": {http.StatusNotFound, "User not found",
+		"No user exists with the given email."},
+	"user_exists": {http.StatusConflict, "User already exists",
+		"A user with this email is already registered."},
+	"patch_test_failed": {http.StatusConflict, "Patch test failed",
+		"A test operation of the JSON Patch does not match the current todo, so no change was made; fetch it and retry."},
+	"patch_conflict": {http.StatusConflict, "Patch does not apply",
+		"The patch refers to a member the current todo does not have, so no change was made."},
+	"route_not_found": {http.StatusNotFound, "Route not found",
+		"No endpoint matches the request method and path."},
+	"idempotency_key_reused": {http.StatusUnprocessableEntity, "Idempotency key reused",
+		"The Idempotency-Key was already used for a different request; send a new key with each new request."},
+	"idempotency_key_in_use": {http.StatusConflict, "Request in progress",
+		"A request with this Idempotency-Key is still being handled; retry after the number of seconds in Retry-After."},
+	"rate_limited": {http.StatusTooManyRequests, "Too many requests",
+		"The client exceeded its rate limit; retry after the number of seconds in Retry-After."},
+	"service_unavailable": {http.StatusServiceUnavailable, "Service unavailable",
+		"Storage is temporarily unavailable; retry after the number of seconds in Retry-After."},
+	"internal_error": {http.StatusInternalServerError, "Internal server error",
+		"The server failed to handle the request. Quote the request_id when reporting it."},
+}
+
+// Lookup returns the documentation of a problem type
+func Lookup(code string) (Type, bool) {
+	t, ok := types[code]
+	return t, ok
+}
+
+// New returns a problem of a catalogued type
+func New(code, detail string) *Problem {
+	t, ok := types[code]
+	if !ok {
+		panic("problem: unknown problem type " + code)
+	}
+	return &Problem{
+		Type:   TypePrefix + code,
+		Title:  t.Title,
+		Status: t.Status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// From maps an error to the problem describing it. Domain errors keep their
+// code, binding errors list every invalid field, and anything unrecognised is
+// an internal error whose details are not exposed.
+func From(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		return fromDomain(domainErr)
+	}
+
+	var unavailable *domain.UnavailableError
+	if errors.As(err, &unavailable) {
+		p := New("service_unavailable", "Service temporarily unavailable, please retry later")
+		p.RetryAfter = unavailable.RetryAfter
+		return p
+	}
+
+	if p := fromBinding(err); p != nil {
+		return p
+	}
+	return New("internal_error", "")
+}
+
+// fromDomain maps a domain error by kind. Invalid input is always a
+// validation_failed problem so clients handle one shape for every bad field.
+func fromDomain(err *domain.Error) *Problem {
+	switch err.Kind {
+	case domain.KindInvalid:
+		p := New("validation_failed", "The request has invalid fields")
+		p.Errors = []FieldError{{Field: err.Field, Code: err.Code, Message: err.Message}}
+		return p
+	case domain.KindNotFound, domain.KindConflict:
+		if _, ok := types[err.Code]; ok {
+			return New(err.Code, err.Message)
+		}
+		status := http.StatusNotFound
+		if err.Kind == domain.KindConflict {
+			status = http.StatusConflict
+		}
+		return &Problem{
+			Type:   TypePrefix + err.Code,
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: err.Message,
+			Code:   err.Code,
+		}
+	}
+	return New("internal_error", "")
+}
+
+// fromBinding maps the errors gin's JSON binding returns, or nil for others
+func fromBinding(err error) *Problem {
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		p := New("validation_failed", "The request has invalid fields")
+		for _, fe := range invalid {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Message: fieldMessage(fe),
+			})
+		}
+		return p
+	}
+
+	var wrongType *json.UnmarshalTypeError
+	if errors.As(err, &wrongType) {
+		p := New("validation_failed", "The request has invalid fields")
+		p.Errors = []FieldError{{
+			Field:   wrongType.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("%s must be a %s, not %s", wrongType.Field, jsonType(wrongType.Type.String()), wrongType.Value),
+		}}
+		return p
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return New("body_too_large", fmt.Sprintf("Request body must not exceed %d bytes", tooLarge.Limit))
+	}
+
+	var syntax *json.SyntaxError
+	var badTime *time.ParseError
+	switch {
+	case errors.As(err, &syntax), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return New("malformed_body", "Request body is not valid JSON")
+	case errors.As(err, &badTime):
+		return New("malformed_body", "Dates must be RFC 3339 timestamps such as 2024-01-31T09:00:00Z")
+	}
+	return nil
+}
+
+// Binding returns the problem for an error from gin's binding that From does
+// not recognise, such as a missing body
+func Binding(err error) *Problem {
+	if p := From(err); p.Code != "internal_error" {
+		return p
+	}
+	return New("malformed_body", err.Error())
+}
+
+// fieldMessage phrases a validator failure for API clients
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters", fe.Field(), fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fe.Field(), strings.ReplaceAll(fe.Param(), " ", ", "))
+	}
+	return fmt.Sprintf("%s failed the %s check", fe.Field(), fe.Tag())
+}
+
+// jsonType names a Go type the way a JSON client knows it
+func jsonType(goType string) string {
+	switch {
+	case goType == "string" || strings.HasSuffix(goType, "*string"):
+		return "string"
+	case strings.Contains(goType, "bool"):
+		return "boolean"
+	case strings.Contains(goType, "int"), strings.Contains(goType, "float"):
+		return "number"
+	case strings.Contains(goType, "time.Time"):
+		return "RFC 3339 timestamp string"
+	}
+	return goType
+}
@@ -0,0 +1,220 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/problem"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestFrom(t *testing.T) {
+	var typeErr error
+	var v struct {
+		Completed bool `json:"completed"`
+	}
+	typeErr = json.Unmarshal([]byte(`{"completed":"yes"}`), &v)
+	var syntaxErr error = json.Unmarshal([]byte(`{"title":`+"\x00"), &v)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantStatus int
+		wantDetail string
+		wantErrors []problem.FieldError
+		wantRetry  time.Duration
+	}{
+		{
+			name:       "domain not found",
+			err:        domain.ErrTodoNotFound,
+			wantCode:   "todo_not_found",
+			wantStatus: http.StatusNotFound,
+			wantDetail: "todo not found",
+		},
+		{
+			name:       "wrapped domain not found",
+			err:        fmt.Errorf("failed to get todo: %w", domain.ErrTodoNotFound),
+			wantCode:   "todo_not_found",
+			wantStatus: http.StatusNotFound,
+			wantDetail: "todo not found",
+		},
+		{
+			name:       "wrapped domain conflict",
+			err:        fmt.Errorf("failed to patch todo: %w", domain.ErrPatchTestFailed),
+			wantCode:   "patch_test_failed",
+			wantStatus: http.StatusConflict,
+			wantDetail: "a test operation of the patch failed",
+		},
+		{
+			name:       "domain conflict outside the catalog",
+			err:        &domain.Error{Kind: domain.KindConflict, Code: "list_locked", Message: "the list is locked"},
+			wantCode:   "list_locked",
+			wantStatus: http.StatusConflict,
+			wantDetail: "the list is locked",
+		},
+		{
+			name:       "wrapped invalid field",
+			err:        fmt.Errorf("failed to create todo: %w", domain.ErrInvalidPriority),
+			wantCode:   "validation_failed",
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "The request has invalid fields",
+			wantErrors: []problem.FieldError{{Field: "priority", Code: "invalid_priority", Message: "priority must be one of: low, medium, high"}},
+		},
+		{
+			name:       "internal domain error is hidden",
+			err:        &domain.Error{Kind: domain.KindInternal, Code: "disk_full", Message: "disk full"},
+			wantCode:   "internal_error",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "wrapped unavailable storage",
+			err:        fmt.Errorf("failed to list todos: %w", &domain.UnavailableError{RetryAfter: 5 * time.Second}),
+			wantCode:   "service_unavailable",
+			wantStatus: http.StatusServiceUnavailable,
+			wantDetail: "Service temporarily unavailable, please retry later",
+			wantRetry:  5 * time.Second,
+		},
+		{
+			name:       "wrapped problem is kept",
+			err:        fmt.Errorf("limiter: %w", problem.New("rate_limited", "slow down")),
+			wantCode:   "rate_limited",
+			wantStatus: http.StatusTooManyRequests,
+			wantDetail: "slow down",
+		},
+		{
+			name:       "wrong JSON type",
+			err:        typeErr,
+			wantCode:   "validation_failed",
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "The request has invalid fields",
+			wantErrors: []problem.FieldError{{Field: "completed", Code: "type", Message: "completed must be a boolean, not string"}},
+		},
+		{
+			name:       "invalid JSON",
+			err:        syntaxErr,
+			wantCode:   "malformed_body",
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "Request body is not valid JSON",
+		},
+		{
+			name:       "empty body",
+			err:        io.EOF,
+			wantCode:   "malformed_body",
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "Request body is not valid JSON",
+		},
+		{
+			name:       "body too large",
+			err:        fmt.Errorf("read: %w", &http.MaxBytesError{Limit: 1024}),
+			wantCode:   "body_too_large",
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantDetail: "Request body must not exceed 1024 bytes",
+		},
+		{
+			name:       "unknown error is hidden",
+			err:        errors.New("connection reset by peer"),
+			wantCode:   "internal_error",
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := problem.From(tt.err)
+
+			if p.Code != tt.wantCode || p.Status != tt.wantStatus || p.Detail != tt.wantDetail {
+				t.Fatalf("got %s %d %q, want %s %d %q", p.Code, p.Status, p.Detail, tt.wantCode, tt.wantStatus, tt.wantDetail)
+			}
+			if p.Type != problem.TypePrefix+tt.wantCode {
+				t.Errorf("type: got %q, want %q", p.Type, problem.TypePrefix+tt.wantCode)
+			}
+			if !reflect.DeepEqual(p.Errors, tt.wantErrors) {
+				t.Errorf("errors: got %+v, want %+v", p.Errors, tt.wantErrors)
+			}
+			if p.RetryAfter != tt.wantRetry {
+				t.Errorf("retry after: got %s, want %s", p.RetryAfter, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestBindingListsEveryField(t *testing.T) {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		return name
+	})
+	req := struct {
+		Title    string `json:"title" validate:"required,max=200"`
+		Priority string `json:"priority" validate:"omitempty,oneof=low medium high"`
+	}{Priority: "urgent"}
+
+	p := problem.Binding(validate.Struct(req))
+
+	want := []problem.FieldError{
+		{Field: "title", Code: "required", Message: "title is required"},
+		{Field: "priority", Code: "oneof", Message: "priority must be one of: low, medium, high"},
+	}
+	if p.Code != "validation_failed" || !reflect.DeepEqual(p.Errors, want) {
+		t.Fatalf("got %s %+v, want validation_failed %+v", p.Code, p.Errors, want)
+	}
+}
+
+func TestBindingReportsUnknownErrorsAsMalformed(t *testing.T) {
+	p := problem.Binding(errors.New("invalid request"))
+
+	if p.Code != "malformed_body" || p.Status != http.StatusBadRequest || p.Detail != "invalid request" {
+		t.Fatalf("got %s %d %q, want malformed_body 400 %q", p.Code, p.Status, p.Detail, "invalid request")
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	codes := []string{
+		"validation_failed", "malformed_body", "body_too_large", "unsupported_media_type",
+		"not_acceptable", "method_not_allowed", "unauthorized", "todo_not_found",
+		"user_not_found", "user_exists", "patch_test_failed", "patch_conflict",
+		"route_not_found", "idempotency_key_reused", "idempotency_key_in_use",
+		"rate_limited", "service_unavailable", "internal_error",
+	}
+	for _, code := range codes {
+		typ, ok := problem.Lookup(code)
+		if !ok {
+			t.Errorf("%s: not in the catalog", code)
+			continue
+		}
+		if typ.Status < 400 || typ.Title == "" || typ.Description == "" {
+			t.Errorf("%s: incomplete entry %+v", code, typ)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("New with an unknown code did not panic")
+		}
+	}()
+	problem.New("no_such_problem", "")
+}
+
+func TestProblemJSON(t *testing.T) {
+	p := problem.New("todo_not_found", "todo not found")
+	p.Instance = "/api/v1/todos/42"
+	p.RequestID = "req-1"
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"type":"/problems/todo_not_found","title":"Todo not found","status":404,"detail":"todo not found","instance":"/api/v1/todos/42","code":"todo_not_found","request_id":"req-1"}`
+	if string(body) != want {
+		t.Fatalf("got %s, want %s", body, want)
+	}
+}
@@ -0,0 +1,40 @@
+// Package projection carries sparse fieldsets from the HTTP layer to the
+// repositories, which then read only the requested columns
+package projection
+
+import (
+	"context"
+	"slices"
+)
+
+type fieldsKey struct{}
+
+// WithFields marks ctx so that todo reads need only fields, named like the
+// columns and JSON members of a todo. Nil fields reads every column again.
+// Only reads may run with a projection: a todo read with one is incomplete
+// and must not be written back.
+func WithFields(ctx context.Context, fields []string) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// Fields returns the fields reads for ctx need, or nil if they need all of them
+func Fields(ctx context.Context) []string {
+	fields, _ := ctx.Value(fieldsKey{}).([]string)
+	return fields
+}
+
+// Columns returns the columns of all that reads for ctx need, in the order of
+// all. The id is always needed; every column is when ctx has no projection.
+func Columns(ctx context.Context, all []string) []string {
+	fields := Fields(ctx)
+	if fields == nil {
+		return all
+	}
+	var columns []string
+	for _, column := range all {
+		if column == "id" || slices.Contains(fields, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
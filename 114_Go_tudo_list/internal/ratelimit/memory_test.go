@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a fake clock moved forward by the tests
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func TestMemoryTake(t *testing.T) {
+	// Three tokens, refilling one per second
+	limit := Limit{Requests: 3, Window: 3 * time.Second}
+
+	type take struct {
+		after         time.Duration
+		key           string
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+		wantReset     time.Duration
+	}
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{"full bucket", []take{
+			{0, "a", true, 2, 0, time.Second},
+		}},
+		{"exhausted", []take{
+			{0, "a", true, 2, 0, time.Second},
+			{0, "a", true, 1, 0, 2 * time.Second},
+			{0, "a", true, 0, 0, 3 * time.Second},
+			{0, "a", false, 0, time.Second, 3 * time.Second},
+		}},
+		{"refill", []take{
+			{0, "a", true, 2, 0, time.Second},
+			{0, "a", true, 1, 0, 2 * time.Second},
+			{0, "a", true, 0, 0, 3 * time.Second},
+			{time.Second, "a", true, 0, 0, 3 * time.Second},
+			{500 * time.Millisecond, "a", false, 0, 500 * time.Millisecond, 2500 * time.Millisecond},
+			{500 * time.Millisecond, "a", true, 0, 0, 3 * time.Second},
+		}},
+		{"refill is capped at the limit", []take{
+			{0, "a", true, 2, 0, time.Second},
+			{time.Hour, "a", true, 2, 0, time.Second},
+		}},
+		{"keys have their own buckets", []take{
+			{0, "a", true, 2, 0, time.Second},
+			{0, "a", true, 1, 0, 2 * time.Second},
+			{0, "b", true, 2, 0, time.Second},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &clock{now: time.Unix(1_700_000_000, 0)}
+			m := NewMemory()
+			m.now = c.Now
+
+			for i, take := range tt.takes {
+				c.now = c.now.Add(take.after)
+				got, err := m.Take(context.Background(), take.key, limit)
+				if err != nil {
+					t.Fatalf("take %d: %v", i, err)
+				}
+				want := Result{Allowed: take.wantAllowed, Remaining: take.wantRemaining, RetryAfter: take.wantRetry, Reset: take.wantReset}
+				if !closeEnough(got, want) {
+					t.Fatalf("take %d: got %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemorySweepsFullBuckets(t *testing.T) {
+	c := &clock{now: time.Unix(1_700_000_000, 0)}
+	m := NewMemory()
+	m.now = c.Now
+	m.lastSweep = c.now
+	limit := Limit{Requests: 10, Window: time.Second}
+
+	m.Take(context.Background(), "idle", limit)
+	c.now = c.now.Add(sweepInterval)
+	m.Take(context.Background(), "busy", limit)
+
+	if _, ok := m.buckets["idle"]; ok {
+		t.Fatal("full bucket was not swept")
+	}
+	if _, ok := m.buckets["busy"]; !ok {
+		t.Fatal("bucket in use was swept")
+	}
+}
+
+// closeEnough compares results allowing for float rounding in the durations
+func closeEnough(got, want Result) bool {
+	near := func(a, b time.Duration) bool { return (a - b).Abs() < time.Millisecond }
+	return got.Allowed == want.Allowed && got.Remaining == want.Remaining &&
+		near(got.RetryAfter, want.RetryAfter) && near(got.Reset, want.Reset)
+}
@@ -0,0 +1,60 @@
+// Package ratelimit implements token-bucket rate limiting with buckets kept
+// in process memory or in Redis so limits hold across instances
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket holding up to Requests tokens that refills
+// continuously at Requests per Window. Each request takes one token.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// rate returns the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long until the next token when the request was not allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps token buckets by key
+type Store interface {
+	// Take takes a token from the bucket for key, creating a full bucket if there is none
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// refill returns the tokens in a bucket that held tokens elapsed ago
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	return math.Min(float64(limit.Requests), tokens+math.Max(elapsed.Seconds(), 0)*limit.rate())
+}
+
+// result describes a bucket left holding tokens after a take
+func result(allowed bool, tokens float64, limit Limit) Result {
+	r := Result{
+		Allowed:   allowed,
+		Remaining: int(tokens),
+		Reset:     seconds((float64(limit.Requests) - tokens) / limit.rate()),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and takes from the bucket in KEYS[1] atomically, using the
+// Redis server clock so instances with skewed clocks agree. It returns whether
+// the take was allowed and the tokens left. The key expires once the bucket is full.
+var takeScript = redis.NewScript(`
+local requests = tonumber(ARGV[1])
+local window_ms = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or requests
+local ts = tonumber(state[2]) or now
+tokens = math.min(requests, tokens + math.max(0, now - ts) * requests / window_ms)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((requests - tokens) * window_ms / requests) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis is a Store that keeps buckets in Redis, shared by every instance
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis creates a Redis store. Keys are prefixed with "ratelimit:".
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{
+		client: client,
+		prefix: "ratelimit:",
+	}
+}
+
+// Take takes a token from the bucket for key
+func (r *Redis) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	reply, err := takeScript.Run(ctx, r.client, []string{r.prefix + key}, limit.Requests, limit.Window.Milliseconds()).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take rate limit token: %v", err)
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	text, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected rate limit tokens %q", text)
+	}
+	return result(allowed == 1, tokens, limit), nil
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisEnv names the variable holding the address of a disposable Redis
+const redisEnv = "TEST_REDIS_ADDR"
+
+func TestRedisTake(t *testing.T) {
+	addr := os.Getenv(redisEnv)
+	if addr == "" {
+		t.Skipf("%s is not set", redisEnv)
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	r := NewRedis(client)
+	ctx := context.Background()
+
+	key := "test:" + uuid.NewString()
+	defer client.Del(ctx, r.prefix+key)
+	limit := Limit{Requests: 2, Window: time.Minute}
+
+	for i, wantRemaining := range []int{1, 0} {
+		got, err := r.Take(ctx, key, limit)
+		if err != nil {
+			t.Fatalf("take %d: %v", i, err)
+		}
+		if !got.Allowed || got.Remaining != wantRemaining {
+			t.Fatalf("take %d: got %+v, want allowed with %d remaining", i, got, wantRemaining)
+		}
+	}
+
+	got, err := r.Take(ctx, key, limit)
+	if err != nil {
+		t.Fatalf("take: %v", err)
+	}
+	// One token refills every 30s; the server clock has moved on a little
+	if got.Allowed || got.RetryAfter <= 29*time.Second || got.RetryAfter > 30*time.Second {
+		t.Fatalf("got %+v, want refused with a retry after about 30s", got)
+	}
+}
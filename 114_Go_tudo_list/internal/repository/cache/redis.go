@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"todo-app/internal/logging"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces cache keys in a Redis instance shared with other apps
+const keyPrefix = "todo-app:cache:"
+
+// Redis is a Store backed by a Redis server shared by all API instances
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a Redis store using client
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Get returns the value for key if it is present
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key for ttl
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, keyPrefix+key, value, ttl).Err()
+}
+
+// Delete removes keys from the cache
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = keyPrefix + key
+	}
+	return r.client.Del(ctx, prefixed...).Err()
+}
+
+// RedisBus is a Bus over a Redis pub/sub channel
+type RedisBus struct {
+	client  *redis.Client
+	channel string
+	// source identifies this instance so it can ignore its own messages
+	source string
+}
+
+type invalidation struct {
+	Source string   `json:"source"`
+	Keys   []string `json:"keys"`
+}
+
+// NewRedisBus creates a Bus publishing on channel
+func NewRedisBus(client *redis.Client, channel string) *RedisBus {
+	return &RedisBus{
+		client:  client,
+		channel: channel,
+		source:  uuid.NewString(),
+	}
+}
+
+// Publish announces that keys were invalidated
+func (b *RedisBus) Publish(ctx context.Context, keys []string) error {
+	data, err := json.Marshal(invalidation{Source: b.source, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+// Subscribe calls fn for every invalidation published by another instance.
+// The subscription is re-established automatically if the connection drops.
+func (b *RedisBus) Subscribe(ctx context.Context, fn func(keys []string)) error {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	// Wait for the subscription to be confirmed so connection errors surface here
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %v", b.channel, err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				logging.Warnf("Ignoring malformed cache invalidation: %v", err)
+				continue
+			}
+			if inv.Source != b.source {
+				fn(inv.Keys)
+			}
+		}
+	}
+}
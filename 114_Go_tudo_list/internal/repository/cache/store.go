@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store is a key/value cache backend. Values are opaque bytes.
+type Store interface {
+	// Get returns the value for key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Bus broadcasts invalidated keys between API instances
+type Bus interface {
+	Publish(ctx context.Context, keys []string) error
+	// Subscribe calls fn with the keys invalidated by other instances until ctx is cancelled
+	Subscribe(ctx context.Context, fn func(keys []string)) error
+}
@@ -0,0 +1,317 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"todo-app/internal/consistency"
+	"todo-app/internal/domain"
+	"todo-app/internal/logging"
+	"todo-app/internal/projection"
+	"todo-app/internal/telemetry"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// stats is published at /debug/vars as "todo_cache"
+var stats = telemetry.NewMetrics("todo_cache")
+
+const allKey = "todos:all"
+
+// generationStripes is the number of invalidation counters keys are spread over
+const generationStripes = 256
+
+// TodoRepository is a read-through caching decorator for domain.TodoRepository.
+// GetByID, GetByIDs, GetAll and GetByStatus are served from the store; writes go to the
+// wrapped repository and then invalidate the affected keys locally and, if a
+// Bus is set, on every other instance.
+type TodoRepository struct {
+	next  domain.TodoRepository
+	store Store
+	bus   Bus
+	ttl   time.Duration
+	group singleflight.Group
+	// generations counts the invalidations of the keys hashed to each stripe,
+	// so a load that overlapped one does not leave its result in the store
+	generations [generationStripes]atomic.Uint64
+}
+
+// NewTodoRepository wraps next with a cache. bus may be nil for a single instance.
+func NewTodoRepository(next domain.TodoRepository, store Store, ttl time.Duration, bus Bus) *TodoRepository {
+	return &TodoRepository{
+		next:  next,
+		store: store,
+		bus:   bus,
+		ttl:   ttl,
+	}
+}
+
+// Create creates a todo and invalidates the cached lists
+func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) error {
+	if err := r.next.Create(ctx, todo); err != nil {
+		return err
+	}
+	r.invalidate(ctx, listKeys()...)
+	return nil
+}
+
+// CreateMany creates todos in bulk and invalidates the cached lists
+func (r *TodoRepository) CreateMany(ctx context.Context, todos []*domain.Todo) error {
+	if err := r.next.CreateMany(ctx, todos); err != nil {
+		return err
+	}
+	r.invalidate(ctx, listKeys()...)
+	return nil
+}
+
+// GetByID retrieves a todo, from the cache when possible
+func (r *TodoRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	return readThrough(r, ctx, todoKey(id), func(ctx context.Context) (*domain.Todo, error) {
+		return r.next.GetByID(ctx, id)
+	})
+}
+
+// GetByIDs retrieves todos, taking those it can from the cache and loading
+// the rest in one batch, which it then caches
+func (r *TodoRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Todo, error) {
+	if consistency.PrimaryPinned(ctx) {
+		return r.next.GetByIDs(ctx, ids)
+	}
+
+	var todos []*domain.Todo
+	var missing []uuid.UUID
+	for _, id := range ids {
+		key := todoKey(id)
+		data, ok, err := r.store.Get(ctx, key)
+		if err != nil {
+			stats.Add("errors", 1)
+			logging.Warnf("Cache get %s failed: %v", key, err)
+		}
+		var todo domain.Todo
+		if ok && json.Unmarshal(data, &todo) == nil {
+			stats.Add("hits", 1)
+			todos = append(todos, &todo)
+			continue
+		}
+		stats.Add("misses", 1)
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return todos, nil
+	}
+
+	generations := make(map[uuid.UUID]uint64, len(missing))
+	for _, id := range missing {
+		generations[id] = r.generation(todoKey(id))
+	}
+	loaded, err := r.next.GetByIDs(fillContext(ctx), missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range loaded {
+		data, err := json.Marshal(todo)
+		if err != nil {
+			return nil, err
+		}
+		r.fill(ctx, todoKey(todo.ID), data, generations[todo.ID])
+	}
+	return append(todos, loaded...), nil
+}
+
+// GetAll retrieves all todos, from the cache when possible
+func (r *TodoRepository) GetAll(ctx context.Context) ([]*domain.Todo, error) {
+	return readThrough(r, ctx, allKey, r.next.GetAll)
+}
+
+// Update updates a todo and invalidates it and the cached lists
+func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) error {
+	if err := r.next.Update(ctx, todo); err != nil {
+		return err
+	}
+	r.invalidate(ctx, append(listKeys(), todoKey(todo.ID))...)
+	return nil
+}
+
+// ToggleCompleted toggles a todo and invalidates it and the cached lists
+func (r *TodoRepository) ToggleCompleted(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	todo, err := r.next.ToggleCompleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, append(listKeys(), todoKey(id))...)
+	return todo, nil
+}
+
+// Delete deletes a todo and invalidates it and the cached lists
+func (r *TodoRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, append(listKeys(), todoKey(id))...)
+	return nil
+}
+
+// GetByStatus retrieves todos by completion status, from the cache when possible
+func (r *TodoRepository) GetByStatus(ctx context.Context, completed bool) ([]*domain.Todo, error) {
+	return readThrough(r, ctx, statusKey(completed), func(ctx context.Context) ([]*domain.Todo, error) {
+		return r.next.GetByStatus(ctx, completed)
+	})
+}
+
+// Search is not cached: queries are too varied to hit often
+func (r *TodoRepository) Search(ctx context.Context, query string) ([]*domain.Todo, error) {
+	return r.next.Search(ctx, query)
+}
+
+// Listen applies invalidations published by other instances until ctx is cancelled
+func (r *TodoRepository) Listen(ctx context.Context) error {
+	return r.bus.Subscribe(ctx, func(keys []string) {
+		r.forget(context.Background(), keys)
+		stats.Add("remote_invalidations", 1)
+	})
+}
+
+// readThrough returns the cached value for key or loads and caches it.
+// Concurrent misses for the same key share one load. Every caller decodes its
+// own copy so results can be modified freely. Entries always hold every
+// column, so a projected read is served from them and loads full rows on a miss.
+func readThrough[T any](r *TodoRepository, ctx context.Context, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	// Read-your-writes requests bypass the cache: an entry may have been
+	// loaded from a replica that has not caught up with the write yet
+	if consistency.PrimaryPinned(ctx) {
+		return load(ctx)
+	}
+
+	var value T
+	data, ok, err := r.store.Get(ctx, key)
+	if err != nil {
+		stats.Add("errors", 1)
+		logging.Warnf("Cache get %s failed: %v", key, err)
+	}
+	if ok {
+		if err := json.Unmarshal(data, &value); err == nil {
+			stats.Add("hits", 1)
+			return value, nil
+		}
+		stats.Add("errors", 1)
+	}
+	stats.Add("misses", 1)
+
+	result, err, shared := r.group.Do(key, func() (any, error) {
+		// Detach from the first caller so its cancellation does not fail the
+		// others
+		ctx := context.WithoutCancel(ctx)
+		generation := r.generation(key)
+		loaded, err := load(fillContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(loaded)
+		if err != nil {
+			return nil, err
+		}
+		r.fill(ctx, key, data, generation)
+		return data, nil
+	})
+	if shared {
+		stats.Add("shared_loads", 1)
+	}
+	if err != nil {
+		return value, err
+	}
+
+	if err := json.Unmarshal(result.([]byte), &value); err != nil {
+		return value, err
+	}
+	return value, nil
+}
+
+// fillContext returns the context of a load filling the cache. It reads every
+// column whatever the caller projected, and reads from the primary: the cache
+// is shared by every reader, so it must not hold a row from a replica that has
+// not caught up with a write yet.
+func fillContext(ctx context.Context) context.Context {
+	return consistency.PinPrimary(projection.WithFields(ctx, nil))
+}
+
+// fill stores data loaded for key while it was at generation. If key was
+// invalidated since, the load may have read the row before the write that
+// invalidated it, so the entry is not kept: it is deleted again when the
+// invalidation raced with the set.
+func (r *TodoRepository) fill(ctx context.Context, key string, data []byte, generation uint64) {
+	if r.generation(key) != generation {
+		stats.Add("discarded_fills", 1)
+		return
+	}
+	if err := r.store.Set(ctx, key, data, r.ttl); err != nil {
+		stats.Add("errors", 1)
+		logging.Warnf("Cache set %s failed: %v", key, err)
+		return
+	}
+	// forget bumps the generation before deleting, so either this sees the
+	// bump or the delete of the invalidation comes after the set
+	if r.generation(key) != generation {
+		stats.Add("discarded_fills", 1)
+		if err := r.store.Delete(ctx, key); err != nil {
+			stats.Add("errors", 1)
+			logging.Warnf("Cache delete %s failed: %v", key, err)
+		}
+	}
+}
+
+// generation returns the invalidation counter of the stripe key hashes to
+func (r *TodoRepository) generation(key string) uint64 {
+	return r.stripe(key).Load()
+}
+
+func (r *TodoRepository) stripe(key string) *atomic.Uint64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &r.generations[h.Sum32()%generationStripes]
+}
+
+// invalidate drops keys here and tells the other instances to do the same.
+// Failures are logged rather than returned because the write itself succeeded;
+// stale entries then live at most until their TTL.
+func (r *TodoRepository) invalidate(ctx context.Context, keys ...string) {
+	r.forget(ctx, keys)
+	stats.Add("invalidations", 1)
+
+	if r.bus != nil {
+		if err := r.bus.Publish(ctx, keys); err != nil {
+			stats.Add("errors", 1)
+			logging.Warnf("Cache invalidation publish failed: %v", err)
+		}
+	}
+}
+
+// forget removes keys from the store and from in-flight loads so later reads
+// reload them. The loads already running are not stopped, but bumping the
+// generation of their keys keeps them from storing what they read.
+func (r *TodoRepository) forget(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		r.stripe(key).Add(1)
+		r.group.Forget(key)
+	}
+	if err := r.store.Delete(ctx, keys...); err != nil {
+		stats.Add("errors", 1)
+		logging.Warnf("Cache delete failed: %v", err)
+	}
+}
+
+func todoKey(id uuid.UUID) string {
+	return "todo:" + id.String()
+}
+
+func statusKey(completed bool) string {
+	return "todos:completed:" + strconv.FormatBool(completed)
+}
+
+func listKeys() []string {
+	return []string{allKey, statusKey(true), statusKey(false)}
+}
@@ -0,0 +1,148 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-app/internal/consistency"
+	"todo-app/internal/domain"
+	"todo-app/internal/repository/cache"
+	"todo-app/internal/repository/memory"
+	"todo-app/internal/repository/repotest"
+
+	"github.com/google/uuid"
+)
+
+func TestTodoRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) domain.TodoRepository {
+		return cache.NewTodoRepository(memory.NewTodoRepository(), cache.NewLRU(100), time.Minute, nil)
+	})
+}
+
+// TestFillsReadPrimary checks that misses are loaded from the primary, so a
+// lagging replica never fills the cache shared by every reader
+func TestFillsReadPrimary(t *testing.T) {
+	ctx := context.Background()
+	backend := &recordingRepo{TodoRepository: memory.NewTodoRepository()}
+	repo := cache.NewTodoRepository(backend, cache.NewLRU(100), time.Minute, nil)
+
+	todo := &domain.Todo{Title: "Buy milk", Priority: "medium"}
+	if err := repo.Create(ctx, todo); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, todo.ID); err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if _, err := repo.GetAll(ctx); err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if err := repo.Delete(ctx, todo.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByIDs(ctx, []uuid.UUID{todo.ID}); err != nil {
+		t.Fatalf("GetByIDs: %v", err)
+	}
+
+	if backend.reads != 3 || backend.replicaReads != 0 {
+		t.Fatalf("got %d reads, %d from a replica; want 3 from the primary", backend.reads, backend.replicaReads)
+	}
+}
+
+// TestInvalidationDiscardsInFlightFill checks that a load which read a todo
+// before it was updated does not store the old version after the update
+// invalidated it
+func TestInvalidationDiscardsInFlightFill(t *testing.T) {
+	ctx := context.Background()
+	backend := &recordingRepo{TodoRepository: memory.NewTodoRepository()}
+	repo := cache.NewTodoRepository(backend, cache.NewLRU(100), time.Minute, nil)
+
+	todo := &domain.Todo{Title: "Old title", Priority: "medium"}
+	if err := repo.Create(ctx, todo); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// The first read loads the old title, then waits while the todo is updated
+	backend.pause(todo.ID)
+	done := make(chan error)
+	go func() {
+		_, err := repo.GetByID(ctx, todo.ID)
+		done <- err
+	}()
+	<-backend.loaded
+
+	updated := *todo
+	updated.Title = "New title"
+	if err := repo.Update(ctx, &updated); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	close(backend.resume)
+	if err := <-done; err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, todo.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Title != "New title" {
+		t.Fatalf("title: got %q, want %q; the load that overlapped the update was cached", got.Title, "New title")
+	}
+}
+
+// recordingRepo counts the reads reaching storage and can hold the first read
+// of a todo after it has loaded the row
+type recordingRepo struct {
+	domain.TodoRepository
+
+	mu           sync.Mutex
+	reads        int
+	replicaReads int
+	paused       uuid.UUID
+	loaded       chan struct{}
+	resume       chan struct{}
+}
+
+// pause holds the next GetByID of id after it has read the row, until resume is closed
+func (r *recordingRepo) pause(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused, r.loaded, r.resume = id, make(chan struct{}), make(chan struct{})
+}
+
+func (r *recordingRepo) record(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	if !consistency.PrimaryPinned(ctx) {
+		r.replicaReads++
+	}
+}
+
+func (r *recordingRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	r.record(ctx)
+	todo, err := r.TodoRepository.GetByID(ctx, id)
+
+	r.mu.Lock()
+	hold := r.paused == id
+	if hold {
+		r.paused = uuid.Nil
+	}
+	r.mu.Unlock()
+	if hold {
+		close(r.loaded)
+		<-r.resume
+	}
+	return todo, err
+}
+
+func (r *recordingRepo) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Todo, error) {
+	r.record(ctx)
+	return r.TodoRepository.GetByIDs(ctx, ids)
+}
+
+func (r *recordingRepo) GetAll(ctx context.Context) ([]*domain.Todo, error) {
+	r.record(ctx)
+	return r.TodoRepository.GetAll(ctx)
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+
+	"todo-app/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// UnitOfWork wraps a domain.UnitOfWork so that the todos written inside a unit
+// of work are invalidated once it commits. Reads inside a unit of work go
+// straight to its transaction and are never cached.
+type UnitOfWork struct {
+	next  domain.UnitOfWork
+	todos *TodoRepository
+}
+
+// NewUnitOfWork wraps next, invalidating entries of the cache behind todos
+func NewUnitOfWork(next domain.UnitOfWork, todos *TodoRepository) *UnitOfWork {
+	return &UnitOfWork{
+		next:  next,
+		todos: todos,
+	}
+}
+
+// Do runs fn through the wrapped unit of work and invalidates what it wrote after a commit
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	written := &writeTracker{ids: make(map[uuid.UUID]bool)}
+	err := u.next.Do(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		written.TodoRepository = repos.Todos
+		repos.Todos = written
+		return fn(ctx, repos)
+	})
+	if err != nil || !written.any {
+		return err
+	}
+
+	keys := listKeys()
+	for id := range written.ids {
+		keys = append(keys, todoKey(id))
+	}
+	u.todos.invalidate(ctx, keys...)
+	return nil
+}
+
+// writeTracker records which todos are written through it
+type writeTracker struct {
+	domain.TodoRepository
+	ids map[uuid.UUID]bool
+	any bool
+}
+
+func (w *writeTracker) Create(ctx context.Context, todo *domain.Todo) error {
+	w.any = true
+	return w.TodoRepository.Create(ctx, todo)
+}
+
+func (w *writeTracker) CreateMany(ctx context.Context, todos []*domain.Todo) error {
+	w.any = true
+	return w.TodoRepository.CreateMany(ctx, todos)
+}
+
+func (w *writeTracker) Update(ctx context.Context, todo *domain.Todo) error {
+	w.any, w.ids[todo.ID] = true, true
+	return w.TodoRepository.Update(ctx, todo)
+}
+
+func (w *writeTracker) ToggleCompleted(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	w.any, w.ids[id] = true, true
+	return w.TodoRepository.ToggleCompleted(ctx, id)
+}
+
+func (w *writeTracker) Delete(ctx context.Context, id uuid.UUID) error {
+	w.any, w.ids[id] = true, true
+	return w.TodoRepository.Delete(ctx, id)
+}
@@ -0,0 +1,90 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	gocql "github.com/apache/cassandra-gocql-driver/v2"
+)
+
+// Connect opens a session on keyspace, creating the keyspace with SimpleStrategy
+// and the given replication factor if it does not exist yet
+func Connect(hosts []string, keyspace string, replicationFactor int, timeout time.Duration) (*gocql.Session, error) {
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Timeout = timeout
+	cluster.ConnectTimeout = timeout
+
+	// The keyspace must exist before a session can be bound to it
+	admin, err := cluster.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to cassandra: %v", err)
+	}
+	err = admin.Query(fmt.Sprintf(
+		`CREATE KEYSPACE IF NOT EXISTS %s WITH replication = {'class': 'SimpleStrategy', 'replication_factor': %d}`,
+		keyspace, replicationFactor,
+	)).Exec()
+	admin.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keyspace %s: %v", keyspace, err)
+	}
+
+	cluster.Keyspace = keyspace
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to keyspace %s: %v", keyspace, err)
+	}
+	return session, nil
+}
+
+// Migrate runs every statement of the *.cql files in source, in file name order.
+// The statements must be idempotent (IF NOT EXISTS) because there is no version table.
+func Migrate(ctx context.Context, session *gocql.Session, source fs.FS) error {
+	files, err := fs.Glob(source, "*.cql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, name := range files {
+		data, err := fs.ReadFile(source, name)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range splitStatements(string(data)) {
+			if err := session.Query(stmt).ExecContext(ctx); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Ping checks that the cluster answers queries
+func Ping(session *gocql.Session) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var version string
+		return session.Query("SELECT release_version FROM system.local").ScanContext(ctx, &version)
+	}
+}
+
+// splitStatements splits a CQL script on semicolons, dropping -- comments and blank statements
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
@@ -0,0 +1,426 @@
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/telemetry"
+
+	gocql "github.com/apache/cassandra-gocql-driver/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("todo-app/internal/repository/cassandra")
+
+const (
+	insertByID = `
+		INSERT INTO todos_by_id (id, owner, title, description, completed, priority, due_date, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`
+	insertByStatus = `
+		INSERT INTO todos_by_user_status_created (user_id, completed, created_at, id, title, description, priority, due_date, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`
+	deleteByID     = `DELETE FROM todos_by_id WHERE id = ?`
+	deleteByStatus = `DELETE FROM todos_by_user_status_created WHERE user_id = ? AND completed = ? AND created_at = ? AND id = ?`
+)
+
+// Options configures a TodoRepository
+type Options struct {
+	// Owner is the user_id partition todos are stored under
+	Owner            string
+	ReadConsistency  gocql.Consistency
+	WriteConsistency gocql.Consistency
+	// CompletedTTL expires completed todos after this long; zero keeps them forever
+	CompletedTTL time.Duration
+}
+
+// TodoRepository implements the TodoRepository interface for Apache Cassandra.
+// Every todo is written to todos_by_id and todos_by_user_status_created in one
+// logged batch so the two query tables never diverge.
+type TodoRepository struct {
+	session *gocql.Session
+	opts    Options
+}
+
+// NewTodoRepository creates a new TodoRepository
+func NewTodoRepository(session *gocql.Session, opts Options) *TodoRepository {
+	return &TodoRepository{
+		session: session,
+		opts:    opts,
+	}
+}
+
+// Create creates a new todo in the database
+func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) (err error) {
+	ctx, span := startSpan(ctx, "BATCH", "todos_by_id", insertByID)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo.ID = uuid.New()
+	// Cassandra timestamps have millisecond precision
+	todo.CreatedAt = time.Now().Truncate(time.Millisecond)
+	todo.UpdatedAt = todo.CreatedAt
+
+	batch := r.session.Batch(gocql.LoggedBatch).Consistency(r.opts.WriteConsistency)
+	r.insert(batch, todo)
+	if err = batch.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to create todo: %v", err)
+	}
+
+	return nil
+}
+
+// CreateMany creates todos one logged batch at a time. Cassandra has no
+// multi-partition transactions, so a failure leaves the earlier todos stored.
+func (r *TodoRepository) CreateMany(ctx context.Context, todos []*domain.Todo) error {
+	for _, todo := range todos {
+		if err := r.Create(ctx, todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByID retrieves a todo by its ID
+func (r *TodoRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *domain.Todo, err error) {
+	query := `
+		SELECT id, owner, title, description, completed, priority, due_date, created_at, updated_at
+		FROM todos_by_id
+		WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos_by_id", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo, _, err := r.get(ctx, query, id)
+	return todo, err
+}
+
+// GetByIDs retrieves the todos with the given IDs in one query over their partitions
+func (r *TodoRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (_ []*domain.Todo, err error) {
+	query := `
+		SELECT id, title, description, completed, priority, due_date, created_at, updated_at
+		FROM todos_by_id
+		WHERE id IN ?`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos_by_id", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	keys := make([]gocql.UUID, len(ids))
+	for i, id := range ids {
+		keys[i] = gocql.UUID(id)
+	}
+	iter := r.session.Query(query, keys).
+		Consistency(r.opts.ReadConsistency).
+		IterContext(ctx)
+
+	todos, err := collect(iter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %v", err)
+	}
+	return todos, nil
+}
+
+// GetAll retrieves all todos from the database
+func (r *TodoRepository) GetAll(ctx context.Context) (_ []*domain.Todo, err error) {
+	pending, err := r.GetByStatus(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	completed, err := r.GetByStatus(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := append(pending, completed...)
+	sort.SliceStable(todos, func(i, j int) bool {
+		return todos[i].CreatedAt.After(todos[j].CreatedAt)
+	})
+	return todos, nil
+}
+
+// Update updates an existing todo in the database. The old row is removed
+// from todos_by_user_status_created because completed is part of its key.
+func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) (err error) {
+	ctx, span := startSpan(ctx, "BATCH", "todos_by_id", insertByID)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	existing, owner, err := r.get(ctx, `
+		SELECT id, owner, title, description, completed, priority, due_date, created_at, updated_at
+		FROM todos_by_id
+		WHERE id = ?`, todo.ID)
+	if err != nil {
+		return err
+	}
+
+	todo.CreatedAt = existing.CreatedAt
+	todo.UpdatedAt = time.Now().Truncate(time.Millisecond)
+
+	batch := r.session.Batch(gocql.LoggedBatch).Consistency(r.opts.WriteConsistency)
+	batch.Query(deleteByStatus, owner, existing.Completed, existing.CreatedAt, gocql.UUID(existing.ID))
+	r.insert(batch, todo)
+	if err = batch.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to update todo: %v", err)
+	}
+
+	return nil
+}
+
+// toggleAttempts is how many times ToggleCompleted retries after losing a race
+const toggleAttempts = 5
+
+// ToggleCompleted flips the completed flag with a lightweight transaction on
+// todos_by_id, so of two concurrent toggles from the same state only one is
+// applied; the other re-reads and flips again. todos_by_user_status_created is
+// then updated with a logged batch.
+func (r *TodoRepository) ToggleCompleted(ctx context.Context, id uuid.UUID) (_ *domain.Todo, err error) {
+	query := `
+		UPDATE todos_by_id USING TTL ?
+		SET owner = ?, title = ?, description = ?, completed = ?, priority = ?, due_date = ?, created_at = ?, updated_at = ?
+		WHERE id = ?
+		IF completed = ?`
+
+	ctx, span := startSpan(ctx, "UPDATE", "todos_by_id", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	for attempt := 1; attempt <= toggleAttempts; attempt++ {
+		existing, owner, err := r.get(ctx, `
+		SELECT id, owner, title, description, completed, priority, due_date, created_at, updated_at
+		FROM todos_by_id
+		WHERE id = ?`, id)
+		if err != nil {
+			return nil, err
+		}
+
+		todo := *existing
+		todo.Completed = !existing.Completed
+		todo.UpdatedAt = time.Now().Truncate(time.Millisecond)
+
+		applied, err := r.session.Query(query,
+			r.ttl(&todo),
+			owner,
+			todo.Title,
+			todo.Description,
+			todo.Completed,
+			todo.Priority,
+			todo.DueDate,
+			todo.CreatedAt,
+			todo.UpdatedAt,
+			gocql.UUID(id),
+			existing.Completed,
+		).WithContext(ctx).MapScanCAS(map[string]any{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to toggle todo: %v", err)
+		}
+		if !applied {
+			continue
+		}
+
+		batch := r.session.Batch(gocql.LoggedBatch).Consistency(r.opts.WriteConsistency)
+		batch.Query(deleteByStatus, owner, existing.Completed, existing.CreatedAt, gocql.UUID(id))
+		r.insertByStatus(batch, &todo)
+		if err = batch.ExecContext(ctx); err != nil {
+			return nil, fmt.Errorf("failed to toggle todo: %v", err)
+		}
+		return &todo, nil
+	}
+
+	return nil, fmt.Errorf("failed to toggle todo: still contended after %d attempts", toggleAttempts)
+}
+
+// Delete deletes a todo from the database
+func (r *TodoRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "BATCH", "todos_by_id", deleteByID)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	existing, owner, err := r.get(ctx, `
+		SELECT id, owner, title, description, completed, priority, due_date, created_at, updated_at
+		FROM todos_by_id
+		WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.session.Batch(gocql.LoggedBatch).
+		Consistency(r.opts.WriteConsistency).
+		Query(deleteByID, gocql.UUID(id)).
+		Query(deleteByStatus, owner, existing.Completed, existing.CreatedAt, gocql.UUID(id)).
+		ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %v", err)
+	}
+
+	return nil
+}
+
+// GetByStatus retrieves todos by their completion status
+func (r *TodoRepository) GetByStatus(ctx context.Context, completed bool) (_ []*domain.Todo, err error) {
+	query := `
+		SELECT id, title, description, completed, priority, due_date, created_at, updated_at
+		FROM todos_by_user_status_created
+		WHERE user_id = ? AND completed = ?`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos_by_user_status_created", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	iter := r.session.Query(query, r.opts.Owner, completed).
+		Consistency(r.opts.ReadConsistency).
+		IterContext(ctx)
+
+	todos, err := collect(iter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos by status: %v", err)
+	}
+	return todos, nil
+}
+
+// Search returns todos whose title or description contain every word of query, ignoring case.
+// Cassandra has no full-text index here, so the owner's partitions are scanned and filtered.
+func (r *TodoRepository) Search(ctx context.Context, query string) ([]*domain.Todo, error) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	todos, err := r.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*domain.Todo
+	for _, todo := range todos {
+		text := strings.ToLower(todo.Title + " " + todo.Description)
+		match := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				match = false
+				break
+			}
+		}
+		if match {
+			matches = append(matches, todo)
+		}
+	}
+	return matches, nil
+}
+
+// get reads one todo and its owner from todos_by_id
+func (r *TodoRepository) get(ctx context.Context, query string, id uuid.UUID) (*domain.Todo, string, error) {
+	var (
+		todo  domain.Todo
+		owner string
+		rowID gocql.UUID
+	)
+	err := r.session.Query(query, gocql.UUID(id)).
+		Consistency(r.opts.ReadConsistency).
+		ScanContext(ctx,
+			&rowID,
+			&owner,
+			&todo.Title,
+			&todo.Description,
+			&todo.Completed,
+			&todo.Priority,
+			&todo.DueDate,
+			&todo.CreatedAt,
+			&todo.UpdatedAt,
+		)
+	if err != nil {
+		if errors.Is(err, gocql.ErrNotFound) {
+			return nil, "", domain.ErrTodoNotFound
+		}
+		return nil, "", fmt.Errorf("failed to get todo: %v", err)
+	}
+
+	todo.ID = uuid.UUID(rowID)
+	return &todo, owner, nil
+}
+
+// collect scans the todos of iter, whose rows hold the todo columns without owner
+func collect(iter *gocql.Iter) ([]*domain.Todo, error) {
+	var todos []*domain.Todo
+	scanner := iter.Scanner()
+	for scanner.Next() {
+		var (
+			todo domain.Todo
+			id   gocql.UUID
+		)
+		err := scanner.Scan(
+			&id,
+			&todo.Title,
+			&todo.Description,
+			&todo.Completed,
+			&todo.Priority,
+			&todo.DueDate,
+			&todo.CreatedAt,
+			&todo.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %v", err)
+		}
+		todo.ID = uuid.UUID(id)
+		todos = append(todos, &todo)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// insert adds the writes for both query tables to batch
+func (r *TodoRepository) insert(batch *gocql.Batch, todo *domain.Todo) {
+	batch.Query(insertByID,
+		gocql.UUID(todo.ID),
+		r.opts.Owner,
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		todo.DueDate,
+		todo.CreatedAt,
+		todo.UpdatedAt,
+		r.ttl(todo),
+	)
+	r.insertByStatus(batch, todo)
+}
+
+// insertByStatus adds the write for todos_by_user_status_created to batch
+func (r *TodoRepository) insertByStatus(batch *gocql.Batch, todo *domain.Todo) {
+	batch.Query(insertByStatus,
+		r.opts.Owner,
+		todo.Completed,
+		todo.CreatedAt,
+		gocql.UUID(todo.ID),
+		todo.Title,
+		todo.Description,
+		todo.Priority,
+		todo.DueDate,
+		todo.UpdatedAt,
+		r.ttl(todo),
+	)
+}
+
+// ttl returns the TTL in seconds to write todo with, 0 meaning none
+func (r *TodoRepository) ttl(todo *domain.Todo) int {
+	if todo.Completed && r.opts.CompletedTTL > 0 {
+		return int(r.opts.CompletedTTL / time.Second)
+	}
+	return 0
+}
+
+// startSpan starts a client span describing a single statement against table
+func startSpan(ctx context.Context, operation, table, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameCassandra,
+			semconv.DBCollectionName(table),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+}
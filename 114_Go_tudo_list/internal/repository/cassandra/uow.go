@@ -0,0 +1,26 @@
+package cassandra
+
+import (
+	"context"
+
+	"todo-app/internal/domain"
+)
+
+// UnitOfWork runs units of work directly against the repository. Cassandra has
+// no multi-partition transactions: writes made before fn fails are kept and
+// concurrent units of work are not isolated from each other.
+type UnitOfWork struct {
+	todos *TodoRepository
+}
+
+// NewUnitOfWork creates a new UnitOfWork
+func NewUnitOfWork(todos *TodoRepository) *UnitOfWork {
+	return &UnitOfWork{
+		todos: todos,
+	}
+}
+
+// Do runs fn with the repository
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	return fn(ctx, domain.Repositories{Todos: u.todos})
+}
@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-app/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// TodoRepository implements the TodoRepository interface in memory.
+// It is safe for concurrent use and mirrors the PostgreSQL semantics:
+// IDs and timestamps are generated on write and lists are ordered by created_at DESC.
+type TodoRepository struct {
+	mu           sync.RWMutex
+	todos        map[uuid.UUID]*domain.Todo
+	snapshotPath string
+}
+
+// NewTodoRepository creates an empty in-memory TodoRepository
+func NewTodoRepository() *TodoRepository {
+	return &TodoRepository{
+		todos: make(map[uuid.UUID]*domain.Todo),
+	}
+}
+
+// NewSnapshotTodoRepository creates an in-memory TodoRepository that loads its
+// initial state from a JSON file, if present, and rewrites it after every change
+func NewSnapshotTodoRepository(path string) (*TodoRepository, error) {
+	r := NewTodoRepository()
+	r.snapshotPath = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+
+	var todos []*domain.Todo
+	if err := json.Unmarshal(data, &todos); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %v", path, err)
+	}
+	for _, todo := range todos {
+		r.todos[todo.ID] = todo
+	}
+
+	return r, nil
+}
+
+// Create creates a new todo
+func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo.ID = uuid.New()
+	todo.CreatedAt = time.Now()
+	todo.UpdatedAt = todo.CreatedAt
+
+	r.todos[todo.ID] = clone(todo)
+	return r.persist()
+}
+
+// CreateMany creates several todos and writes the snapshot once
+func (r *TodoRepository) CreateMany(ctx context.Context, todos []*domain.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, todo := range todos {
+		todo.ID = uuid.New()
+		todo.CreatedAt = now
+		todo.UpdatedAt = now
+		r.todos[todo.ID] = clone(todo)
+	}
+	return r.persist()
+}
+
+// GetByID retrieves a todo by its ID
+func (r *TodoRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, domain.ErrTodoNotFound
+	}
+	return clone(todo), nil
+}
+
+// GetByIDs retrieves the todos with the given IDs
+func (r *TodoRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var todos []*domain.Todo
+	for _, id := range ids {
+		if todo, ok := r.todos[id]; ok {
+			todos = append(todos, clone(todo))
+		}
+	}
+	return todos, nil
+}
+
+// GetAll retrieves all todos
+func (r *TodoRepository) GetAll(ctx context.Context) ([]*domain.Todo, error) {
+	return r.list(func(*domain.Todo) bool { return true }), nil
+}
+
+// Update updates an existing todo
+func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.todos[todo.ID]
+	if !ok {
+		return domain.ErrTodoNotFound
+	}
+
+	todo.CreatedAt = existing.CreatedAt
+	todo.UpdatedAt = time.Now()
+
+	r.todos[todo.ID] = clone(todo)
+	return r.persist()
+}
+
+// ToggleCompleted flips the completed flag under the write lock
+func (r *TodoRepository) ToggleCompleted(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, domain.ErrTodoNotFound
+	}
+
+	todo.Completed = !todo.Completed
+	todo.UpdatedAt = time.Now()
+	if err := r.persist(); err != nil {
+		return nil, err
+	}
+	return clone(todo), nil
+}
+
+// Delete deletes a todo
+func (r *TodoRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.todos[id]; !ok {
+		return domain.ErrTodoNotFound
+	}
+
+	delete(r.todos, id)
+	return r.persist()
+}
+
+// GetByStatus retrieves todos by their completion status
+func (r *TodoRepository) GetByStatus(ctx context.Context, completed bool) ([]*domain.Todo, error) {
+	return r.list(func(todo *domain.Todo) bool { return todo.Completed == completed }), nil
+}
+
+// Search returns todos whose title or description contain every word of query, ignoring case
+func (r *TodoRepository) Search(ctx context.Context, query string) ([]*domain.Todo, error) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	return r.list(func(todo *domain.Todo) bool {
+		text := strings.ToLower(todo.Title + " " + todo.Description)
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+		return true
+	}), nil
+}
+
+// list returns copies of the todos matching keep, newest first
+func (r *TodoRepository) list(keep func(*domain.Todo) bool) []*domain.Todo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var todos []*domain.Todo
+	for _, todo := range r.todos {
+		if keep(todo) {
+			todos = append(todos, clone(todo))
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		return todos[i].CreatedAt.After(todos[j].CreatedAt)
+	})
+	return todos
+}
+
+// persist writes the snapshot file, if configured. Callers must hold the write lock.
+func (r *TodoRepository) persist() error {
+	if r.snapshotPath == "" {
+		return nil
+	}
+
+	todos := make([]*domain.Todo, 0, len(r.todos))
+	for _, todo := range r.todos {
+		todos = append(todos, todo)
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		return todos[i].CreatedAt.Before(todos[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(todos, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+
+	// Write to a temporary file and rename so a crash never leaves a truncated snapshot
+	tmp, err := os.CreateTemp(filepath.Dir(r.snapshotPath), ".todos-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), r.snapshotPath); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	return nil
+}
+
+// clone copies a todo so callers never share state with the store
+func clone(todo *domain.Todo) *domain.Todo {
+	c := *todo
+	if todo.DueDate != nil {
+		due := *todo.DueDate
+		c.DueDate = &due
+	}
+	return &c
+}
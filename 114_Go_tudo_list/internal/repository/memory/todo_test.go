@@ -0,0 +1,17 @@
+package memory_test
+
+import (
+	"testing"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/repository/memory"
+	"todo-app/internal/repository/repotest"
+)
+
+// RunUnitOfWork does not apply: the unit of work of this backend serialises
+// units of work but does not roll back their writes
+func TestTodoRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) domain.TodoRepository {
+		return memory.NewTodoRepository()
+	})
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"todo-app/internal/domain"
+)
+
+// UnitOfWork runs units of work one at a time against the repository. Each
+// repository call is atomic, but writes made before fn fails are not rolled back.
+type UnitOfWork struct {
+	mu    sync.Mutex
+	todos *TodoRepository
+}
+
+// NewUnitOfWork creates a new UnitOfWork
+func NewUnitOfWork(todos *TodoRepository) *UnitOfWork {
+	return &UnitOfWork{
+		todos: todos,
+	}
+}
+
+// Do runs fn with the repository while holding the unit-of-work lock
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return fn(ctx, domain.Repositories{Todos: u.todos})
+}
@@ -0,0 +1,429 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/projection"
+	"todo-app/internal/telemetry"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("todo-app/internal/repository/mysql")
+
+// todoColumns are the todo columns in the order every query reads them
+var todoColumns = []string{"id", "title", "description", "completed", "priority", "due_date", "created_at", "updated_at"}
+
+const insertTodo = `
+		INSERT INTO todos (id, title, description, completed, priority, due_date, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+// dbtx is the part of *sql.DB and *sql.Tx the repositories use, so they
+// run the same queries on the database or inside a unit of work
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// TodoRepository implements the TodoRepository interface for MySQL and MariaDB
+type TodoRepository struct {
+	db dbtx
+}
+
+// NewTodoRepository creates a new TodoRepository
+func NewTodoRepository(db *sql.DB) *TodoRepository {
+	return &TodoRepository{
+		db: db,
+	}
+}
+
+// Create creates a new todo in the database
+func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) (err error) {
+	query := insertTodo
+
+	ctx, span := startSpan(ctx, "INSERT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo.ID = uuid.New()
+	todo.CreatedAt = time.Now()
+	todo.UpdatedAt = todo.CreatedAt
+
+	_, err = r.db.ExecContext(ctx, query,
+		todo.ID,
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		todo.DueDate,
+		todo.CreatedAt,
+		todo.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+
+	return nil
+}
+
+// CreateMany creates todos in one transaction using a single prepared insert
+func (r *TodoRepository) CreateMany(ctx context.Context, todos []*domain.Todo) (err error) {
+	ctx, span := startSpan(ctx, "INSERT", "todos", insertTodo)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	return r.inTx(ctx, func(tx dbtx) error {
+		return createMany(ctx, tx, todos)
+	})
+}
+
+// createMany inserts todos with one prepared statement
+func createMany(ctx context.Context, tx dbtx, todos []*domain.Todo) error {
+	stmt, err := tx.PrepareContext(ctx, insertTodo)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, todo := range todos {
+		todo.ID = uuid.New()
+		todo.CreatedAt = now
+		todo.UpdatedAt = now
+
+		_, err = stmt.ExecContext(ctx,
+			todo.ID,
+			todo.Title,
+			todo.Description,
+			todo.Completed,
+			todo.Priority,
+			todo.DueDate,
+			todo.CreatedAt,
+			todo.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create todo: %w", err)
+		}
+	}
+	return nil
+}
+
+// inTx runs fn in a new transaction, or in the current one if r already runs inside a unit of work
+func (r *TodoRepository) inTx(ctx context.Context, fn func(tx dbtx) error) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return fn(r.db)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a todo by its ID
+func (r *TodoRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *domain.Todo, err error) {
+	query := selectColumns(ctx) + `
+		WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo, err := scanTodo(r.db.QueryRowContext(ctx, query, id), projection.Columns(ctx, todoColumns))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	return todo, nil
+}
+
+// GetByIDs retrieves the todos with the given IDs in one query
+func (r *TodoRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (_ []*domain.Todo, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query := selectColumns(ctx) + `
+		WHERE id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	todos, err := r.list(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+	return todos, nil
+}
+
+// GetAll retrieves all todos from the database
+func (r *TodoRepository) GetAll(ctx context.Context) (_ []*domain.Todo, err error) {
+	query := selectColumns(ctx) + `
+		ORDER BY created_at DESC`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todos, err := r.list(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all todos: %w", err)
+	}
+	return todos, nil
+}
+
+// Update updates an existing todo in the database
+func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) (err error) {
+	// clientFoundRows in the DSN makes RowsAffected count matched rows, so
+	// an update that changes nothing is not mistaken for a missing todo
+	query := `
+		UPDATE todos
+		SET title = ?, description = ?, completed = ?, priority = ?, due_date = ?, updated_at = ?
+		WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "UPDATE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		todo.DueDate,
+		todo.UpdatedAt,
+		todo.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrTodoNotFound
+	}
+
+	return nil
+}
+
+// ToggleCompleted flips the completed flag and reads the todo back in one
+// transaction. The UPDATE locks the row, so concurrent toggles never both
+// write the same value.
+func (r *TodoRepository) ToggleCompleted(ctx context.Context, id uuid.UUID) (todo *domain.Todo, err error) {
+	query := `
+		UPDATE todos
+		SET completed = NOT completed, updated_at = ?
+		WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "UPDATE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	err = r.inTx(ctx, func(tx dbtx) error {
+		result, err := tx.ExecContext(ctx, query, time.Now(), id)
+		if err != nil {
+			return fmt.Errorf("failed to toggle todo: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return domain.ErrTodoNotFound
+		}
+
+		if todo, err = scanTodo(tx.QueryRowContext(ctx, selectColumns(ctx)+`
+		WHERE id = ?`, id), projection.Columns(ctx, todoColumns)); err != nil {
+			return fmt.Errorf("failed to get todo: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// Delete deletes a todo from the database
+func (r *TodoRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	query := `DELETE FROM todos WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "DELETE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrTodoNotFound
+	}
+
+	return nil
+}
+
+// GetByStatus retrieves todos by their completion status
+func (r *TodoRepository) GetByStatus(ctx context.Context, completed bool) (_ []*domain.Todo, err error) {
+	query := selectColumns(ctx) + `
+		WHERE completed = ?
+		ORDER BY created_at DESC`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todos, err := r.list(ctx, query, completed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos by status: %w", err)
+	}
+	return todos, nil
+}
+
+// Search runs a boolean-mode FULLTEXT search over title and description.
+// Every word of q is required; double quotes are dropped because boolean mode cannot escape them.
+func (r *TodoRepository) Search(ctx context.Context, q string) (_ []*domain.Todo, err error) {
+	var terms []string
+	for _, word := range strings.Fields(strings.ReplaceAll(q, `"`, " ")) {
+		terms = append(terms, `+"`+word+`"`)
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	query := selectColumns(ctx) + `
+		WHERE MATCH (title, description) AGAINST (? IN BOOLEAN MODE)
+		ORDER BY created_at DESC`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todos, err := r.list(ctx, query, strings.Join(terms, " "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+	return todos, nil
+}
+
+// list runs a query returning todo rows
+func (r *TodoRepository) list(ctx context.Context, query string, args ...any) ([]*domain.Todo, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := projection.Columns(ctx, todoColumns)
+	var todos []*domain.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows, columns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating todos: %w", err)
+	}
+
+	return todos, nil
+}
+
+// selectColumns starts a query reading the todo columns ctx needs
+func selectColumns(ctx context.Context) string {
+	return `
+		SELECT ` + strings.Join(projection.Columns(ctx, todoColumns), ", ") + `
+		FROM todos`
+}
+
+// scanTodo reads one todo row holding columns. Other columns keep their zero value.
+func scanTodo(row interface{ Scan(...any) error }, columns []string) (*domain.Todo, error) {
+	todo := &domain.Todo{}
+	fields := map[string]any{
+		"id":          &todo.ID,
+		"title":       &todo.Title,
+		"description": &todo.Description,
+		"completed":   &todo.Completed,
+		"priority":    &todo.Priority,
+		"due_date":    &todo.DueDate,
+		"created_at":  &todo.CreatedAt,
+		"updated_at":  &todo.UpdatedAt,
+	}
+	var dest []any
+	for _, column := range columns {
+		dest = append(dest, fields[column])
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// startSpan starts a client span describing a single statement against table
+func startSpan(ctx context.Context, operation, table, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameMySQL,
+			semconv.DBCollectionName(table),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// Connect creates a database connection
+func Connect(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// Set connection pool settings
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	return db, nil
+}
@@ -0,0 +1,68 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/migrate"
+	"todo-app/internal/repository/mysql"
+	"todo-app/internal/repository/repotest"
+	"todo-app/migrations"
+)
+
+// dsnEnv names the variable holding the DSN of a disposable test database,
+// with the parameters MySQLConfig.GetDSN adds; its todos are deleted before
+// each test
+const dsnEnv = "TEST_MYSQL_DSN"
+
+func TestTodoRepository(t *testing.T) {
+	db := openDB(t)
+	repotest.Run(t, func(t *testing.T) domain.TodoRepository {
+		truncate(t, db)
+		return mysql.NewTodoRepository(db)
+	})
+}
+
+func TestUnitOfWork(t *testing.T) {
+	db := openDB(t)
+	repotest.RunUnitOfWork(t, func(t *testing.T) (domain.TodoRepository, domain.UnitOfWork) {
+		truncate(t, db)
+		return mysql.NewTodoRepository(db), mysql.NewUnitOfWork(db)
+	})
+}
+
+// openDB connects to the test database and migrates it, skipping t when no
+// test database is configured
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+
+	db, err := mysql.Connect(dsn)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrate.MySQL, migrations.MySQL)
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	return db
+}
+
+// truncate deletes every todo so each subtest starts empty
+func truncate(t *testing.T, db *sql.DB) {
+	t.Helper()
+	if _, err := db.Exec("TRUNCATE TABLE todos"); err != nil {
+		t.Fatalf("TRUNCATE todos: %v", err)
+	}
+}
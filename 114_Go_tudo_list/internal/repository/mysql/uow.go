@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"todo-app/internal/domain"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+// txAttempts is how many times a unit of work is run when it keeps deadlocking
+const txAttempts = 3
+
+// UnitOfWork runs units of work in SERIALIZABLE transactions. InnoDB then locks
+// every row a unit of work reads, so two that read and update the same todo
+// deadlock instead of losing a write; the loser is retried from the start.
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork creates a new UnitOfWork
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{
+		db: db,
+	}
+}
+
+// Do runs fn in a transaction, committing if it returns nil
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	var err error
+	for attempt := 1; attempt <= txAttempts; attempt++ {
+		err = u.do(ctx, fn)
+		if !isDeadlock(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (u *UnitOfWork) do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(ctx, domain.Repositories{Todos: &TodoRepository{db: tx}}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isDeadlock reports whether err is InnoDB's ER_LOCK_DEADLOCK, which rolls back the whole transaction
+func isDeadlock(err error) bool {
+	var myErr *driver.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == 1213
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"todo-app/internal/config"
+	"todo-app/internal/consistency"
+	"todo-app/internal/logging"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// replicaLagQuery returns how far behind the primary a replica is, in seconds.
+// A replica that has replayed everything it received reports zero even if the
+// primary has been idle; a server that is not in recovery also reports zero.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+
+// ReplicaSet routes reads to healthy streaming replicas, falling back to the
+// primary when the context is pinned or no replica is healthy
+type ReplicaSet struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	next     atomic.Uint64
+
+	// maxLag and interval can change at runtime through SetLimits
+	maxLag   atomic.Int64
+	interval atomic.Int64
+}
+
+type replica struct {
+	name string
+	pool *pgxpool.Pool
+
+	mu      sync.RWMutex
+	healthy bool
+	lag     time.Duration
+	err     error
+}
+
+// ReplicaStatus describes the last health check of one replica
+type ReplicaStatus struct {
+	Healthy bool    `json:"healthy"`
+	LagSecs float64 `json:"lag_seconds"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// ConnectReplicas opens a connection pool per replica DSN in cfg, with the same
+// pool settings as the primary. Replicas start out unhealthy until the first
+// check so nothing is read from them unverified.
+func ConnectReplicas(ctx context.Context, primary *pgxpool.Pool, cfg config.DatabaseConfig) (*ReplicaSet, error) {
+	set := &ReplicaSet{primary: primary}
+	set.SetLimits(cfg.ReplicaMaxLag, cfg.ReplicaCheckInterval)
+	for i, dsn := range cfg.ReplicaDSNs {
+		pool, err := newPool(ctx, dsn, cfg)
+		if err != nil {
+			set.Close()
+			return nil, fmt.Errorf("failed to open replica %d: %v", i+1, err)
+		}
+		set.replicas = append(set.replicas, &replica{name: fmt.Sprintf("replica-%d", i+1), pool: pool})
+	}
+	return set, nil
+}
+
+// SetLimits changes the lag beyond which a replica leaves rotation and how
+// often Monitor checks, taking effect from the next check
+func (s *ReplicaSet) SetLimits(maxLag, interval time.Duration) {
+	s.maxLag.Store(int64(maxLag))
+	s.interval.Store(int64(interval))
+}
+
+// Reader returns the pool that should serve a read for ctx
+func (s *ReplicaSet) Reader(ctx context.Context) *pgxpool.Pool {
+	if consistency.PrimaryPinned(ctx) {
+		return s.primary
+	}
+
+	// Round-robin over the replicas, skipping unhealthy ones
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		r := s.replicas[(start+i)%n]
+		r.mu.RLock()
+		healthy := r.healthy
+		r.mu.RUnlock()
+		if healthy {
+			return r.pool
+		}
+	}
+	return s.primary
+}
+
+// Check probes every replica once and updates its health
+func (s *ReplicaSet) Check(ctx context.Context) {
+	for _, r := range s.replicas {
+		var lagSecs float64
+		err := r.pool.QueryRow(ctx, replicaLagQuery).Scan(&lagSecs)
+		lag := time.Duration(lagSecs * float64(time.Second))
+		if maxLag := time.Duration(s.maxLag.Load()); err == nil && lag > maxLag {
+			err = fmt.Errorf("replication lag %s exceeds %s", lag.Round(time.Millisecond), maxLag)
+		}
+
+		r.mu.Lock()
+		if r.healthy != (err == nil) {
+			if err != nil {
+				logging.Warnf("Read replica %s removed from rotation: %v", r.name, err)
+			} else {
+				logging.Infof("Read replica %s back in rotation", r.name)
+			}
+		}
+		r.healthy, r.lag, r.err = err == nil, lag, err
+		r.mu.Unlock()
+	}
+}
+
+// Monitor checks the replicas every check interval until ctx is cancelled
+func (s *ReplicaSet) Monitor(ctx context.Context) error {
+	interval := time.Duration(s.interval.Load())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		s.Check(checkCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if next := time.Duration(s.interval.Load()); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// Status returns the last known state of every replica keyed by name
+func (s *ReplicaSet) Status() map[string]ReplicaStatus {
+	status := make(map[string]ReplicaStatus, len(s.replicas))
+	for _, r := range s.replicas {
+		r.mu.RLock()
+		st := ReplicaStatus{Healthy: r.healthy, LagSecs: r.lag.Seconds()}
+		if r.err != nil {
+			st.Error = r.err.Error()
+		}
+		r.mu.RUnlock()
+		status[r.name] = st
+	}
+	return status
+}
+
+// Close closes the replica pools. The primary is owned by the caller.
+func (s *ReplicaSet) Close() {
+	for _, r := range s.replicas {
+		r.pool.Close()
+	}
+}
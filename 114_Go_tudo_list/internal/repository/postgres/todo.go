@@ -1,40 +1,75 @@
 package postgres
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"todo-app/internal/domain"
+	"todo-app/internal/projection"
+	"todo-app/internal/telemetry"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("todo-app/internal/repository/postgres")
+
+// todoColumns are the todo columns in the order used by every query and by COPY.
+// Their names match the db tags on domain.Todo so rows scan by name.
+var todoColumns = []string{"id", "title", "description", "completed", "priority", "due_date", "created_at", "updated_at"}
+
+// dbtx is the part of *pgxpool.Pool and pgx.Tx the repositories use, so they
+// run the same queries on the pool or inside a unit of work
+type dbtx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error)
+}
+
 // TodoRepository implements the TodoRepository interface for PostgreSQL
 type TodoRepository struct {
-	db *sql.DB
+	db       dbtx
+	replicas *ReplicaSet
 }
 
 // NewTodoRepository creates a new TodoRepository
-func NewTodoRepository(db *sql.DB) *TodoRepository {
+func NewTodoRepository(pool *pgxpool.Pool) *TodoRepository {
 	return &TodoRepository{
-		db: db,
+		db: pool,
+	}
+}
+
+// NewReplicatedTodoRepository creates a TodoRepository that writes to pool and
+// serves GetByID, GetAll, GetByStatus and Search from replicas
+func NewReplicatedTodoRepository(pool *pgxpool.Pool, replicas *ReplicaSet) *TodoRepository {
+	return &TodoRepository{
+		db:       pool,
+		replicas: replicas,
 	}
 }
 
 // Create creates a new todo in the database
-func (r *TodoRepository) Create(todo *domain.Todo) error {
+func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) (err error) {
 	query := `
 		INSERT INTO todos (id, title, description, completed, priority, due_date, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
+	ctx, span := startSpan(ctx, "INSERT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	todo.ID = uuid.New()
 	todo.CreatedAt = time.Now()
 	todo.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(query,
+	_, err = r.db.Exec(ctx, query,
 		todo.ID,
 		todo.Title,
 		todo.Description,
@@ -46,90 +81,118 @@ func (r *TodoRepository) Create(todo *domain.Todo) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to create todo: %v", err)
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+
+	return nil
+}
+
+// CreateMany stores todos with a single COPY FROM. Either all rows are stored or none.
+func (r *TodoRepository) CreateMany(ctx context.Context, todos []*domain.Todo) (err error) {
+	ctx, span := startSpan(ctx, "COPY", "todos", "COPY todos FROM STDIN")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	now := time.Now()
+	for _, todo := range todos {
+		todo.ID = uuid.New()
+		todo.CreatedAt = now
+		todo.UpdatedAt = now
+	}
+
+	_, err = r.db.CopyFrom(ctx, pgx.Identifier{"todos"}, todoColumns,
+		pgx.CopyFromSlice(len(todos), func(i int) ([]any, error) {
+			todo := todos[i]
+			return []any{
+				todo.ID,
+				todo.Title,
+				todo.Description,
+				todo.Completed,
+				todo.Priority,
+				todo.DueDate,
+				todo.CreatedAt,
+				todo.UpdatedAt,
+			}, nil
+		}),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to copy todos: %w", err)
 	}
 
 	return nil
 }
 
 // GetByID retrieves a todo by its ID
-func (r *TodoRepository) GetByID(id uuid.UUID) (*domain.Todo, error) {
-	query := `
-		SELECT id, title, description, completed, priority, due_date, created_at, updated_at
-		FROM todos
+func (r *TodoRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *domain.Todo, err error) {
+	query := selectTodos(ctx) + `
 		WHERE id = $1`
 
-	todo := &domain.Todo{}
-	err := r.db.QueryRow(query, id).Scan(
-		&todo.ID,
-		&todo.Title,
-		&todo.Description,
-		&todo.Completed,
-		&todo.Priority,
-		&todo.DueDate,
-		&todo.CreatedAt,
-		&todo.UpdatedAt,
-	)
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	rows, err := r.reader(ctx).Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
 
+	todo, err := pgx.CollectExactlyOneRow(rows, scanTodo)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrTodoNotFound
 		}
-		return nil, fmt.Errorf("failed to get todo: %v", err)
+		return nil, fmt.Errorf("failed to get todo: %w", err)
 	}
 
 	return todo, nil
 }
 
-// GetAll retrieves all todos from the database
-func (r *TodoRepository) GetAll() ([]*domain.Todo, error) {
-	query := `
-		SELECT id, title, description, completed, priority, due_date, created_at, updated_at
-		FROM todos
-		ORDER BY created_at DESC`
+// GetByIDs retrieves the todos with the given IDs in one query
+func (r *TodoRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (_ []*domain.Todo, err error) {
+	query := selectTodos(ctx) + `
+		WHERE id = ANY($1::uuid[])`
 
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get all todos: %v", err)
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = id.String()
 	}
-	defer rows.Close()
-
-	var todos []*domain.Todo
-	for rows.Next() {
-		todo := &domain.Todo{}
-		err := rows.Scan(
-			&todo.ID,
-			&todo.Title,
-			&todo.Description,
-			&todo.Completed,
-			&todo.Priority,
-			&todo.DueDate,
-			&todo.CreatedAt,
-			&todo.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan todo: %v", err)
-		}
-		todos = append(todos, todo)
+	todos, err := r.list(ctx, query, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
 	}
+	return todos, nil
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating todos: %v", err)
-	}
+// GetAll retrieves all todos from the database
+func (r *TodoRepository) GetAll(ctx context.Context) (_ []*domain.Todo, err error) {
+	query := selectTodos(ctx) + `
+		ORDER BY created_at DESC`
 
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todos, err := r.list(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all todos: %w", err)
+	}
 	return todos, nil
 }
 
 // Update updates an existing todo in the database
-func (r *TodoRepository) Update(todo *domain.Todo) error {
+func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) (err error) {
 	query := `
 		UPDATE todos
 		SET title = $2, description = $3, completed = $4, priority = $5, due_date = $6, updated_at = $7
 		WHERE id = $1`
 
+	ctx, span := startSpan(ctx, "UPDATE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	todo.UpdatedAt = time.Now()
 
-	result, err := r.db.Exec(query,
+	tag, err := r.db.Exec(ctx, query,
 		todo.ID,
 		todo.Title,
 		todo.Description,
@@ -140,36 +203,57 @@ func (r *TodoRepository) Update(todo *domain.Todo) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to update todo: %v", err)
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrTodoNotFound
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return nil
+}
+
+// ToggleCompleted flips the completed flag in a single statement, so
+// concurrent toggles never both write the same value
+func (r *TodoRepository) ToggleCompleted(ctx context.Context, id uuid.UUID) (_ *domain.Todo, err error) {
+	query := `
+		UPDATE todos
+		SET completed = NOT completed, updated_at = $2
+		WHERE id = $1
+		RETURNING id, title, description, completed, priority, due_date, created_at, updated_at`
+
+	ctx, span := startSpan(ctx, "UPDATE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	rows, err := r.db.Query(ctx, query, id, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+		return nil, fmt.Errorf("failed to toggle todo: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return domain.ErrTodoNotFound
+	todo, err := pgx.CollectExactlyOneRow(rows, scanTodo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to toggle todo: %w", err)
 	}
 
-	return nil
+	return todo, nil
 }
 
 // Delete deletes a todo from the database
-func (r *TodoRepository) Delete(id uuid.UUID) error {
+func (r *TodoRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
 	query := `DELETE FROM todos WHERE id = $1`
 
-	result, err := r.db.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete todo: %v", err)
-	}
+	ctx, span := startSpan(ctx, "DELETE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
 
-	rowsAffected, err := result.RowsAffected()
+	tag, err := r.db.Exec(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+		return fmt.Errorf("failed to delete todo: %w", err)
 	}
 
-	if rowsAffected == 0 {
+	if tag.RowsAffected() == 0 {
 		return domain.ErrTodoNotFound
 	}
 
@@ -177,120 +261,77 @@ func (r *TodoRepository) Delete(id uuid.UUID) error {
 }
 
 // GetByStatus retrieves todos by their completion status
-func (r *TodoRepository) GetByStatus(completed bool) ([]*domain.Todo, error) {
-	query := `
-		SELECT id, title, description, completed, priority, due_date, created_at, updated_at
-		FROM todos
+func (r *TodoRepository) GetByStatus(ctx context.Context, completed bool) (_ []*domain.Todo, err error) {
+	query := selectTodos(ctx) + `
 		WHERE completed = $1
 		ORDER BY created_at DESC`
 
-	rows, err := r.db.Query(query, completed)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get todos by status: %v", err)
-	}
-	defer rows.Close()
-
-	var todos []*domain.Todo
-	for rows.Next() {
-		todo := &domain.Todo{}
-		err := rows.Scan(
-			&todo.ID,
-			&todo.Title,
-			&todo.Description,
-			&todo.Completed,
-			&todo.Priority,
-			&todo.DueDate,
-			&todo.CreatedAt,
-			&todo.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan todo: %v", err)
-		}
-		todos = append(todos, todo)
-	}
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating todos: %v", err)
+	todos, err := r.list(ctx, query, completed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos by status: %w", err)
 	}
-
 	return todos, nil
 }
 
-// Connect creates a database connection
-func Connect(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %v", err)
-	}
-
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %v", err)
-	}
+// Search runs a full-text search over title and description
+func (r *TodoRepository) Search(ctx context.Context, q string) (_ []*domain.Todo, err error) {
+	query := selectTodos(ctx) + `
+		WHERE to_tsvector('simple', title || ' ' || COALESCE(description, '')) @@ websearch_to_tsquery('simple', $1)
+		ORDER BY created_at DESC`
 
-	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
 
-	return db, nil
+	todos, err := r.list(ctx, query, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+	return todos, nil
 }
 
-// RunMigrations runs database migrations
-func RunMigrations(db *sql.DB) error {
-	// Check if the migration has already been run
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_name = 'todos'").Scan(&count)
+// list runs a read query and scans every row into a todo
+func (r *TodoRepository) list(ctx context.Context, query string, args ...any) ([]*domain.Todo, error) {
+	rows, err := r.reader(ctx).Query(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to check if table exists: %v", err)
+		return nil, err
 	}
+	return pgx.CollectRows(rows, scanTodo)
+}
 
-	if count > 0 {
-		// Table already exists, skip migration
-		return nil
-	}
+// selectTodos starts a query reading the todo columns ctx needs
+func selectTodos(ctx context.Context) string {
+	return `
+		SELECT ` + strings.Join(projection.Columns(ctx, todoColumns), ", ") + `
+		FROM todos`
+}
 
-	// Run the migration
-	migration := `
-		CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
-
-		CREATE TABLE IF NOT EXISTS todos (
-			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-			title VARCHAR(200) NOT NULL,
-			description TEXT,
-			completed BOOLEAN NOT NULL DEFAULT FALSE,
-			priority VARCHAR(10) CHECK (priority IN ('low', 'medium', 'high')) DEFAULT 'medium',
-			due_date TIMESTAMP,
-			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-		);
-
-		CREATE INDEX idx_todos_completed ON todos(completed);
-		CREATE INDEX idx_todos_priority ON todos(priority);
-		CREATE INDEX idx_todos_due_date ON todos(due_date);
-		CREATE INDEX idx_todos_created_at ON todos(created_at);
-
-		-- Function to automatically update the updated_at column
-		CREATE OR REPLACE FUNCTION update_updated_at_column()
-		RETURNS TRIGGER AS $$
-		BEGIN
-			NEW.updated_at = CURRENT_TIMESTAMP;
-			RETURN NEW;
-		END;
-		$$ language 'plpgsql';
-
-		-- Trigger to call the function before update
-		CREATE TRIGGER update_todos_updated_at BEFORE UPDATE ON todos
-			FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-	`
-
-	_, err = db.Exec(migration)
-	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			return fmt.Errorf("postgres error: %s", pqErr.Message)
-		}
-		return fmt.Errorf("failed to run migrations: %v", err)
+// scanTodo is the row scanner shared by every todo query. Columns left out by
+// a projection keep their zero value.
+func scanTodo(row pgx.CollectableRow) (*domain.Todo, error) {
+	return pgx.RowToAddrOfStructByNameLax[domain.Todo](row)
+}
+
+// reader returns where to read from: a healthy replica unless the context
+// is pinned to the primary, no replicas are configured or r runs in a transaction
+func (r *TodoRepository) reader(ctx context.Context) dbtx {
+	if r.replicas == nil {
+		return r.db
 	}
+	return r.replicas.Reader(ctx)
+}
 
-	return nil
+// startSpan starts a client span describing a single statement against table
+func startSpan(ctx context.Context, operation, table, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBCollectionName(table),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
 }
@@ -0,0 +1,71 @@
+package postgres_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/migrate"
+	"todo-app/internal/repository/postgres"
+	"todo-app/internal/repository/repotest"
+	"todo-app/migrations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// dsnEnv names the variable holding the DSN of a disposable test database;
+// its todos are deleted before each test
+const dsnEnv = "TEST_POSTGRES_DSN"
+
+func TestTodoRepository(t *testing.T) {
+	pool := openPool(t)
+	repotest.Run(t, func(t *testing.T) domain.TodoRepository {
+		truncate(t, pool)
+		return postgres.NewTodoRepository(pool)
+	})
+}
+
+func TestUnitOfWork(t *testing.T) {
+	pool := openPool(t)
+	repotest.RunUnitOfWork(t, func(t *testing.T) (domain.TodoRepository, domain.UnitOfWork) {
+		truncate(t, pool)
+		return postgres.NewTodoRepository(pool), postgres.NewUnitOfWork(pool)
+	})
+}
+
+// openPool connects to the test database and migrates it, skipping t when
+// no test database is configured
+func openPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("pgxpool.New: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+	migrator, err := migrate.New(db, migrate.Postgres, migrations.Postgres)
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	return pool
+}
+
+// truncate deletes every todo so each subtest starts empty
+func truncate(t *testing.T, pool *pgxpool.Pool) {
+	t.Helper()
+	if _, err := pool.Exec(context.Background(), "TRUNCATE todos"); err != nil {
+		t.Fatalf("TRUNCATE todos: %v", err)
+	}
+}
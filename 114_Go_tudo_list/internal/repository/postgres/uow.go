@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"todo-app/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// txAttempts is how many times a unit of work is run when it keeps conflicting
+const txAttempts = 3
+
+// UnitOfWork runs units of work in REPEATABLE READ transactions on the primary.
+// A transaction that conflicts with a concurrent one fails instead of losing
+// the other's write, and is retried from the start.
+type UnitOfWork struct {
+	pool *pgxpool.Pool
+}
+
+// NewUnitOfWork creates a new UnitOfWork
+func NewUnitOfWork(pool *pgxpool.Pool) *UnitOfWork {
+	return &UnitOfWork{
+		pool: pool,
+	}
+}
+
+// Do runs fn in a transaction, committing if it returns nil
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	var err error
+	for attempt := 1; attempt <= txAttempts; attempt++ {
+		err = pgx.BeginTxFunc(ctx, u.pool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead}, func(tx pgx.Tx) error {
+			return fn(ctx, domain.Repositories{
+				Todos: &TodoRepository{db: tx},
+			})
+		})
+		if !isConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isConflict reports whether err is a serialization failure or deadlock,
+// after which the whole transaction can safely be run again
+func isConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/telemetry"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// uniqueViolation is the PostgreSQL error code for unique constraint violations
+const uniqueViolation = "23505"
+
+// UserRepository implements the UserRepository interface for PostgreSQL
+type UserRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewUserRepository creates a new UserRepository
+func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
+	return &UserRepository{
+		pool: pool,
+	}
+}
+
+// Create creates a new user in the database
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) (err error) {
+	query := `
+		INSERT INTO users (id, email, password_hash, disabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	ctx, span := startSpan(ctx, "INSERT", "users", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	user.ID = uuid.New()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	_, err = r.pool.Exec(ctx, query,
+		user.ID,
+		user.Email,
+		user.PasswordHash,
+		user.Disabled,
+		user.CreatedAt,
+		user.UpdatedAt,
+	)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrUserExists
+		}
+		return fmt.Errorf("failed to create user: %v", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *domain.User, err error) {
+	query := `
+		SELECT id, email, password_hash, disabled, created_at, updated_at
+		FROM users
+		WHERE id = $1`
+
+	ctx, span := startSpan(ctx, "SELECT", "users", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	user := &domain.User{}
+	err = r.pool.QueryRow(ctx, query, id).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Disabled,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	return user, nil
+}
+
+// GetByEmail retrieves a user by email, ignoring case
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (_ *domain.User, err error) {
+	query := `
+		SELECT id, email, password_hash, disabled, created_at, updated_at
+		FROM users
+		WHERE LOWER(email) = LOWER($1)`
+
+	ctx, span := startSpan(ctx, "SELECT", "users", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	user := &domain.User{}
+	err = r.pool.QueryRow(ctx, query, email).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Disabled,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	return user, nil
+}
+
+// Update updates an existing user in the database
+func (r *UserRepository) Update(ctx context.Context, user *domain.User) (err error) {
+	query := `
+		UPDATE users
+		SET email = $2, password_hash = $3, disabled = $4, updated_at = $5
+		WHERE id = $1`
+
+	ctx, span := startSpan(ctx, "UPDATE", "users", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	user.UpdatedAt = time.Now()
+
+	tag, err := r.pool.Exec(ctx, query,
+		user.ID,
+		user.Email,
+		user.PasswordHash,
+		user.Disabled,
+		user.UpdatedAt,
+	)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrUserExists
+		}
+		return fmt.Errorf("failed to update user: %v", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
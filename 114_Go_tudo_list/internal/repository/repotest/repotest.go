@@ -0,0 +1,450 @@
+// Package repotest is a conformance suite for domain.TodoRepository
+// implementations. Backends run it from their todo_test.go; those needing a
+// server skip it unless a test database is configured:
+//
+//	repotest.Run(t, func(t *testing.T) domain.TodoRepository { return newEmptyRepo(t) })
+//
+// Backends with transactions also run RunUnitOfWork.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"todo-app/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Run runs the conformance suite. newRepo must return an empty repository for each subtest.
+func Run(t *testing.T, newRepo func(t *testing.T) domain.TodoRepository) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo domain.TodoRepository)
+	}{
+		{"CreateAssignsIDAndTimestamps", testCreate},
+		{"CreateMany", testCreateMany},
+		{"GetByIDRoundTrip", testGetByID},
+		{"GetByIDNotFound", testGetByIDNotFound},
+		{"GetByIDs", testGetByIDs},
+		{"GetAllNewestFirst", testGetAll},
+		{"Update", testUpdate},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"Delete", testDelete},
+		{"DeleteNotFound", testDeleteNotFound},
+		{"ToggleCompleted", testToggleCompleted},
+		{"ToggleCompletedConcurrent", testToggleCompletedConcurrent},
+		{"ToggleCompletedNotFound", testToggleCompletedNotFound},
+		{"GetByStatus", testGetByStatus},
+		{"Search", testSearch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func testCreate(t *testing.T, repo domain.TodoRepository) {
+	todo := create(t, repo, "Write report", "")
+	if todo.ID == uuid.Nil {
+		t.Fatal("Create did not assign an ID")
+	}
+	if todo.CreatedAt.IsZero() || todo.UpdatedAt.IsZero() {
+		t.Fatal("Create did not set timestamps")
+	}
+}
+
+func testCreateMany(t *testing.T, repo domain.TodoRepository) {
+	due := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	todos := []*domain.Todo{
+		{Title: "Import one", Priority: "low"},
+		{Title: "Import two", Description: "Second", Priority: "high", Completed: true, DueDate: &due},
+	}
+	if err := repo.CreateMany(context.Background(), todos); err != nil {
+		t.Fatalf("CreateMany: %v", err)
+	}
+
+	for _, want := range todos {
+		if want.ID == uuid.Nil || want.CreatedAt.IsZero() {
+			t.Fatalf("CreateMany did not assign an ID and timestamps to %q", want.Title)
+		}
+		got, err := repo.GetByID(context.Background(), want.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertEqual(t, got, want)
+	}
+}
+
+func testGetByID(t *testing.T, repo domain.TodoRepository) {
+	due := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := &domain.Todo{
+		Title:       "Book flight",
+		Description: "Window seat",
+		Priority:    "high",
+		DueDate:     &due,
+	}
+	if err := repo.Create(context.Background(), want); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := repo.GetByID(context.Background(), want.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	assertEqual(t, got, want)
+}
+
+func testGetByIDNotFound(t *testing.T, repo domain.TodoRepository) {
+	_, err := repo.GetByID(context.Background(), uuid.New())
+	if !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("GetByID of unknown id: got %v, want ErrTodoNotFound", err)
+	}
+}
+
+func testGetByIDs(t *testing.T, repo domain.TodoRepository) {
+	first := create(t, repo, "First", "")
+	create(t, repo, "Second", "")
+	third := create(t, repo, "Third", "")
+
+	got, err := repo.GetByIDs(context.Background(), []uuid.UUID{third.ID, uuid.New(), first.ID})
+	if err != nil {
+		t.Fatalf("GetByIDs: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetByIDs returned %d todos, want 2", len(got))
+	}
+	byID := map[uuid.UUID]*domain.Todo{got[0].ID: got[0], got[1].ID: got[1]}
+	for _, want := range []*domain.Todo{first, third} {
+		if byID[want.ID] == nil {
+			t.Fatalf("GetByIDs did not return %q", want.Title)
+		}
+		assertEqual(t, byID[want.ID], want)
+	}
+
+	got, err = repo.GetByIDs(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("GetByIDs of no ids: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("GetByIDs of no ids returned %d todos, want none", len(got))
+	}
+}
+
+func testGetAll(t *testing.T, repo domain.TodoRepository) {
+	first := create(t, repo, "First", "")
+	second := create(t, repo, "Second", "")
+	third := create(t, repo, "Third", "")
+
+	todos, err := repo.GetAll(context.Background())
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, todos, third, second, first)
+}
+
+func testUpdate(t *testing.T, repo domain.TodoRepository) {
+	todo := create(t, repo, "Draft", "")
+
+	due := time.Date(2031, 6, 7, 8, 0, 0, 0, time.UTC)
+	todo.Title = "Final"
+	todo.Description = "Reviewed"
+	todo.Completed = true
+	todo.Priority = "low"
+	todo.DueDate = &due
+	if err := repo.Update(context.Background(), todo); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got, err := repo.GetByID(context.Background(), todo.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	assertEqual(t, got, todo)
+	if got.UpdatedAt.Before(got.CreatedAt) {
+		t.Fatalf("UpdatedAt %v is before CreatedAt %v", got.UpdatedAt, got.CreatedAt)
+	}
+}
+
+func testUpdateNotFound(t *testing.T, repo domain.TodoRepository) {
+	err := repo.Update(context.Background(), &domain.Todo{ID: uuid.New(), Title: "Ghost", Priority: "low"})
+	if !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("Update of unknown id: got %v, want ErrTodoNotFound", err)
+	}
+}
+
+func testDelete(t *testing.T, repo domain.TodoRepository) {
+	todo := create(t, repo, "Temporary", "")
+	if err := repo.Delete(context.Background(), todo.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByID(context.Background(), todo.ID); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("GetByID after Delete: got %v, want ErrTodoNotFound", err)
+	}
+}
+
+func testDeleteNotFound(t *testing.T, repo domain.TodoRepository) {
+	err := repo.Delete(context.Background(), uuid.New())
+	if !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("Delete of unknown id: got %v, want ErrTodoNotFound", err)
+	}
+}
+
+func testToggleCompleted(t *testing.T, repo domain.TodoRepository) {
+	todo := create(t, repo, "Water plants", "")
+
+	toggled, err := repo.ToggleCompleted(context.Background(), todo.ID)
+	if err != nil {
+		t.Fatalf("ToggleCompleted: %v", err)
+	}
+	if !toggled.Completed || toggled.Title != todo.Title {
+		t.Fatalf("got %+v, want the todo completed", toggled)
+	}
+
+	got, err := repo.GetByID(context.Background(), todo.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if !got.Completed {
+		t.Fatal("toggle was not stored")
+	}
+}
+
+func testToggleCompletedConcurrent(t *testing.T, repo domain.TodoRepository) {
+	todo := create(t, repo, "Water plants", "")
+
+	// An even number of toggles must leave the todo as it was
+	const toggles = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, toggles)
+	for range toggles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.ToggleCompleted(context.Background(), todo.ID)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("ToggleCompleted: %v", err)
+		}
+	}
+
+	got, err := repo.GetByID(context.Background(), todo.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Completed {
+		t.Fatal("concurrent toggles lost an update")
+	}
+}
+
+func testToggleCompletedNotFound(t *testing.T, repo domain.TodoRepository) {
+	_, err := repo.ToggleCompleted(context.Background(), uuid.New())
+	if !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("got %v, want ErrTodoNotFound", err)
+	}
+}
+
+// RunUnitOfWork checks that a unit of work commits when its function succeeds,
+// rolls back when it fails and is isolated from concurrent units of work. newUoW must return an empty repository and a
+// unit of work over the same storage for each subtest.
+func RunUnitOfWork(t *testing.T, newUoW func(t *testing.T) (domain.TodoRepository, domain.UnitOfWork)) {
+	t.Run("Commit", func(t *testing.T) {
+		repo, uow := newUoW(t)
+		todo := create(t, repo, "Old title", "")
+
+		err := uow.Do(context.Background(), func(ctx context.Context, repos domain.Repositories) error {
+			got, err := repos.Todos.GetByID(ctx, todo.ID)
+			if err != nil {
+				return err
+			}
+			got.Title = "New title"
+			return repos.Todos.Update(ctx, got)
+		})
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+
+		got, err := repo.GetByID(context.Background(), todo.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.Title != "New title" {
+			t.Fatalf("title: got %q, want the committed update", got.Title)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		repo, uow := newUoW(t)
+		todo := create(t, repo, "Old title", "")
+		errAbort := errors.New("abort")
+
+		err := uow.Do(context.Background(), func(ctx context.Context, repos domain.Repositories) error {
+			if err := repos.Todos.Create(ctx, &domain.Todo{Title: "Never stored", Priority: "low"}); err != nil {
+				return err
+			}
+			if _, err := repos.Todos.ToggleCompleted(ctx, todo.ID); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("Do: got %v, want the function's error", err)
+		}
+
+		todos, err := repo.GetAll(context.Background())
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		assertIDs(t, todos, todo)
+		if todos[0].Completed {
+			t.Fatal("toggle inside the failed unit of work was kept")
+		}
+	})
+
+	t.Run("ConcurrentReadModifyWrite", func(t *testing.T) {
+		repo, uow := newUoW(t)
+		todo := create(t, repo, "Counter", "0")
+
+		// Each unit of work reads the counter and writes it back incremented.
+		// A backend may give up on a unit that keeps conflicting, but every
+		// unit that committed must be counted: none may overwrite another.
+		const workers, rounds = 4, 5
+		var committed atomic.Int64
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range rounds {
+					err := uow.Do(context.Background(), func(ctx context.Context, repos domain.Repositories) error {
+						got, err := repos.Todos.GetByID(ctx, todo.ID)
+						if err != nil {
+							return err
+						}
+						n, err := strconv.Atoi(got.Description)
+						if err != nil {
+							return err
+						}
+						got.Description = strconv.Itoa(n + 1)
+						return repos.Todos.Update(ctx, got)
+					})
+					if err == nil {
+						committed.Add(1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		got, err := repo.GetByID(context.Background(), todo.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if committed.Load() == 0 {
+			t.Fatal("no unit of work committed")
+		}
+		if want := strconv.FormatInt(committed.Load(), 10); got.Description != want {
+			t.Fatalf("counter: got %s after %s committed increments; an update was lost", got.Description, want)
+		}
+	})
+}
+
+func testGetByStatus(t *testing.T, repo domain.TodoRepository) {
+	open := create(t, repo, "Open", "")
+	done := create(t, repo, "Done", "")
+	done.Completed = true
+	if err := repo.Update(context.Background(), done); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	completed, err := repo.GetByStatus(context.Background(), true)
+	if err != nil {
+		t.Fatalf("GetByStatus(true): %v", err)
+	}
+	assertIDs(t, completed, done)
+
+	pending, err := repo.GetByStatus(context.Background(), false)
+	if err != nil {
+		t.Fatalf("GetByStatus(false): %v", err)
+	}
+	assertIDs(t, pending, open)
+}
+
+func testSearch(t *testing.T, repo domain.TodoRepository) {
+	groceries := create(t, repo, "Buy groceries", "Milk and eggs")
+	report := create(t, repo, "Write report", "Quarterly numbers")
+	create(t, repo, "Call plumber", "")
+	milk := create(t, repo, "Return milk crates", "")
+
+	cases := []struct {
+		query string
+		want  []*domain.Todo
+	}{
+		{"groceries", []*domain.Todo{groceries}},
+		{"QUARTERLY", []*domain.Todo{report}},
+		{"milk", []*domain.Todo{milk, groceries}},
+		{"milk eggs", []*domain.Todo{groceries}},
+		{"dentist", nil},
+		{`"unbalanced`, nil},
+		{"", nil},
+	}
+	for _, c := range cases {
+		todos, err := repo.Search(context.Background(), c.query)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", c.query, err)
+		}
+		assertIDs(t, todos, c.want...)
+	}
+}
+
+// create stores a medium-priority todo and fails the test on error
+func create(t *testing.T, repo domain.TodoRepository, title, description string) *domain.Todo {
+	t.Helper()
+	todo := &domain.Todo{Title: title, Description: description, Priority: "medium"}
+	if err := repo.Create(context.Background(), todo); err != nil {
+		t.Fatalf("Create(%q): %v", title, err)
+	}
+	// Keep created_at strictly increasing so ordering is deterministic
+	time.Sleep(2 * time.Millisecond)
+	return todo
+}
+
+func assertIDs(t *testing.T, got []*domain.Todo, want ...*domain.Todo) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d todos, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID {
+			t.Fatalf("todo %d: got %q, want %q", i, got[i].Title, want[i].Title)
+		}
+	}
+}
+
+func assertEqual(t *testing.T, got, want *domain.Todo) {
+	t.Helper()
+	if got.ID != want.ID || got.Title != want.Title || got.Description != want.Description ||
+		got.Completed != want.Completed || got.Priority != want.Priority {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if (got.DueDate == nil) != (want.DueDate == nil) ||
+		got.DueDate != nil && !got.DueDate.Equal(*want.DueDate) {
+		t.Fatalf("due date: got %v, want %v", got.DueDate, want.DueDate)
+	}
+	// Backends may store timestamps with reduced precision
+	if got.CreatedAt.Sub(want.CreatedAt).Abs() > time.Millisecond {
+		t.Fatalf("created_at: got %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+}
@@ -0,0 +1,145 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/resilience"
+
+	"github.com/google/uuid"
+)
+
+// TodoRepository decorates a domain.TodoRepository with a circuit breaker and
+// retries reads that fail with transient errors. Writes are never retried
+// because a failed write may already have been applied.
+type TodoRepository struct {
+	next      domain.TodoRepository
+	breaker   *resilience.Breaker
+	backoff   resilience.Backoff
+	transient func(error) bool
+}
+
+// NewTodoRepository wraps next. transient reports which read errors are worth retrying with backoff.
+func NewTodoRepository(next domain.TodoRepository, breaker *resilience.Breaker, backoff resilience.Backoff, transient func(error) bool) *TodoRepository {
+	return &TodoRepository{
+		next:      next,
+		breaker:   breaker,
+		backoff:   backoff,
+		transient: transient,
+	}
+}
+
+// IsFailure reports whether err means storage is failing, as opposed to a
+// client that went away or a domain error such as a missing todo, an invalid
+// field or a patch conflict. Units of work return the errors of the service
+// code they run, so only driver and connection errors may trip the breaker.
+func IsFailure(err error) bool {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		switch domainErr.Kind {
+		case domain.KindInvalid, domain.KindConflict, domain.KindNotFound:
+			return false
+		}
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// Create creates a todo
+func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.next.Create(ctx, todo)
+	})
+}
+
+// CreateMany creates several todos
+func (r *TodoRepository) CreateMany(ctx context.Context, todos []*domain.Todo) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.next.CreateMany(ctx, todos)
+	})
+}
+
+// GetByID retrieves a todo by its ID
+func (r *TodoRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	return read(ctx, r, func(ctx context.Context) (*domain.Todo, error) {
+		return r.next.GetByID(ctx, id)
+	})
+}
+
+// GetByIDs retrieves several todos by their IDs
+func (r *TodoRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Todo, error) {
+	return read(ctx, r, func(ctx context.Context) ([]*domain.Todo, error) {
+		return r.next.GetByIDs(ctx, ids)
+	})
+}
+
+// GetAll retrieves all todos
+func (r *TodoRepository) GetAll(ctx context.Context) ([]*domain.Todo, error) {
+	return read(ctx, r, r.next.GetAll)
+}
+
+// Update updates a todo
+func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.next.Update(ctx, todo)
+	})
+}
+
+// ToggleCompleted toggles a todo's completed flag
+func (r *TodoRepository) ToggleCompleted(ctx context.Context, id uuid.UUID) (todo *domain.Todo, err error) {
+	err = r.call(ctx, func(ctx context.Context) error {
+		todo, err = r.next.ToggleCompleted(ctx, id)
+		return err
+	})
+	return todo, err
+}
+
+// Delete deletes a todo
+func (r *TodoRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.next.Delete(ctx, id)
+	})
+}
+
+// GetByStatus retrieves todos by their completion status
+func (r *TodoRepository) GetByStatus(ctx context.Context, completed bool) ([]*domain.Todo, error) {
+	return read(ctx, r, func(ctx context.Context) ([]*domain.Todo, error) {
+		return r.next.GetByStatus(ctx, completed)
+	})
+}
+
+// Search runs a full-text search
+func (r *TodoRepository) Search(ctx context.Context, query string) ([]*domain.Todo, error) {
+	return read(ctx, r, func(ctx context.Context) ([]*domain.Todo, error) {
+		return r.next.Search(ctx, query)
+	})
+}
+
+// read runs load through the breaker, retrying transient errors. All attempts
+// count as a single call to the breaker.
+func read[T any](ctx context.Context, r *TodoRepository, load func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := r.call(ctx, func(ctx context.Context) error {
+		return resilience.Retry(ctx, r.backoff, r.transient, func(ctx context.Context) error {
+			var err error
+			result, err = load(ctx)
+			return err
+		})
+	})
+	return result, err
+}
+
+// call runs fn through the breaker
+func (r *TodoRepository) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	return call(ctx, r.breaker, fn)
+}
+
+// call runs fn through breaker and reports a rejected call as a domain.UnavailableError
+func call(ctx context.Context, breaker *resilience.Breaker, fn func(ctx context.Context) error) error {
+	err := breaker.Do(ctx, fn)
+	var open *resilience.OpenError
+	if errors.As(err, &open) {
+		return &domain.UnavailableError{RetryAfter: open.RetryAfter}
+	}
+	return err
+}
@@ -0,0 +1,30 @@
+package resilient
+
+import (
+	"context"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/resilience"
+)
+
+// UnitOfWork runs each unit of work through the circuit breaker as one call.
+// Units of work are never retried here: the backend already retries conflicts.
+type UnitOfWork struct {
+	next    domain.UnitOfWork
+	breaker *resilience.Breaker
+}
+
+// NewUnitOfWork wraps next with breaker
+func NewUnitOfWork(next domain.UnitOfWork, breaker *resilience.Breaker) *UnitOfWork {
+	return &UnitOfWork{
+		next:    next,
+		breaker: breaker,
+	}
+}
+
+// Do runs fn through the wrapped unit of work unless the breaker is open
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos domain.Repositories) error) error {
+	return call(ctx, u.breaker, func(ctx context.Context) error {
+		return u.next.Do(ctx, fn)
+	})
+}
@@ -0,0 +1,468 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/projection"
+	"todo-app/internal/telemetry"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite"
+)
+
+var tracer = otel.Tracer("todo-app/internal/repository/sqlite")
+
+// timeFormat is fixed-width UTC so that stored timestamps sort chronologically as text
+const timeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// todoColumns are the todo columns in the order every query reads them
+var todoColumns = []string{"id", "title", "description", "completed", "priority", "due_date", "created_at", "updated_at"}
+
+const insertTodo = `
+		INSERT INTO todos (id, title, description, completed, priority, due_date, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+// dbtx is the part of *sql.DB and *sql.Tx the repositories use, so they
+// run the same queries on the database or inside a unit of work
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// TodoRepository implements the TodoRepository interface for SQLite
+type TodoRepository struct {
+	db dbtx
+}
+
+// NewTodoRepository creates a new TodoRepository
+func NewTodoRepository(db *sql.DB) *TodoRepository {
+	return &TodoRepository{
+		db: db,
+	}
+}
+
+// Create creates a new todo in the database
+func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) (err error) {
+	query := insertTodo
+
+	ctx, span := startSpan(ctx, "INSERT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo.ID = uuid.New()
+	todo.CreatedAt = time.Now()
+	todo.UpdatedAt = todo.CreatedAt
+
+	_, err = r.db.ExecContext(ctx, query,
+		todo.ID.String(),
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		formatDueDate(todo.DueDate),
+		formatTime(todo.CreatedAt),
+		formatTime(todo.UpdatedAt),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+
+	return nil
+}
+
+// CreateMany creates todos in one transaction using a single prepared insert
+func (r *TodoRepository) CreateMany(ctx context.Context, todos []*domain.Todo) (err error) {
+	ctx, span := startSpan(ctx, "INSERT", "todos", insertTodo)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	return r.inTx(ctx, func(tx dbtx) error {
+		return createMany(ctx, tx, todos)
+	})
+}
+
+// createMany inserts todos with one prepared statement
+func createMany(ctx context.Context, tx dbtx, todos []*domain.Todo) error {
+	stmt, err := tx.PrepareContext(ctx, insertTodo)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, todo := range todos {
+		todo.ID = uuid.New()
+		todo.CreatedAt = now
+		todo.UpdatedAt = now
+
+		_, err = stmt.ExecContext(ctx,
+			todo.ID.String(),
+			todo.Title,
+			todo.Description,
+			todo.Completed,
+			todo.Priority,
+			formatDueDate(todo.DueDate),
+			formatTime(todo.CreatedAt),
+			formatTime(todo.UpdatedAt),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create todo: %w", err)
+		}
+	}
+	return nil
+}
+
+// inTx runs fn in a new transaction, or in the current one if r already runs inside a unit of work
+func (r *TodoRepository) inTx(ctx context.Context, fn func(tx dbtx) error) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return fn(r.db)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a todo by its ID
+func (r *TodoRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *domain.Todo, err error) {
+	query := selectColumns(ctx) + `
+		WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo, err := scanTodo(r.db.QueryRowContext(ctx, query, id.String()), projection.Columns(ctx, todoColumns))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	return todo, nil
+}
+
+// GetByIDs retrieves the todos with the given IDs in one query
+func (r *TodoRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (_ []*domain.Todo, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query := selectColumns(ctx) + `
+		WHERE id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id.String()
+	}
+	todos, err := r.list(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+	return todos, nil
+}
+
+// GetAll retrieves all todos from the database
+func (r *TodoRepository) GetAll(ctx context.Context) (_ []*domain.Todo, err error) {
+	query := selectColumns(ctx) + `
+		ORDER BY created_at DESC`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todos, err := r.list(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all todos: %w", err)
+	}
+	return todos, nil
+}
+
+// Update updates an existing todo in the database
+func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) (err error) {
+	query := `
+		UPDATE todos
+		SET title = ?, description = ?, completed = ?, priority = ?, due_date = ?, updated_at = ?
+		WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "UPDATE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		formatDueDate(todo.DueDate),
+		formatTime(todo.UpdatedAt),
+		todo.ID.String(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrTodoNotFound
+	}
+
+	return nil
+}
+
+// ToggleCompleted flips the completed flag in a single statement, so
+// concurrent toggles never both write the same value
+func (r *TodoRepository) ToggleCompleted(ctx context.Context, id uuid.UUID) (_ *domain.Todo, err error) {
+	query := `
+		UPDATE todos
+		SET completed = NOT completed, updated_at = ?
+		WHERE id = ?
+		RETURNING id, title, description, completed, priority, due_date, created_at, updated_at`
+
+	ctx, span := startSpan(ctx, "UPDATE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todo, err := scanTodo(r.db.QueryRowContext(ctx, query, formatTime(time.Now()), id.String()), todoColumns)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to toggle todo: %w", err)
+	}
+
+	return todo, nil
+}
+
+// Delete deletes a todo from the database
+func (r *TodoRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	query := `DELETE FROM todos WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "DELETE", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, query, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrTodoNotFound
+	}
+
+	return nil
+}
+
+// GetByStatus retrieves todos by their completion status
+func (r *TodoRepository) GetByStatus(ctx context.Context, completed bool) (_ []*domain.Todo, err error) {
+	query := selectColumns(ctx) + `
+		WHERE completed = ?
+		ORDER BY created_at DESC`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todos, err := r.list(ctx, query, completed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos by status: %w", err)
+	}
+	return todos, nil
+}
+
+// Search runs an FTS5 full-text search over title and description.
+// Every word of q must match; words are quoted so FTS5 operators in user input are taken literally.
+func (r *TodoRepository) Search(ctx context.Context, q string) (_ []*domain.Todo, err error) {
+	words := strings.Fields(q)
+	if len(words) == 0 {
+		return nil, nil
+	}
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+
+	query := selectColumns(ctx) + `
+		JOIN todos_fts ON todos_fts.rowid = todos.rowid
+		WHERE todos_fts MATCH ?
+		ORDER BY todos.created_at DESC`
+
+	ctx, span := startSpan(ctx, "SELECT", "todos", query)
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	todos, err := r.list(ctx, query, strings.Join(words, " "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+	return todos, nil
+}
+
+// list runs a query returning todo rows
+func (r *TodoRepository) list(ctx context.Context, query string, args ...any) ([]*domain.Todo, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := projection.Columns(ctx, todoColumns)
+	var todos []*domain.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows, columns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating todos: %w", err)
+	}
+
+	return todos, nil
+}
+
+// selectColumns starts a query reading the todo columns ctx needs
+func selectColumns(ctx context.Context) string {
+	columns := projection.Columns(ctx, todoColumns)
+	qualified := make([]string, len(columns))
+	for i, column := range columns {
+		qualified[i] = "todos." + column
+	}
+	return `
+		SELECT ` + strings.Join(qualified, ", ") + `
+		FROM todos`
+}
+
+// scanTodo reads one todo row holding columns, converting the text columns
+// back to Go types. Other columns keep their zero value.
+func scanTodo(row interface{ Scan(...any) error }, columns []string) (*domain.Todo, error) {
+	var (
+		todo                 domain.Todo
+		id                   string
+		dueDate              sql.NullString
+		createdAt, updatedAt string
+		err                  error
+	)
+	fields := map[string]any{
+		"id":          &id,
+		"title":       &todo.Title,
+		"description": &todo.Description,
+		"completed":   &todo.Completed,
+		"priority":    &todo.Priority,
+		"due_date":    &dueDate,
+		"created_at":  &createdAt,
+		"updated_at":  &updatedAt,
+	}
+	dest := make([]any, len(columns))
+	for i, column := range columns {
+		dest[i] = fields[column]
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if todo.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid id %q: %w", id, err)
+	}
+	if dueDate.Valid {
+		due, err := time.Parse(timeFormat, dueDate.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due_date %q: %w", dueDate.String, err)
+		}
+		todo.DueDate = &due
+	}
+	if createdAt != "" {
+		if todo.CreatedAt, err = time.Parse(timeFormat, createdAt); err != nil {
+			return nil, fmt.Errorf("invalid created_at %q: %w", createdAt, err)
+		}
+	}
+	if updatedAt != "" {
+		if todo.UpdatedAt, err = time.Parse(timeFormat, updatedAt); err != nil {
+			return nil, fmt.Errorf("invalid updated_at %q: %w", updatedAt, err)
+		}
+	}
+
+	return &todo, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+func formatDueDate(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
+
+// startSpan starts a client span describing a single statement against table
+func startSpan(ctx context.Context, operation, table, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameSQLite,
+			semconv.DBCollectionName(table),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// Open opens the SQLite database at path in WAL mode, creating it if needed.
+// Transactions take the write lock when they begin, so a unit of work that
+// reads before writing never races another writer.
+func Open(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Set("_txlock", "immediate")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "foreign_keys(ON)")
+
+	// Escape characters that would end the path part of the file: URI
+	path = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,46 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"todo-app/internal/domain"
+	"todo-app/internal/migrate"
+	"todo-app/internal/repository/repotest"
+	"todo-app/internal/repository/sqlite"
+	"todo-app/migrations"
+)
+
+func TestTodoRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) domain.TodoRepository {
+		return sqlite.NewTodoRepository(openDB(t))
+	})
+}
+
+func TestUnitOfWork(t *testing.T) {
+	repotest.RunUnitOfWork(t, func(t *testing.T) (domain.TodoRepository, domain.UnitOfWork) {
+		db := openDB(t)
+		return sqlite.NewTodoRepository(db), sqlite.NewUnitOfWork(db)
+	})
+}
+
+// openDB opens a migrated database in a temporary file, closed when t ends
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "todos.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrate.SQLite, migrations.SQLite)
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,147 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"todo-app/internal/logging"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// Closed lets every call through
+	Closed State = iota
+	// Open rejects every call until the cooldown has passed
+	Open
+	// HalfOpen lets a single probe call through to decide whether to close again
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	default:
+		return "half-open"
+	}
+}
+
+// OpenError is returned by Breaker.Do when the call was rejected without running
+type OpenError struct {
+	Name string
+	// RetryAfter is how long until the breaker lets a call through again
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %s is open, retry in %s", e.Name, e.RetryAfter.Round(time.Second))
+}
+
+// Breaker is a circuit breaker. After threshold consecutive failed calls it
+// opens and rejects calls for cooldown, then lets one probe through: if the
+// probe succeeds it closes, otherwise it opens for another cooldown.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	isFailure func(error) bool
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewBreaker creates a closed Breaker. isFailure decides which errors count
+// towards threshold; errors it rejects are returned but count as successes.
+func NewBreaker(name string, threshold int, cooldown time.Duration, isFailure func(error) bool) *Breaker {
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		isFailure: isFailure,
+	}
+}
+
+// Do runs fn unless the breaker is open, in which case it returns an *OpenError
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if wait, ok := b.allow(); !ok {
+		return &OpenError{Name: b.name, RetryAfter: wait}
+	}
+	err := fn(ctx)
+	b.record(err != nil && b.isFailure(err))
+	return err
+}
+
+// BreakerStatus describes the current state of a Breaker
+type BreakerStatus struct {
+	State               string  `json:"state"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	RetryAfterSecs      float64 `json:"retry_after_secs,omitempty"`
+}
+
+// Status returns the current state of the breaker
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.state.String(), ConsecutiveFailures: b.failures}
+	if b.state == Open {
+		status.RetryAfterSecs = max(time.Until(b.openUntil), 0).Seconds()
+	}
+	return status
+}
+
+// allow reports whether a call may run, or how long the caller should wait
+func (b *Breaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		return 0, true
+	case Open:
+		if wait := time.Until(b.openUntil); wait > 0 {
+			return wait, false
+		}
+		b.state = HalfOpen
+	}
+
+	// Half-open: only one probe at a time
+	if b.probing {
+		return time.Second, false
+	}
+	b.probing = true
+	return 0, true
+}
+
+// record updates the breaker with the outcome of a call
+func (b *Breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		if b.state != Closed {
+			logging.Infof("Circuit breaker %s closed", b.name)
+		}
+		b.state = Closed
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.state == HalfOpen || (b.state == Closed && b.failures >= b.threshold) {
+		b.state = Open
+		b.openUntil = time.Now().Add(b.cooldown)
+		b.probing = false
+		logging.Warnf("Circuit breaker %s opened after %d consecutive failures, retrying in %s", b.name, b.failures, b.cooldown)
+	}
+}
@@ -0,0 +1,64 @@
+package resilience
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff describes how an operation is retried. Waits grow exponentially
+// from Initial up to Max, with random jitter so instances don't retry in step.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	// Attempts limits the number of calls, including the first; zero means no limit
+	Attempts int
+	// Timeout stops retrying once this much time has passed since the first call; zero means no limit
+	Timeout time.Duration
+	// ResetAfter starts the waits again from Initial after a call that ran at
+	// least this long before failing; zero never resets them
+	ResetAfter time.Duration
+	// OnRetry, if set, is called before each wait
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// Retry calls fn until it succeeds, fails with an error retryable rejects, the
+// backoff is exhausted or ctx is done, and returns fn's last error
+func Retry(ctx context.Context, b Backoff, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	initial := max(b.Initial, time.Millisecond)
+	ceiling := initial
+	for attempt := 1; ; attempt++ {
+		called := time.Now()
+		err := fn(ctx)
+		if err == nil || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if b.Attempts > 0 && attempt >= b.Attempts {
+			return err
+		}
+		if b.ResetAfter > 0 && time.Since(called) >= b.ResetAfter {
+			ceiling = initial
+		}
+
+		// Wait between half and all of the current ceiling
+		wait := ceiling/2 + rand.N(ceiling/2+1)
+		if b.Timeout > 0 && time.Since(start)+wait > b.Timeout {
+			return err
+		}
+		if b.OnRetry != nil {
+			b.OnRetry(attempt, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if b.Max > 0 {
+			ceiling = min(ceiling*2, b.Max)
+		}
+	}
+}
@@ -1,33 +1,47 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"todo-app/internal/domain"
+	"todo-app/internal/telemetry"
 )
 
+var tracer = otel.Tracer("todo-app/internal/service")
+
 // TodoService implements the TodoService interface
 type TodoService struct {
 	todoRepo domain.TodoRepository
+	uow      domain.UnitOfWork
 }
 
-// NewTodoService creates a new TodoService
-func NewTodoService(todoRepo domain.TodoRepository) *TodoService {
+// NewTodoService creates a new TodoService. Operations spanning several
+// repository calls run through uow so they see and write a consistent state.
+func NewTodoService(todoRepo domain.TodoRepository, uow domain.UnitOfWork) *TodoService {
 	return &TodoService{
 		todoRepo: todoRepo,
+		uow:      uow,
 	}
 }
 
 // CreateTodo creates a new todo item
-func (s *TodoService) CreateTodo(title, description, priority string, dueDate *time.Time) (*domain.Todo, error) {
+func (s *TodoService) CreateTodo(ctx context.Context, title, description, priority string, dueDate *time.Time) (todo *domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.CreateTodo")
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	// Set default priority if not provided
 	if priority == "" {
 		priority = "medium"
 	}
 
 	// Create the todo
-	todo := &domain.Todo{
+	todo = &domain.Todo{
 		Title:       title,
 		Description: description,
 		Completed:   false,
@@ -41,53 +55,109 @@ func (s *TodoService) CreateTodo(title, description, priority string, dueDate *t
 	}
 
 	// Save to repository
-	if err := s.todoRepo.Create(todo); err != nil {
+	if err := s.todoRepo.Create(ctx, todo); err != nil {
 		return nil, err
 	}
 
+	span.SetAttributes(attribute.String("todo.id", todo.ID.String()))
 	return todo, nil
 }
 
 // GetTodo retrieves a todo by its ID
-func (s *TodoService) GetTodo(id uuid.UUID) (*domain.Todo, error) {
-	return s.todoRepo.GetByID(id)
+func (s *TodoService) GetTodo(ctx context.Context, id uuid.UUID) (todo *domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.GetTodo")
+	defer func() { telemetry.EndSpan(span, err) }()
+	span.SetAttributes(attribute.String("todo.id", id.String()))
+
+	return s.todoRepo.GetByID(ctx, id)
+}
+
+// GetTodosByIDs retrieves several todo items in one read. Missing IDs are left out.
+func (s *TodoService) GetTodosByIDs(ctx context.Context, ids []uuid.UUID) (todos []*domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.GetTodosByIDs")
+	defer func() { telemetry.EndSpan(span, err) }()
+	span.SetAttributes(attribute.Int("todo.count", len(ids)))
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return s.todoRepo.GetByIDs(ctx, ids)
 }
 
 // GetAllTodos retrieves all todo items
-func (s *TodoService) GetAllTodos() ([]*domain.Todo, error) {
-	return s.todoRepo.GetAll()
+func (s *TodoService) GetAllTodos(ctx context.Context) (todos []*domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.GetAllTodos")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	return s.todoRepo.GetAll(ctx)
 }
 
-// UpdateTodo updates an existing todo item
-func (s *TodoService) UpdateTodo(id uuid.UUID, title, description, priority string, completed bool, dueDate *time.Time) (*domain.Todo, error) {
-	// Get the existing todo
-	todo, err := s.todoRepo.GetByID(id)
-	if err != nil {
-		return nil, err
+// UpdateTodo replaces every field of an existing todo item. An empty priority
+// is medium and a nil dueDate removes the due date.
+func (s *TodoService) UpdateTodo(ctx context.Context, id uuid.UUID, title, description, priority string, completed bool, dueDate *time.Time) (todo *domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.UpdateTodo")
+	defer func() { telemetry.EndSpan(span, err) }()
+	span.SetAttributes(attribute.String("todo.id", id.String()))
+
+	if priority == "" {
+		priority = "medium"
 	}
 
-	// Update fields if provided
-	if title != "" {
+	// Read and write in one transaction so the todo cannot be deleted in between
+	err = s.uow.Do(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		// Get the existing todo
+		todo, err = repos.Todos.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
 		todo.Title = title
-	}
-	if description != "" {
 		todo.Description = description
-	}
-	if priority != "" {
 		todo.Priority = priority
-	}
-	todo.Completed = completed
-	if dueDate != nil {
+		todo.Completed = completed
 		todo.DueDate = dueDate
-	}
 
-	// Validate the updated todo
-	if err := todo.Validate(); err != nil {
+		// Validate the updated todo
+		if err := todo.Validate(); err != nil {
+			return err
+		}
+
+		// Update in repository
+		return repos.Todos.Update(ctx, todo)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Update in repository
-	if err := s.todoRepo.Update(todo); err != nil {
+	return todo, nil
+}
+
+// PatchTodo changes the fields of a todo that the patch computed by patcher
+// sets. The patch is computed from the todo read in the same transaction, so
+// a concurrent change cannot slip in between a JSON Patch test and the write.
+func (s *TodoService) PatchTodo(ctx context.Context, id uuid.UUID, patcher domain.TodoPatcher) (todo *domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.PatchTodo")
+	defer func() { telemetry.EndSpan(span, err) }()
+	span.SetAttributes(attribute.String("todo.id", id.String()))
+
+	err = s.uow.Do(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		todo, err = repos.Todos.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		patch, err := patcher.Patch(todo)
+		if err != nil {
+			return err
+		}
+		patch.Apply(todo)
+
+		if err := todo.Validate(); err != nil {
+			return err
+		}
+		return repos.Todos.Update(ctx, todo)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -95,30 +165,65 @@ func (s *TodoService) UpdateTodo(id uuid.UUID, title, description, priority stri
 }
 
 // DeleteTodo deletes a todo item
-func (s *TodoService) DeleteTodo(id uuid.UUID) error {
-	return s.todoRepo.Delete(id)
+func (s *TodoService) DeleteTodo(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.DeleteTodo")
+	defer func() { telemetry.EndSpan(span, err) }()
+	span.SetAttributes(attribute.String("todo.id", id.String()))
+
+	return s.todoRepo.Delete(ctx, id)
 }
 
 // GetTodosByStatus retrieves todos filtered by completion status
-func (s *TodoService) GetTodosByStatus(completed bool) ([]*domain.Todo, error) {
-	return s.todoRepo.GetByStatus(completed)
+func (s *TodoService) GetTodosByStatus(ctx context.Context, completed bool) (todos []*domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.GetTodosByStatus")
+	defer func() { telemetry.EndSpan(span, err) }()
+	span.SetAttributes(attribute.Bool("todo.completed", completed))
+
+	return s.todoRepo.GetByStatus(ctx, completed)
 }
 
-// ToggleComplete toggles the completion status of a todo
-func (s *TodoService) ToggleComplete(id uuid.UUID) (*domain.Todo, error) {
-	// Get the existing todo
-	todo, err := s.todoRepo.GetByID(id)
-	if err != nil {
-		return nil, err
+// SearchTodos runs a full-text search over todo titles and descriptions
+func (s *TodoService) SearchTodos(ctx context.Context, query string) (todos []*domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.SearchTodos")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
 	}
+	return s.todoRepo.Search(ctx, query)
+}
 
-	// Toggle the completed status
-	todo.Completed = !todo.Completed
+// ImportTodos validates and stores a batch of todos in one bulk write, keeping
+// their completion status. IDs and timestamps are assigned by the repository.
+// It returns how many todos were stored.
+func (s *TodoService) ImportTodos(ctx context.Context, todos []*domain.Todo) (imported int, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.ImportTodos")
+	defer func() { telemetry.EndSpan(span, err) }()
+	span.SetAttributes(attribute.Int("todo.count", len(todos)))
+
+	for i, todo := range todos {
+		if todo.Priority == "" {
+			todo.Priority = "medium"
+		}
+		if err := todo.Validate(); err != nil {
+			return imported, fmt.Errorf("todo %d: %w", i+1, err)
+		}
+	}
 
-	// Update in repository
-	if err := s.todoRepo.Update(todo); err != nil {
-		return nil, err
+	if err := s.todoRepo.CreateMany(ctx, todos); err != nil {
+		return 0, err
 	}
 
-	return todo, nil
+	return len(todos), nil
+}
+
+// ToggleComplete toggles the completion status of a todo
+func (s *TodoService) ToggleComplete(ctx context.Context, id uuid.UUID) (todo *domain.Todo, err error) {
+	ctx, span := tracer.Start(ctx, "TodoService.ToggleComplete")
+	defer func() { telemetry.EndSpan(span, err) }()
+	span.SetAttributes(attribute.String("todo.id", id.String()))
+
+	// A single atomic update, so concurrent toggles never both write the same value
+	return s.todoRepo.ToggleCompleted(ctx, id)
 }
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/crypto/bcrypt"
+	"todo-app/internal/domain"
+	"todo-app/internal/telemetry"
+)
+
+// minPasswordLength is the shortest password accepted for a user
+const minPasswordLength = 8
+
+// UserService implements the UserService interface
+type UserService struct {
+	userRepo domain.UserRepository
+}
+
+// NewUserService creates a new UserService
+func NewUserService(userRepo domain.UserRepository) *UserService {
+	return &UserService{
+		userRepo: userRepo,
+	}
+}
+
+// CreateUser creates a new user with a bcrypt-hashed password
+func (s *UserService) CreateUser(ctx context.Context, email, password string) (user *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.CreateUser")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	user = &domain.User{Email: strings.TrimSpace(email)}
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = hash
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("user.id", user.ID.String()))
+	return user, nil
+}
+
+// DisableUser marks a user as disabled so they can no longer sign in
+func (s *UserService) DisableUser(ctx context.Context, email string) (user *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.DisableUser")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	user, err = s.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+
+	user.Disabled = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ResetPassword replaces a user's password
+func (s *UserService) ResetPassword(ctx context.Context, email, password string) (user *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.ResetPassword")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	user, err = s.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = hash
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// hashPassword validates and bcrypt-hashes a plain-text password
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", domain.ErrPasswordTooShort
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,16 @@
+package telemetry
+
+import "expvar"
+
+// Metrics holds the counters of the application, keyed by the name each
+// package registers with NewMetrics, and is served on /debug/vars. It is kept
+// out of the global expvar registry, whose handler also publishes cmdline: the
+// command line may carry secrets such as --jwt.secret.
+var Metrics = new(expvar.Map)
+
+// NewMetrics returns an empty map of counters published in Metrics as name
+func NewMetrics(name string) *expvar.Map {
+	m := new(expvar.Map)
+	Metrics.Set(name, m)
+	return m
+}
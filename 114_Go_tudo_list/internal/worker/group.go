@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/resilience"
+)
+
+// Func is a background job that runs until its context is cancelled
+type Func func(ctx context.Context) error
+
+// Status describes the current state of a single worker. Error is set only
+// while the worker is down waiting to be restarted; LastError keeps the most
+// recent failure after it has recovered.
+type Status struct {
+	Running   bool      `json:"running"`
+	StartedAt time.Time `json:"started_at"`
+	Restarts  int       `json:"restarts,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Group runs named background workers, restarting those that fail with
+// backoff, and stops them together on shutdown. The backoff starts over for a
+// worker that ran for stableAfter before failing.
+type Group struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	backoff resilience.Backoff
+
+	mu     sync.RWMutex
+	status map[string]*Status
+}
+
+// stableAfter is how long a worker must run before a failure is treated as a
+// new problem rather than the same one recurring
+const stableAfter = 5 * time.Minute
+
+// NewGroup creates a new, empty worker group
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		backoff: resilience.Backoff{
+			Initial:    time.Second,
+			Max:        time.Minute,
+			ResetAfter: stableAfter,
+		},
+		status: make(map[string]*Status),
+	}
+}
+
+// Go starts fn in its own goroutine under the given name. If fn returns an
+// error before the group is stopped it is restarted after a backoff; if it
+// returns nil it is done and not restarted.
+func (g *Group) Go(name string, fn Func) {
+	g.mu.Lock()
+	g.status[name] = &Status{Running: true, StartedAt: time.Now()}
+	g.mu.Unlock()
+
+	backoff := g.backoff
+	backoff.OnRetry = func(attempt int, err error, wait time.Duration) {
+		g.mu.Lock()
+		s := g.status[name]
+		s.Running = false
+		s.Error = err.Error()
+		s.LastError = err.Error()
+		g.mu.Unlock()
+		logging.Errorf("Worker %s stopped with error: %v; restarting in %s", name, err, wait.Round(time.Millisecond))
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		attempt := 0
+		resilience.Retry(g.ctx, backoff, func(error) bool { return true }, func(ctx context.Context) error {
+			if attempt++; attempt > 1 {
+				g.mu.Lock()
+				s := g.status[name]
+				s.Running = true
+				s.StartedAt = time.Now()
+				s.Restarts++
+				s.Error = ""
+				g.mu.Unlock()
+			}
+			logging.Infof("Worker %s started", name)
+			return fn(ctx)
+		})
+
+		g.mu.Lock()
+		g.status[name].Running = false
+		g.mu.Unlock()
+		logging.Infof("Worker %s stopped", name)
+	}()
+}
+
+// Status returns a snapshot of every worker's state keyed by name
+func (g *Group) Status() map[string]Status {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	snapshot := make(map[string]Status, len(g.status))
+	for name, s := range g.status {
+		snapshot[name] = *s
+	}
+	return snapshot
+}
+
+// Stop cancels all workers and waits for them to return or for ctx to expire
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"todo-app/internal/resilience"
+)
+
+func TestGroupRestartsFailedWorker(t *testing.T) {
+	g := NewGroup()
+	g.backoff = resilience.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+	failed := make(chan struct{})
+	restarted := make(chan struct{})
+	runs := 0
+	g.Go("flaky", func(ctx context.Context) error {
+		if runs++; runs == 1 {
+			close(failed)
+			return errors.New("connection lost")
+		}
+		close(restarted)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	<-failed
+	select {
+	case <-restarted:
+	case <-time.After(time.Second):
+		t.Fatal("worker was not restarted after failing")
+	}
+
+	// Status is written just before the restarted run starts
+	s := g.Status()["flaky"]
+	if !s.Running || s.Error != "" || s.Restarts != 1 || s.LastError != "connection lost" {
+		t.Fatalf("status: got %+v, want running after 1 restart with the failure kept as last_error", s)
+	}
+
+	if err := g.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if s := g.Status()["flaky"]; s.Running {
+		t.Fatalf("status after Stop: got %+v, want stopped", s)
+	}
+}
+
+func TestGroupDoesNotRestartFinishedWorker(t *testing.T) {
+	g := NewGroup()
+	g.backoff = resilience.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+	runs := make(chan struct{}, 2)
+	g.Go("once", func(ctx context.Context) error {
+		runs <- struct{}{}
+		return nil
+	})
+
+	<-runs
+	time.Sleep(20 * time.Millisecond)
+	if err := g.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Fatal("worker that returned nil was restarted")
+	}
+	if s := g.Status()["once"]; s.Running || s.Error != "" {
+		t.Fatalf("status: got %+v, want stopped without error", s)
+	}
+}
+
+func TestGroupResetsBackoffAfterAStableRun(t *testing.T) {
+	g := NewGroup()
+	g.backoff = resilience.Backoff{Initial: time.Millisecond, Max: 10 * time.Second, ResetAfter: 100 * time.Millisecond}
+
+	// Eight quick failures grow the wait, then a run longer than ResetAfter fails
+	const quick = 8
+	var starts, ends []time.Time
+	done := make(chan struct{})
+	g.Go("flaky", func(ctx context.Context) error {
+		starts = append(starts, time.Now())
+		defer func() { ends = append(ends, time.Now()) }()
+		switch n := len(starts); {
+		case n <= quick:
+			return errors.New("connection refused")
+		case n == quick+1:
+			time.Sleep(150 * time.Millisecond)
+			return errors.New("connection lost")
+		default:
+			close(done)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+	})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker was not restarted after its stable run")
+	}
+	if err := g.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	// The wait before the stable run is at least half of a 128ms ceiling; the
+	// one after it starts again from 1ms
+	if grown := starts[quick].Sub(ends[quick-1]); grown < 50*time.Millisecond {
+		t.Fatalf("wait after %d quick failures: got %s, want the backoff to have grown", quick, grown)
+	}
+	if reset := starts[quick+1].Sub(ends[quick]); reset >= 50*time.Millisecond {
+		t.Fatalf("wait after the stable run: got %s, want the backoff reset", reset)
+	}
+}
@@ -0,0 +1,3 @@
+-- Full-text search over title and description
+CREATE INDEX idx_todos_search ON todos
+    USING GIN (to_tsvector('simple', title || ' ' || COALESCE(description, '')));
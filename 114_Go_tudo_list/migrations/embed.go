@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Postgres holds the PostgreSQL migration files compiled into the binary
+//
+//go:embed *.sql
+var Postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLite holds the SQLite migration files compiled into the binary
+var SQLite = mustSub(sqliteFiles, "sqlite")
+
+//go:embed mysql/*.sql
+var mysqlFiles embed.FS
+
+// MySQL holds the MySQL/MariaDB migration files compiled into the binary
+var MySQL = mustSub(mysqlFiles, "mysql")
+
+//go:embed cassandra/*.cql
+var cassandraFiles embed.FS
+
+// Cassandra holds the idempotent CQL schema for the cassandra driver
+var Cassandra = mustSub(cassandraFiles, "cassandra")
+
+// mustSub returns the subtree of an embedded directory
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,17 @@
+-- UUIDs are stored in their canonical 36-character form so rows stay readable from the mysql client
+CREATE TABLE IF NOT EXISTS todos (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    priority ENUM('low', 'medium', 'high') NOT NULL DEFAULT 'medium',
+    due_date DATETIME(6) NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    -- ON UPDATE replaces the plpgsql trigger used by the PostgreSQL schema
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    INDEX idx_todos_completed (completed),
+    INDEX idx_todos_priority (priority),
+    INDEX idx_todos_due_date (due_date),
+    INDEX idx_todos_created_at (created_at),
+    FULLTEXT INDEX idx_todos_search (title, description)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
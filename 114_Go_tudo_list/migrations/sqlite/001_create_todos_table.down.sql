@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS todos_fts_update;
+DROP TRIGGER IF EXISTS todos_fts_delete;
+DROP TRIGGER IF EXISTS todos_fts_insert;
+DROP TABLE IF EXISTS todos_fts;
+DROP INDEX IF EXISTS idx_todos_created_at;
+DROP INDEX IF EXISTS idx_todos_due_date;
+DROP INDEX IF EXISTS idx_todos_priority;
+DROP INDEX IF EXISTS idx_todos_completed;
+DROP TABLE IF EXISTS todos;
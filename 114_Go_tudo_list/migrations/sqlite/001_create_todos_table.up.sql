@@ -0,0 +1,37 @@
+-- Timestamps are stored as fixed-width UTC RFC 3339 text so they sort chronologically
+CREATE TABLE IF NOT EXISTS todos (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL CHECK (length(title) BETWEEN 1 AND 200),
+    description TEXT NOT NULL DEFAULT '',
+    completed INTEGER NOT NULL DEFAULT 0 CHECK (completed IN (0, 1)),
+    priority TEXT NOT NULL DEFAULT 'medium' CHECK (priority IN ('low', 'medium', 'high')),
+    due_date TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_todos_completed ON todos(completed);
+CREATE INDEX idx_todos_priority ON todos(priority);
+CREATE INDEX idx_todos_due_date ON todos(due_date);
+CREATE INDEX idx_todos_created_at ON todos(created_at);
+
+-- Full-text index over title and description, kept in sync by triggers
+CREATE VIRTUAL TABLE todos_fts USING fts5(
+    title,
+    description,
+    content = 'todos',
+    content_rowid = 'rowid'
+);
+
+CREATE TRIGGER todos_fts_insert AFTER INSERT ON todos BEGIN
+    INSERT INTO todos_fts (rowid, title, description) VALUES (new.rowid, new.title, new.description);
+END;
+
+CREATE TRIGGER todos_fts_delete AFTER DELETE ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+END;
+
+CREATE TRIGGER todos_fts_update AFTER UPDATE ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+    INSERT INTO todos_fts (rowid, title, description) VALUES (new.rowid, new.title, new.description);
+END;
@@ -0,0 +1,70 @@
+package todopb
+
+import (
+	"strings"
+	"time"
+
+	"todo-app/internal/domain"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrInvalidDueDate is returned for a due date outside the range of timestamps
+var ErrInvalidDueDate = &domain.Error{Kind: domain.KindInvalid, Code: "invalid_due_date", Field: "due_date", Message: "due_date must be a valid timestamp"}
+
+// NewTodoItem converts a todo to its message
+func NewTodoItem(todo *domain.Todo) *TodoItem {
+	item := &TodoItem{
+		Id:          todo.ID.String(),
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Priority:    PriorityOf(todo.Priority),
+	}
+	if todo.DueDate != nil {
+		item.DueDate = timestamppb.New(*todo.DueDate)
+	}
+	if !todo.CreatedAt.IsZero() {
+		item.CreatedAt = timestamppb.New(todo.CreatedAt)
+	}
+	if !todo.UpdatedAt.IsZero() {
+		item.UpdatedAt = timestamppb.New(todo.UpdatedAt)
+	}
+	return item
+}
+
+// NewTodoList converts todos to a list message
+func NewTodoList(todos []*domain.Todo) *TodoList {
+	list := &TodoList{Items: make([]*TodoItem, 0, len(todos))}
+	for _, todo := range todos {
+		list.Items = append(list.Items, NewTodoItem(todo))
+	}
+	return list
+}
+
+// PriorityOf returns the enum value of a stored priority; a priority that
+// was not read is unspecified
+func PriorityOf(priority string) Priority {
+	return Priority(Priority_value["PRIORITY_"+strings.ToUpper(priority)])
+}
+
+// Value returns the stored priority of p: "" for unspecified, so the default
+// applies, and the name of unknown values so the service rejects them
+func (p Priority) Value() string {
+	if p == Priority_PRIORITY_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(p.String(), "PRIORITY_"))
+}
+
+// TimeOf returns the time ts holds, or nil
+func TimeOf(ts *timestamppb.Timestamp) (*time.Time, error) {
+	if ts == nil {
+		return nil, nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return nil, ErrInvalidDueDate
+	}
+	t := ts.AsTime()
+	return &t, nil
+}
@@ -0,0 +1,949 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: proto/todo.proto
+
+// Bản mở rộng của todo.proto trong 102_nodejs_grpc: giữ nguyên package,
+// service, các RPC và số thứ tự field cũ nên client cũ vẫn gọi được; chỉ thêm
+// field và RPC mới.
+
+package todopb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Mức độ ưu tiên. Khi ghi, PRIORITY_UNSPECIFIED nghĩa là MEDIUM; khi đọc, nó chỉ
+// xuất hiện nếu read_mask không có priority
+type Priority int32
+
+const (
+	Priority_PRIORITY_UNSPECIFIED Priority = 0
+	Priority_PRIORITY_LOW         Priority = 1
+	Priority_PRIORITY_MEDIUM      Priority = 2
+	Priority_PRIORITY_HIGH        Priority = 3
+)
+
+// Enum value maps for Priority.
+var (
+	Priority_name = map[int32]string{
+		0: "PRIORITY_UNSPECIFIED",
+		1: "PRIORITY_LOW",
+		2: "PRIORITY_MEDIUM",
+		3: "PRIORITY_HIGH",
+	}
+	Priority_value = map[string]int32{
+		"PRIORITY_UNSPECIFIED": 0,
+		"PRIORITY_LOW":         1,
+		"PRIORITY_MEDIUM":      2,
+		"PRIORITY_HIGH":        3,
+	}
+)
+
+func (x Priority) Enum() *Priority {
+	p := new(Priority)
+	*p = x
+	return p
+}
+
+func (x Priority) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Priority) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_todo_proto_enumTypes[0].Descriptor()
+}
+
+func (Priority) Type() protoreflect.EnumType {
+	return &file_proto_todo_proto_enumTypes[0]
+}
+
+func (x Priority) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Priority.Descriptor instead.
+func (Priority) EnumDescriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{0}
+}
+
+type TodoStatus int32
+
+const (
+	TodoStatus_TODO_STATUS_UNSPECIFIED TodoStatus = 0
+	TodoStatus_TODO_STATUS_PENDING     TodoStatus = 1
+	TodoStatus_TODO_STATUS_COMPLETED   TodoStatus = 2
+)
+
+// Enum value maps for TodoStatus.
+var (
+	TodoStatus_name = map[int32]string{
+		0: "TODO_STATUS_UNSPECIFIED",
+		1: "TODO_STATUS_PENDING",
+		2: "TODO_STATUS_COMPLETED",
+	}
+	TodoStatus_value = map[string]int32{
+		"TODO_STATUS_UNSPECIFIED": 0,
+		"TODO_STATUS_PENDING":     1,
+		"TODO_STATUS_COMPLETED":   2,
+	}
+)
+
+func (x TodoStatus) Enum() *TodoStatus {
+	p := new(TodoStatus)
+	*p = x
+	return p
+}
+
+func (x TodoStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TodoStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_todo_proto_enumTypes[1].Descriptor()
+}
+
+func (TodoStatus) Type() protoreflect.EnumType {
+	return &file_proto_todo_proto_enumTypes[1]
+}
+
+func (x TodoStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TodoStatus.Descriptor instead.
+func (TodoStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{1}
+}
+
+type TodoEventType int32
+
+const (
+	TodoEventType_TODO_EVENT_TYPE_UNSPECIFIED TodoEventType = 0
+	TodoEventType_TODO_EVENT_TYPE_CREATED     TodoEventType = 1
+	TodoEventType_TODO_EVENT_TYPE_UPDATED     TodoEventType = 2
+	TodoEventType_TODO_EVENT_TYPE_DELETED     TodoEventType = 3
+)
+
+// Enum value maps for TodoEventType.
+var (
+	TodoEventType_name = map[int32]string{
+		0: "TODO_EVENT_TYPE_UNSPECIFIED",
+		1: "TODO_EVENT_TYPE_CREATED",
+		2: "TODO_EVENT_TYPE_UPDATED",
+		3: "TODO_EVENT_TYPE_DELETED",
+	}
+	TodoEventType_value = map[string]int32{
+		"TODO_EVENT_TYPE_UNSPECIFIED": 0,
+		"TODO_EVENT_TYPE_CREATED":     1,
+		"TODO_EVENT_TYPE_UPDATED":     2,
+		"TODO_EVENT_TYPE_DELETED":     3,
+	}
+)
+
+func (x TodoEventType) Enum() *TodoEventType {
+	p := new(TodoEventType)
+	*p = x
+	return p
+}
+
+func (x TodoEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TodoEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_todo_proto_enumTypes[2].Descriptor()
+}
+
+func (TodoEventType) Type() protoreflect.EnumType {
+	return &file_proto_todo_proto_enumTypes[2]
+}
+
+func (x TodoEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TodoEventType.Descriptor instead.
+func (TodoEventType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{2}
+}
+
+// Định nghĩa cấu trúc cho một công việc
+type TodoItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Completed     bool                   `protobuf:"varint,4,opt,name=completed,proto3" json:"completed,omitempty"`
+	Priority      Priority               `protobuf:"varint,5,opt,name=priority,proto3,enum=todo.Priority" json:"priority,omitempty"`
+	DueDate       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=due_date,json=dueDate,proto3" json:"due_date,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TodoItem) Reset() {
+	*x = TodoItem{}
+	mi := &file_proto_todo_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TodoItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TodoItem) ProtoMessage() {}
+
+func (x *TodoItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TodoItem.ProtoReflect.Descriptor instead.
+func (*TodoItem) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TodoItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TodoItem) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *TodoItem) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *TodoItem) GetCompleted() bool {
+	if x != nil {
+		return x.Completed
+	}
+	return false
+}
+
+func (x *TodoItem) GetPriority() Priority {
+	if x != nil {
+		return x.Priority
+	}
+	return Priority_PRIORITY_UNSPECIFIED
+}
+
+func (x *TodoItem) GetDueDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueDate
+	}
+	return nil
+}
+
+func (x *TodoItem) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *TodoItem) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// Định nghĩa ID của công việc
+type TodoId struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TodoId) Reset() {
+	*x = TodoId{}
+	mi := &file_proto_todo_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TodoId) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TodoId) ProtoMessage() {}
+
+func (x *TodoId) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TodoId.ProtoReflect.Descriptor instead.
+func (*TodoId) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TodoId) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Danh sách công việc
+type TodoList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*TodoItem            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TodoList) Reset() {
+	*x = TodoList{}
+	mi := &file_proto_todo_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TodoList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TodoList) ProtoMessage() {}
+
+func (x *TodoList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TodoList.ProtoReflect.Descriptor instead.
+func (*TodoList) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TodoList) GetItems() []*TodoItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// Message rỗng cho các request/response không cần tham số
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_proto_todo_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{3}
+}
+
+type PatchTodoRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// todo.id là công việc cần sửa
+	Todo *TodoItem `protobuf:"bytes,1,opt,name=todo,proto3" json:"todo,omitempty"`
+	// Các field của todo được ghi: title, description, priority, completed,
+	// due_date. Field có trong mask nhưng để trống được trả về mặc định; không
+	// có mask thì ghi mọi field khác rỗng.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PatchTodoRequest) Reset() {
+	*x = PatchTodoRequest{}
+	mi := &file_proto_todo_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatchTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatchTodoRequest) ProtoMessage() {}
+
+func (x *PatchTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatchTodoRequest.ProtoReflect.Descriptor instead.
+func (*PatchTodoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PatchTodoRequest) GetTodo() *TodoItem {
+	if x != nil {
+		return x.Todo
+	}
+	return nil
+}
+
+func (x *PatchTodoRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type ListTodosRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Lấy các công việc này trong một lần đọc, theo thứ tự đã cho, bỏ qua ID
+	// không tồn tại. Khi có ids thì search và status bị bỏ qua.
+	Ids []string `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	// Tìm mọi từ trong title hoặc description
+	Search string     `protobuf:"bytes,2,opt,name=search,proto3" json:"search,omitempty"`
+	Status TodoStatus `protobuf:"varint,3,opt,name=status,proto3,enum=todo.TodoStatus" json:"status,omitempty"`
+	// Chỉ đọc các field này (như ?fields= của REST); id luôn có
+	ReadMask      *fieldmaskpb.FieldMask `protobuf:"bytes,4,opt,name=read_mask,json=readMask,proto3" json:"read_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTodosRequest) Reset() {
+	*x = ListTodosRequest{}
+	mi := &file_proto_todo_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTodosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTodosRequest) ProtoMessage() {}
+
+func (x *ListTodosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTodosRequest.ProtoReflect.Descriptor instead.
+func (*ListTodosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListTodosRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *ListTodosRequest) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *ListTodosRequest) GetStatus() TodoStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TodoStatus_TODO_STATUS_UNSPECIFIED
+}
+
+func (x *ListTodosRequest) GetReadMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.ReadMask
+	}
+	return nil
+}
+
+type PriorityCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Priority      Priority               `protobuf:"varint,1,opt,name=priority,proto3,enum=todo.Priority" json:"priority,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriorityCount) Reset() {
+	*x = PriorityCount{}
+	mi := &file_proto_todo_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriorityCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriorityCount) ProtoMessage() {}
+
+func (x *PriorityCount) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriorityCount.ProtoReflect.Descriptor instead.
+func (*PriorityCount) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PriorityCount) GetPriority() Priority {
+	if x != nil {
+		return x.Priority
+	}
+	return Priority_PRIORITY_UNSPECIFIED
+}
+
+func (x *PriorityCount) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type TodoStats struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Total     int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Completed int32                  `protobuf:"varint,2,opt,name=completed,proto3" json:"completed,omitempty"`
+	Pending   int32                  `protobuf:"varint,3,opt,name=pending,proto3" json:"pending,omitempty"`
+	// Công việc chưa xong đã quá hạn
+	Overdue       int32            `protobuf:"varint,4,opt,name=overdue,proto3" json:"overdue,omitempty"`
+	ByPriority    []*PriorityCount `protobuf:"bytes,5,rep,name=by_priority,json=byPriority,proto3" json:"by_priority,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TodoStats) Reset() {
+	*x = TodoStats{}
+	mi := &file_proto_todo_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TodoStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TodoStats) ProtoMessage() {}
+
+func (x *TodoStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TodoStats.ProtoReflect.Descriptor instead.
+func (*TodoStats) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TodoStats) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *TodoStats) GetCompleted() int32 {
+	if x != nil {
+		return x.Completed
+	}
+	return 0
+}
+
+func (x *TodoStats) GetPending() int32 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
+}
+
+func (x *TodoStats) GetOverdue() int32 {
+	if x != nil {
+		return x.Overdue
+	}
+	return 0
+}
+
+func (x *TodoStats) GetByPriority() []*PriorityCount {
+	if x != nil {
+		return x.ByPriority
+	}
+	return nil
+}
+
+type WatchTodosRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Chỉ nhận thay đổi của công việc này; để trống để nhận mọi thay đổi
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchTodosRequest) Reset() {
+	*x = WatchTodosRequest{}
+	mi := &file_proto_todo_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchTodosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTodosRequest) ProtoMessage() {}
+
+func (x *WatchTodosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTodosRequest.ProtoReflect.Descriptor instead.
+func (*WatchTodosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchTodosRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type TodoEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  TodoEventType          `protobuf:"varint,1,opt,name=type,proto3,enum=todo.TodoEventType" json:"type,omitempty"`
+	Id    string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	// Công việc sau khi thay đổi; không có khi đã bị xóa
+	Todo          *TodoItem `protobuf:"bytes,3,opt,name=todo,proto3" json:"todo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TodoEvent) Reset() {
+	*x = TodoEvent{}
+	mi := &file_proto_todo_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TodoEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TodoEvent) ProtoMessage() {}
+
+func (x *TodoEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_todo_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TodoEvent.ProtoReflect.Descriptor instead.
+func (*TodoEvent) Descriptor() ([]byte, []int) {
+	return file_proto_todo_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TodoEvent) GetType() TodoEventType {
+	if x != nil {
+		return x.Type
+	}
+	return TodoEventType_TODO_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *TodoEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TodoEvent) GetTodo() *TodoItem {
+	if x != nil {
+		return x.Todo
+	}
+	return nil
+}
+
+var File_proto_todo_proto protoreflect.FileDescriptor
+
+const file_proto_todo_proto_rawDesc = "" +
+	"\n" +
+	"\x10proto/todo.proto\x12\x04todo\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc9\x02\n" +
+	"\bTodoItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1c\n" +
+	"\tcompleted\x18\x04 \x01(\bR\tcompleted\x12*\n" +
+	"\bpriority\x18\x05 \x01(\x0e2\x0e.todo.PriorityR\bpriority\x125\n" +
+	"\bdue_date\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\adueDate\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x18\n" +
+	"\x06TodoId\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"0\n" +
+	"\bTodoList\x12$\n" +
+	"\x05items\x18\x01 \x03(\v2\x0e.todo.TodoItemR\x05items\"\a\n" +
+	"\x05Empty\"s\n" +
+	"\x10PatchTodoRequest\x12\"\n" +
+	"\x04todo\x18\x01 \x01(\v2\x0e.todo.TodoItemR\x04todo\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"\x9f\x01\n" +
+	"\x10ListTodosRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\x12\x16\n" +
+	"\x06search\x18\x02 \x01(\tR\x06search\x12(\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x10.todo.TodoStatusR\x06status\x127\n" +
+	"\tread_mask\x18\x04 \x01(\v2\x1a.google.protobuf.FieldMaskR\breadMask\"Q\n" +
+	"\rPriorityCount\x12*\n" +
+	"\bpriority\x18\x01 \x01(\x0e2\x0e.todo.PriorityR\bpriority\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"\xa9\x01\n" +
+	"\tTodoStats\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12\x1c\n" +
+	"\tcompleted\x18\x02 \x01(\x05R\tcompleted\x12\x18\n" +
+	"\apending\x18\x03 \x01(\x05R\apending\x12\x18\n" +
+	"\aoverdue\x18\x04 \x01(\x05R\aoverdue\x124\n" +
+	"\vby_priority\x18\x05 \x03(\v2\x13.todo.PriorityCountR\n" +
+	"byPriority\"#\n" +
+	"\x11WatchTodosRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"h\n" +
+	"\tTodoEvent\x12'\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x13.todo.TodoEventTypeR\x04type\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\"\n" +
+	"\x04todo\x18\x03 \x01(\v2\x0e.todo.TodoItemR\x04todo*^\n" +
+	"\bPriority\x12\x18\n" +
+	"\x14PRIORITY_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fPRIORITY_LOW\x10\x01\x12\x13\n" +
+	"\x0fPRIORITY_MEDIUM\x10\x02\x12\x11\n" +
+	"\rPRIORITY_HIGH\x10\x03*]\n" +
+	"\n" +
+	"TodoStatus\x12\x1b\n" +
+	"\x17TODO_STATUS_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13TODO_STATUS_PENDING\x10\x01\x12\x19\n" +
+	"\x15TODO_STATUS_COMPLETED\x10\x02*\x87\x01\n" +
+	"\rTodoEventType\x12\x1f\n" +
+	"\x1bTODO_EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17TODO_EVENT_TYPE_CREATED\x10\x01\x12\x1b\n" +
+	"\x17TODO_EVENT_TYPE_UPDATED\x10\x02\x12\x1b\n" +
+	"\x17TODO_EVENT_TYPE_DELETED\x10\x032\xde\x03\n" +
+	"\vTodoService\x12,\n" +
+	"\n" +
+	"CreateTodo\x12\x0e.todo.TodoItem\x1a\x0e.todo.TodoItem\x12'\n" +
+	"\bGetTodos\x12\v.todo.Empty\x1a\x0e.todo.TodoList\x12'\n" +
+	"\aGetTodo\x12\f.todo.TodoId\x1a\x0e.todo.TodoItem\x12,\n" +
+	"\n" +
+	"UpdateTodo\x12\x0e.todo.TodoItem\x1a\x0e.todo.TodoItem\x12'\n" +
+	"\n" +
+	"DeleteTodo\x12\f.todo.TodoId\x1a\v.todo.Empty\x123\n" +
+	"\tPatchTodo\x12\x16.todo.PatchTodoRequest\x1a\x0e.todo.TodoItem\x12*\n" +
+	"\n" +
+	"ToggleTodo\x12\f.todo.TodoId\x1a\x0e.todo.TodoItem\x123\n" +
+	"\tListTodos\x12\x16.todo.ListTodosRequest\x1a\x0e.todo.TodoList\x12(\n" +
+	"\bGetStats\x12\v.todo.Empty\x1a\x0f.todo.TodoStats\x128\n" +
+	"\n" +
+	"WatchTodos\x12\x17.todo.WatchTodosRequest\x1a\x0f.todo.TodoEvent0\x01B\x17Z\x15todo-app/proto;todopbb\x06proto3"
+
+var (
+	file_proto_todo_proto_rawDescOnce sync.Once
+	file_proto_todo_proto_rawDescData []byte
+)
+
+func file_proto_todo_proto_rawDescGZIP() []byte {
+	file_proto_todo_proto_rawDescOnce.Do(func() {
+		file_proto_todo_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_todo_proto_rawDesc), len(file_proto_todo_proto_rawDesc)))
+	})
+	return file_proto_todo_proto_rawDescData
+}
+
+var file_proto_todo_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_proto_todo_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_proto_todo_proto_goTypes = []any{
+	(Priority)(0),                 // 0: todo.Priority
+	(TodoStatus)(0),               // 1: todo.TodoStatus
+	(TodoEventType)(0),            // 2: todo.TodoEventType
+	(*TodoItem)(nil),              // 3: todo.TodoItem
+	(*TodoId)(nil),                // 4: todo.TodoId
+	(*TodoList)(nil),              // 5: todo.TodoList
+	(*Empty)(nil),                 // 6: todo.Empty
+	(*PatchTodoRequest)(nil),      // 7: todo.PatchTodoRequest
+	(*ListTodosRequest)(nil),      // 8: todo.ListTodosRequest
+	(*PriorityCount)(nil),         // 9: todo.PriorityCount
+	(*TodoStats)(nil),             // 10: todo.TodoStats
+	(*WatchTodosRequest)(nil),     // 11: todo.WatchTodosRequest
+	(*TodoEvent)(nil),             // 12: todo.TodoEvent
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil), // 14: google.protobuf.FieldMask
+}
+var file_proto_todo_proto_depIdxs = []int32{
+	0,  // 0: todo.TodoItem.priority:type_name -> todo.Priority
+	13, // 1: todo.TodoItem.due_date:type_name -> google.protobuf.Timestamp
+	13, // 2: todo.TodoItem.created_at:type_name -> google.protobuf.Timestamp
+	13, // 3: todo.TodoItem.updated_at:type_name -> google.protobuf.Timestamp
+	3,  // 4: todo.TodoList.items:type_name -> todo.TodoItem
+	3,  // 5: todo.PatchTodoRequest.todo:type_name -> todo.TodoItem
+	14, // 6: todo.PatchTodoRequest.update_mask:type_name -> google.protobuf.FieldMask
+	1,  // 7: todo.ListTodosRequest.status:type_name -> todo.TodoStatus
+	14, // 8: todo.ListTodosRequest.read_mask:type_name -> google.protobuf.FieldMask
+	0,  // 9: todo.PriorityCount.priority:type_name -> todo.Priority
+	9,  // 10: todo.TodoStats.by_priority:type_name -> todo.PriorityCount
+	2,  // 11: todo.TodoEvent.type:type_name -> todo.TodoEventType
+	3,  // 12: todo.TodoEvent.todo:type_name -> todo.TodoItem
+	3,  // 13: todo.TodoService.CreateTodo:input_type -> todo.TodoItem
+	6,  // 14: todo.TodoService.GetTodos:input_type -> todo.Empty
+	4,  // 15: todo.TodoService.GetTodo:input_type -> todo.TodoId
+	3,  // 16: todo.TodoService.UpdateTodo:input_type -> todo.TodoItem
+	4,  // 17: todo.TodoService.DeleteTodo:input_type -> todo.TodoId
+	7,  // 18: todo.TodoService.PatchTodo:input_type -> todo.PatchTodoRequest
+	4,  // 19: todo.TodoService.ToggleTodo:input_type -> todo.TodoId
+	8,  // 20: todo.TodoService.ListTodos:input_type -> todo.ListTodosRequest
+	6,  // 21: todo.TodoService.GetStats:input_type -> todo.Empty
+	11, // 22: todo.TodoService.WatchTodos:input_type -> todo.WatchTodosRequest
+	3,  // 23: todo.TodoService.CreateTodo:output_type -> todo.TodoItem
+	5,  // 24: todo.TodoService.GetTodos:output_type -> todo.TodoList
+	3,  // 25: todo.TodoService.GetTodo:output_type -> todo.TodoItem
+	3,  // 26: todo.TodoService.UpdateTodo:output_type -> todo.TodoItem
+	6,  // 27: todo.TodoService.DeleteTodo:output_type -> todo.Empty
+	3,  // 28: todo.TodoService.PatchTodo:output_type -> todo.TodoItem
+	3,  // 29: todo.TodoService.ToggleTodo:output_type -> todo.TodoItem
+	5,  // 30: todo.TodoService.ListTodos:output_type -> todo.TodoList
+	10, // 31: todo.TodoService.GetStats:output_type -> todo.TodoStats
+	12, // 32: todo.TodoService.WatchTodos:output_type -> todo.TodoEvent
+	23, // [23:33] is the sub-list for method output_type
+	13, // [13:23] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_proto_todo_proto_init() }
+func file_proto_todo_proto_init() {
+	if File_proto_todo_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_todo_proto_rawDesc), len(file_proto_todo_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_todo_proto_goTypes,
+		DependencyIndexes: file_proto_todo_proto_depIdxs,
+		EnumInfos:         file_proto_todo_proto_enumTypes,
+		MessageInfos:      file_proto_todo_proto_msgTypes,
+	}.Build()
+	File_proto_todo_proto = out.File
+	file_proto_todo_proto_goTypes = nil
+	file_proto_todo_proto_depIdxs = nil
+}
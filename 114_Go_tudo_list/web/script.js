@@ -66,7 +66,9 @@ async function apiRequest(endpoint, options = {}) {
         const data = await response.json();
         
         if (!response.ok) {
-            throw new Error(data.error || `HTTP error! status: ${response.status}`);
+            // Errors are application/problem+json; validation problems list each field
+            const fieldErrors = (data.errors || []).map(e => e.message).join(', ');
+            throw new Error(fieldErrors || data.detail || data.title || `HTTP error! status: ${response.status}`);
         }
         
         return data;